@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/emilyselwood/orbcalc/orbplot"
 	"gonum.org/v1/plot"
@@ -28,7 +29,7 @@ func main() {
 	p.X.Label.Text = "X (km)"
 	p.Y.Label.Text = "Y (km)"
 
-	if err := orbplot.PlotSolarSystemLines(p, true); err != nil {
+	if err := orbplot.PlotSolarSystemLines(p, time.Now(), true); err != nil {
 		log.Fatal(err)
 	}
 
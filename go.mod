@@ -10,7 +10,3 @@ require (
 )
 
 go 1.11
-
-replace (
-    github.com/emilyselwood/gompcreader => ../gompcreader
-)
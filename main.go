@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"io"
 	"log"
@@ -10,7 +9,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/emilyselwood/gompcreader"
+	"github.com/emilyselwood/orbcalc/orbcatalog"
 	"github.com/emilyselwood/orbcalc/orbconvert"
 	"github.com/emilyselwood/orbcalc/orbcore"
 
@@ -21,11 +20,15 @@ const monitoringInterval = 1 * time.Second
 const processors = 3
 const channelSize = 100000
 
-var inputfile = flag.String("in", "", "the minor planet center file to read")
-var outputfile = flag.String("out", "", "path to output file")
+var inputfile = flag.String("in", "", "the minor planet center file to read, MPCORB.DAT or mpcorb_extended.json, optionally gzip or bzip2 compressed")
+var outputfile = flag.String("out", "", "path to output file, gzip compressed if it ends in .gz")
 var count = flag.Int("count", 1000000, "number of records to run")
 var skip = flag.Int("skip", 0, "number of records from the begining to skip")
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
+var filter = flag.String("filter", "", "only process orbits matching this expression, for example 'a < 3.3 && e > 0.2'")
+var velocity = flag.Bool("velocity", false, "add vx,vy,vz velocity columns in km/s to the output")
+var header = flag.Bool("header", false, "start the output with a row naming the columns, csv only")
+var format = flag.String("format", "csv", "output format, csv, binary or binary32 for the binary format with float32 coordinates")
 
 /*
 An example program that uses the gompcreader and calculates the position in space for each object.
@@ -54,6 +57,15 @@ func main() {
 		log.Fatal("No output file prvided. Use the -out /path/to/outputfile")
 	}
 
+	if *format != "csv" && *format != "binary" && *format != "binary32" {
+		log.Fatalf("unknown output format %q, use csv, binary or binary32", *format)
+	}
+
+	query, err := orbcatalog.ParseQuery(*filter)
+	if err != nil {
+		log.Fatal("could not parse filter ", err)
+	}
+
 	// rate counters for each processing stage
 	counter1 := ratecounter.NewRateCounter(monitoringInterval)
 	counter2 := ratecounter.NewRateCounter(monitoringInterval)
@@ -80,7 +92,7 @@ func main() {
 
 	// Setup stage one which reads in the input file, parses the records from it and passes them on to the next stage.
 	readGroup.Add(1)
-	go stageRead(*inputfile, *count, *skip, stage1, &readGroup, counter1)
+	go stageRead(*inputfile, query, *count, *skip, stage1, &readGroup, counter1)
 
 	// Stage two progates an object forward one day and then passes it on.
 	for i := 0; i < processors; i++ {
@@ -104,20 +116,24 @@ func main() {
 	log.Println("done")
 }
 
-// stageRead opens a file using the gompcreader project and reads out orbital information.
-func stageRead(inputfile string, target int, skip int, output chan *orbcore.Orbit, wg *sync.WaitGroup, counter *ratecounter.RateCounter) {
-	mpcReader, err := gompcreader.NewMpcReader(inputfile)
+// stageRead opens a catalog file and reads out orbital information. Orbits that do not match the query are dropped
+// before they are counted.
+func stageRead(inputfile string, query *orbcatalog.Query, target int, skip int, output chan *orbcore.Orbit, wg *sync.WaitGroup, counter *ratecounter.RateCounter) {
+	reader, err := orbconvert.OpenOrbitReader(inputfile)
 	if err != nil {
-		log.Fatal("error creating mpcReader ", err)
+		log.Fatal("error creating reader ", err)
 	}
-	defer mpcReader.Close()
+	defer reader.Close()
 	defer close(output)
 	defer wg.Done()
 
 	var count int
-	result, err := mpcReader.ReadEntry()
+	orb, err := reader.ReadEntry()
 	for err == nil {
-		orb := orbconvert.ConvertFromMinorPlanet(result)
+		if !query.Match(orb) {
+			orb, err = reader.ReadEntry()
+			continue
+		}
 		if skip == 0 {
 			//fmt.Println(orb)
 			output <- orb
@@ -129,7 +145,7 @@ func stageRead(inputfile string, target int, skip int, output chan *orbcore.Orbi
 		if count >= target {
 			return
 		}
-		result, err = mpcReader.ReadEntry()
+		orb, err = reader.ReadEntry()
 	}
 	if err != io.EOF {
 		log.Fatal("error reading", err)
@@ -154,18 +170,34 @@ func stageMeanMotion(in chan *orbcore.Orbit, output chan *orbcore.Position, wg *
 func stageOutput(outputPath string, in chan *orbcore.Position, wg *sync.WaitGroup, counter *ratecounter.RateCounter) {
 	defer wg.Done()
 
-	f, err := os.Create(outputPath)
+	f, err := orbcore.CreateCompressed(outputPath)
 	if err != nil {
 		log.Fatal("error creating outputfile ", err)
 	}
-	defer f.Close()
 
-	w := bufio.NewWriterSize(f, 64*1024)
-	defer w.Flush()
+	var w interface {
+		WriteEntry(*orbcore.Position) error
+	}
+	var finish func() error
+	if *format == "csv" {
+		pw := orbcore.NewPositionWriter(f)
+		pw.Velocity = *velocity
+		pw.Header = *header
+		w, finish = pw, pw.Flush
+	} else {
+		bw := orbcore.NewBinaryPositionWriter(f)
+		bw.Velocity = *velocity
+		bw.Float32 = *format == "binary32"
+		w, finish = bw, bw.Close
+	}
+
 	for orb := range in {
-		w.WriteString(orb.String())
-		w.WriteRune('\n')
+		if err := w.WriteEntry(orb); err != nil {
+			log.Fatal("error writing output ", err)
+		}
 		counter.Incr(1)
 	}
-
+	if err := orbcore.FinishCompressed(f, finish); err != nil {
+		log.Fatal("error writing output ", err)
+	}
 }
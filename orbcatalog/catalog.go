@@ -0,0 +1,304 @@
+/*
+Package orbcatalog holds a set of orbits in memory so they can be looked up and queried without reading the catalog
+file again.
+*/
+package orbcatalog
+
+import (
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbconvert/designation"
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+Element identifies one of the orbital elements that the catalog keeps a sorted index of.
+*/
+type Element int
+
+/*
+The elements that can be used in range queries. Values are in the units people usually quote them in rather than the
+internal ones: the semimajor axis in AU and the inclination in degrees.
+*/
+const (
+	SemimajorAxis Element = iota
+	Eccentricity
+	Inclination
+	AbsoluteMagnitude
+	numElements
+)
+
+/*
+Value returns the value of this element for an orbit.
+*/
+func (e Element) Value(orb *orbcore.Orbit) float64 {
+	switch e {
+	case SemimajorAxis:
+		return orbconvert.KmToAu(orb.SemimajorAxis)
+	case Eccentricity:
+		return orb.OrbitalEccentricity
+	case Inclination:
+		return orbconvert.RadToDeg(orb.InclinationToTheEcliptic)
+	case AbsoluteMagnitude:
+		return orb.AbsoluteMagnitude
+	}
+	return 0
+}
+
+/*
+Bounds restricts an element to values between Min and Max inclusive.
+*/
+type Bounds struct {
+	Element Element
+	Min     float64
+	Max     float64
+}
+
+func (b Bounds) contains(orb *orbcore.Orbit) bool {
+	v := b.Element.Value(orb)
+	return v >= b.Min && v <= b.Max
+}
+
+/*
+Catalog is a read only, indexed set of orbits. It is safe to use from many goroutines at once.
+*/
+type Catalog struct {
+	orbits  []*orbcore.Orbit
+	ids     map[string]int
+	names   map[string]int
+	indexes [numElements][]int
+}
+
+/*
+New builds a catalog from a list of orbits. If more than one orbit has the same ID the last one wins for lookups.
+*/
+func New(orbits []*orbcore.Orbit) *Catalog {
+	c := Catalog{
+		orbits: orbits,
+		ids:    make(map[string]int, len(orbits)),
+		names:  make(map[string]int),
+	}
+
+	for i, orb := range orbits {
+		c.ids[orb.ID] = i
+		if orb.Metadata != nil {
+			for _, name := range names(orb.Metadata.ReadableDesignation) {
+				c.names[strings.ToLower(name)] = i
+			}
+		}
+	}
+
+	for e := Element(0); e < numElements; e++ {
+		index := make([]int, len(orbits))
+		for i := range index {
+			index[i] = i
+		}
+		sort.SliceStable(index, func(a, b int) bool {
+			return e.Value(orbits[index[a]]) < e.Value(orbits[index[b]])
+		})
+		c.indexes[e] = index
+	}
+
+	return &c
+}
+
+/*
+Load reads every orbit from a catalog file into a new Catalog. Any file that orbconvert.OpenOrbitReader understands
+can be used.
+*/
+func Load(path string) (*Catalog, error) {
+	reader, err := orbconvert.OpenOrbitReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return Read(reader)
+}
+
+/*
+Read builds a catalog from everything left in an orbit reader.
+*/
+func Read(reader orbconvert.OrbitReader) (*Catalog, error) {
+	var orbits []*orbcore.Orbit
+	orb, err := reader.ReadEntry()
+	for err == nil {
+		orbits = append(orbits, orb)
+		orb, err = reader.ReadEntry()
+	}
+	if err != io.EOF {
+		return nil, err
+	}
+	return New(orbits), nil
+}
+
+/*
+Len returns the number of orbits in the catalog.
+*/
+func (c *Catalog) Len() int {
+	return len(c.orbits)
+}
+
+/*
+Orbits returns all the orbits in the order they were loaded. The slice is shared and must not be modified.
+*/
+func (c *Catalog) Orbits() []*orbcore.Orbit {
+	return c.orbits
+}
+
+/*
+Lookup finds an orbit by its packed designation, a permanent number ("433" or "(433)"), a readable designation
+("1995 XA12") or a name ("Eros"). Names are not case sensitive.
+*/
+func (c *Catalog) Lookup(key string) (*orbcore.Orbit, bool) {
+	key = strings.TrimSpace(key)
+	if i, ok := c.ids[key]; ok {
+		return c.orbits[i], true
+	}
+	if packed, err := designation.Pack(key); err == nil {
+		if i, ok := c.ids[packed]; ok {
+			return c.orbits[i], true
+		}
+	}
+	if i, ok := c.names[strings.ToLower(key)]; ok {
+		return c.orbits[i], true
+	}
+	return nil, false
+}
+
+/*
+Range returns the orbits that fall within all of the given bounds, in the order they were loaded. The index of the
+first bound is used to find candidates so put the most selective one first. The returned slice is always a new one
+that the caller is free to modify, use Orbits to get every orbit without copying.
+*/
+func (c *Catalog) Range(bounds ...Bounds) []*orbcore.Orbit {
+	if len(bounds) == 0 {
+		return append([]*orbcore.Orbit(nil), c.orbits...)
+	}
+
+	first := bounds[0]
+	index := c.indexes[first.Element]
+	start := sort.Search(len(index), func(i int) bool {
+		return first.Element.Value(c.orbits[index[i]]) >= first.Min
+	})
+	end := sort.Search(len(index), func(i int) bool {
+		return first.Element.Value(c.orbits[index[i]]) > first.Max
+	})
+	if start >= end {
+		return nil
+	}
+
+	matches := make([]int, 0, end-start)
+	for _, i := range index[start:end] {
+		ok := true
+		for _, b := range bounds[1:] {
+			if !b.contains(c.orbits[i]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, i)
+		}
+	}
+	sort.Ints(matches)
+
+	result := make([]*orbcore.Orbit, len(matches))
+	for j, i := range matches {
+		result[j] = c.orbits[i]
+	}
+	return result
+}
+
+/*
+Filter returns the orbits for which keep returns true, in the order they were loaded. keep is called from several
+goroutines at once.
+*/
+func (c *Catalog) Filter(keep func(*orbcore.Orbit) bool) []*orbcore.Orbit {
+	chunks := Chunks(c.orbits, runtime.NumCPU())
+	results := make([][]*orbcore.Orbit, len(chunks))
+
+	var wg sync.WaitGroup
+	for n, chunk := range chunks {
+		wg.Add(1)
+		go func(n int, chunk []*orbcore.Orbit) {
+			defer wg.Done()
+			for _, orb := range chunk {
+				if keep(orb) {
+					results[n] = append(results[n], orb)
+				}
+			}
+		}(n, chunk)
+	}
+	wg.Wait()
+
+	var result []*orbcore.Orbit
+	for _, r := range results {
+		result = append(result, r...)
+	}
+	return result
+}
+
+/*
+Parallel splits the catalog into chunks and calls fn once for each of them from its own goroutine, using at most
+workers goroutines. It returns once every chunk has been processed.
+*/
+func (c *Catalog) Parallel(workers int, fn func(chunk []*orbcore.Orbit)) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	var wg sync.WaitGroup
+	for _, chunk := range Chunks(c.orbits, workers) {
+		wg.Add(1)
+		go func(chunk []*orbcore.Orbit) {
+			defer wg.Done()
+			fn(chunk)
+		}(chunk)
+	}
+	wg.Wait()
+}
+
+/*
+Chunks splits a list of orbits into at most n slices of roughly equal size. The slices share the backing array of the
+input.
+*/
+func Chunks(orbits []*orbcore.Orbit, n int) [][]*orbcore.Orbit {
+	if n < 1 {
+		n = 1
+	}
+	if n > len(orbits) {
+		n = len(orbits)
+	}
+
+	result := make([][]*orbcore.Orbit, 0, n)
+	for i := 0; i < n; i++ {
+		start := i * len(orbits) / n
+		end := (i + 1) * len(orbits) / n
+		result = append(result, orbits[start:end])
+	}
+	return result
+}
+
+// names pulls the parts of a readable designation an object could be looked up by, "(433) Eros" gives "(433) Eros"
+// and "Eros".
+func names(readable string) []string {
+	readable = strings.TrimSpace(readable)
+	if readable == "" {
+		return nil
+	}
+	result := []string{readable}
+	if strings.HasPrefix(readable, "(") {
+		if end := strings.Index(readable, ")"); end > 0 {
+			if rest := strings.TrimSpace(readable[end+1:]); rest != "" {
+				result = append(result, rest)
+			}
+		}
+	}
+	return result
+}
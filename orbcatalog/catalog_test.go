@@ -0,0 +1,142 @@
+package orbcatalog
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+func testOrbit(id string, readable string, a, e, i, h float64) *orbcore.Orbit {
+	return &orbcore.Orbit{
+		ID:                       id,
+		SemimajorAxis:            orbconvert.AuToKm(a),
+		OrbitalEccentricity:      e,
+		InclinationToTheEcliptic: orbconvert.DegToRad(i),
+		AbsoluteMagnitude:        h,
+		Metadata:                 &orbcore.Metadata{ReadableDesignation: readable},
+	}
+}
+
+func testCatalog() *Catalog {
+	return New([]*orbcore.Orbit{
+		testOrbit("00001", "(1) Ceres", 2.77, 0.079, 10.6, 3.53),
+		testOrbit("00433", "(433) Eros", 1.46, 0.223, 10.8, 10.4),
+		testOrbit("03200", "(3200) Phaethon", 1.27, 0.890, 22.3, 14.3),
+		testOrbit("K19A00A", "2019 AA", 3.1, 0.15, 2.5, 18.2),
+		testOrbit("A0001", "(100001) 1997 UZ", 2.4, 0.12, 5.1, 15.0),
+	})
+}
+
+func TestLookup(t *testing.T) {
+	c := testCatalog()
+
+	tests := map[string]string{
+		"00433":     "00433",
+		"433":       "00433",
+		"(433)":     "00433",
+		"eros":      "00433",
+		"(1) Ceres": "00001",
+		"2019 AA":   "K19A00A",
+		"K19A00A":   "K19A00A",
+		"100001":    "A0001",
+		"1997 UZ":   "A0001",
+	}
+	for key, expected := range tests {
+		orb, ok := c.Lookup(key)
+		if !ok {
+			t.Errorf("could not find %q", key)
+			continue
+		}
+		if orb.ID != expected {
+			t.Errorf("expected %q to find %v got %v", key, expected, orb.ID)
+		}
+	}
+
+	if _, ok := c.Lookup("Vesta"); ok {
+		t.Errorf("expected Vesta to be missing")
+	}
+}
+
+func TestRange(t *testing.T) {
+	c := testCatalog()
+
+	r := c.Range(Bounds{SemimajorAxis, 1.0, 2.5})
+	if ids(r) != "00433,03200,A0001" {
+		t.Errorf("unexpected semimajor axis range %v", ids(r))
+	}
+
+	r = c.Range(Bounds{SemimajorAxis, 1.0, 2.5}, Bounds{Eccentricity, 0.2, 1})
+	if ids(r) != "00433,03200" {
+		t.Errorf("unexpected combined range %v", ids(r))
+	}
+
+	r = c.Range(Bounds{Inclination, 10, 11}, Bounds{AbsoluteMagnitude, 0, 5})
+	if ids(r) != "00001" {
+		t.Errorf("unexpected inclination range %v", ids(r))
+	}
+
+	if r := c.Range(Bounds{AbsoluteMagnitude, 30, 40}); len(r) != 0 {
+		t.Errorf("expected nothing got %v", ids(r))
+	}
+	if r := c.Range(); len(r) != c.Len() {
+		t.Errorf("expected everything without bounds got %v", len(r))
+	}
+
+	r = c.Range()
+	r[0] = nil
+	if c.Orbits()[0] == nil {
+		t.Error("changing the result of Range changed the catalog")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	c := testCatalog()
+	r := c.Filter(func(orb *orbcore.Orbit) bool {
+		return orb.AbsoluteMagnitude > 12
+	})
+	if ids(r) != "03200,K19A00A,A0001" {
+		t.Errorf("unexpected filter result %v", ids(r))
+	}
+}
+
+func TestParallel(t *testing.T) {
+	c := testCatalog()
+	var seen int64
+	c.Parallel(3, func(chunk []*orbcore.Orbit) {
+		atomic.AddInt64(&seen, int64(len(chunk)))
+	})
+	if seen != int64(c.Len()) {
+		t.Errorf("expected to see %v orbits got %v", c.Len(), seen)
+	}
+}
+
+func TestChunks(t *testing.T) {
+	orbits := testCatalog().Orbits()
+	for n := 1; n <= 7; n++ {
+		total := 0
+		chunks := Chunks(orbits, n)
+		for _, chunk := range chunks {
+			if len(chunk) == 0 {
+				t.Errorf("empty chunk when splitting into %v", n)
+			}
+			total += len(chunk)
+		}
+		if total != len(orbits) {
+			t.Errorf("expected %v orbits splitting into %v got %v", len(orbits), n, total)
+		}
+	}
+	if len(Chunks(nil, 4)) != 0 {
+		t.Errorf("expected no chunks for no orbits")
+	}
+}
+
+func ids(orbits []*orbcore.Orbit) string {
+	var result []string
+	for _, orb := range orbits {
+		result = append(result, orb.ID)
+	}
+	return strings.Join(result, ",")
+}
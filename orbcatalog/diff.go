@@ -0,0 +1,186 @@
+package orbcatalog
+
+import (
+	"math"
+	"sort"
+
+	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbconvert/designation"
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+ChangeKind says how an object differs between two catalogs.
+*/
+type ChangeKind string
+
+/*
+The kinds of change Diff reports.
+*/
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Changed  ChangeKind = "changed"
+	Numbered ChangeKind = "numbered" // was known by a provisional designation and now has a number
+)
+
+/*
+Tolerances are the largest element differences that are not reported as a change. Distances are in AU and angles in
+degrees.
+*/
+type Tolerances struct {
+	SemimajorAxis     float64
+	Eccentricity      float64
+	Angle             float64
+	AbsoluteMagnitude float64
+}
+
+/*
+DefaultTolerances ignore differences that come from the rounding in MPCORB.DAT.
+*/
+var DefaultTolerances = Tolerances{
+	SemimajorAxis:     1e-6,
+	Eccentricity:      1e-6,
+	Angle:             1e-4,
+	AbsoluteMagnitude: 0.01,
+}
+
+/*
+ElementChange is a single element that moved by more than its tolerance.
+*/
+type ElementChange struct {
+	Element string  `json:"element"`
+	Old     float64 `json:"old"`
+	New     float64 `json:"new"`
+}
+
+/*
+Change describes one object that is different between two catalogs. Old is nil for added objects and New is nil for
+removed ones. Numbered objects have the provisional designation they used to have in OldID.
+*/
+type Change struct {
+	Kind     ChangeKind      `json:"kind"`
+	ID       string          `json:"id"`
+	OldID    string          `json:"old_id,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Elements []ElementChange `json:"elements,omitempty"`
+	Old      *orbcore.Orbit  `json:"-"`
+	New      *orbcore.Orbit  `json:"-"`
+}
+
+/*
+Diff compares two catalogs by designation. The elements are only compared when both orbits have the same epoch, moving
+an orbit to a new epoch also picks up the perturbations from the planets which two body propagation can not reproduce,
+so a routine epoch update on its own is not a change. The absolute magnitude is always compared. The result is sorted
+by ID.
+*/
+func Diff(old, new *Catalog, tol Tolerances) []Change {
+	var result []Change
+	matched := make(map[string]bool)
+
+	for _, n := range new.orbits {
+		if o, ok := old.byID(n.ID); ok {
+			matched[o.ID] = true
+			if elements := compareElements(o, n, tol); len(elements) > 0 {
+				result = append(result, Change{Kind: Changed, ID: n.ID, Name: readable(n), Elements: elements, Old: o, New: n})
+			}
+			continue
+		}
+
+		if o, ok := old.previousDesignation(n); ok {
+			if _, stillThere := new.byID(o.ID); !stillThere {
+				matched[o.ID] = true
+				result = append(result, Change{
+					Kind:     Numbered,
+					ID:       n.ID,
+					OldID:    o.ID,
+					Name:     readable(n),
+					Elements: compareElements(o, n, tol),
+					Old:      o,
+					New:      n,
+				})
+				continue
+			}
+		}
+
+		result = append(result, Change{Kind: Added, ID: n.ID, Name: readable(n), New: n})
+	}
+
+	for _, o := range old.orbits {
+		if !matched[o.ID] {
+			if _, ok := new.byID(o.ID); !ok {
+				result = append(result, Change{Kind: Removed, ID: o.ID, Name: readable(o), Old: o})
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func (c *Catalog) byID(id string) (*orbcore.Orbit, bool) {
+	i, ok := c.ids[id]
+	if !ok {
+		return nil, false
+	}
+	return c.orbits[i], true
+}
+
+// previousDesignation finds the orbit a newly numbered object had under its provisional designation, from the
+// principal designation in its metadata. This works for objects numbered and named at once, but MPCORB.DAT only gives
+// the principal designation of numbered objects without a name, the extended json catalog gives it for all of them.
+func (c *Catalog) previousDesignation(orb *orbcore.Orbit) (*orbcore.Orbit, bool) {
+	if orb.Metadata == nil || orb.Metadata.PrincipalDesignation == "" {
+		return nil, false
+	}
+	packed, err := designation.Pack(orb.Metadata.PrincipalDesignation)
+	if err != nil || packed == orb.ID {
+		return nil, false
+	}
+	return c.byID(packed)
+}
+
+func readable(orb *orbcore.Orbit) string {
+	if orb.Metadata == nil {
+		return ""
+	}
+	return orb.Metadata.ReadableDesignation
+}
+
+func compareElements(o, n *orbcore.Orbit, tol Tolerances) []ElementChange {
+	var result []ElementChange
+	check := func(name string, a, b, limit float64) {
+		if math.Abs(a-b) > limit {
+			result = append(result, ElementChange{Element: name, Old: a, New: b})
+		}
+	}
+	angle := func(name string, a, b float64) {
+		a = normalise(orbconvert.RadToDeg(a))
+		b = normalise(orbconvert.RadToDeg(b))
+		d := math.Abs(a - b)
+		if math.Min(d, 360-d) > tol.Angle {
+			result = append(result, ElementChange{Element: name, Old: a, New: b})
+		}
+	}
+
+	if o.Epoch.Equal(n.Epoch) {
+		check("a", orbconvert.KmToAu(o.SemimajorAxis), orbconvert.KmToAu(n.SemimajorAxis), tol.SemimajorAxis)
+		check("e", o.OrbitalEccentricity, n.OrbitalEccentricity, tol.Eccentricity)
+		angle("i", o.InclinationToTheEcliptic, n.InclinationToTheEcliptic)
+		angle("node", o.LongitudeOfTheAscendingNode, n.LongitudeOfTheAscendingNode)
+		angle("w", o.ArgumentOfPerihelion, n.ArgumentOfPerihelion)
+		angle("M", o.MeanAnomalyEpoch, n.MeanAnomalyEpoch)
+	}
+	check("H", o.AbsoluteMagnitude, n.AbsoluteMagnitude, tol.AbsoluteMagnitude)
+	return result
+}
+
+func normalise(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
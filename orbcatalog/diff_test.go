@@ -0,0 +1,93 @@
+package orbcatalog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+func diffOrbit(id, readable string, a float64) *orbcore.Orbit {
+	orb := testOrbit(id, readable, a, 0.1, 5, 15)
+	orb.ParentGrav = orbdata.SunGrav
+	orb.Epoch = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	orb.MeanAnomalyEpoch = 1
+	return orb
+}
+
+func TestDiff(t *testing.T) {
+	old := New([]*orbcore.Orbit{
+		diffOrbit("00001", "(1) Ceres", 2.77),
+		diffOrbit("00002", "(2) Pallas", 2.77),
+		diffOrbit("00003", "(3) Juno", 2.67),
+		diffOrbit("00004", "(4) Vesta", 2.36),
+		diffOrbit("K19A00A", "2019 AA", 3.1),
+		diffOrbit("K19C00C", "2019 CC", 2.5),
+	})
+
+	// a new epoch with the elements moved by the planets as well as the mean motion
+	moved := orbcore.MeanMotionToDate(diffOrbit("00001", "(1) Ceres", 2.7702), time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	moved.InclinationToTheEcliptic += orbconvert.DegToRad(0.01)
+	// a new epoch and a brighter magnitude
+	brighter := orbcore.MeanMotionToDate(diffOrbit("00004", "(4) Vesta", 2.36), time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	brighter.AbsoluteMagnitude -= 0.2
+	changed := diffOrbit("00002", "(2) Pallas", 2.78)
+	numbered := diffOrbit("A1234", "(101234) 2019 AA", 3.1)
+	numbered.Metadata.PrincipalDesignation = "2019 AA"
+	// numbered and named in the same batch, only the principal designation links it to its old orbit
+	named := diffOrbit("A1235", "(101235) Selwood", 2.5)
+	named.Metadata.PrincipalDesignation = "2019 CC"
+	added := diffOrbit("K19B00B", "2019 BB", 2.2)
+
+	new := New([]*orbcore.Orbit{moved, changed, brighter, numbered, named, added})
+
+	changes := Diff(old, new, DefaultTolerances)
+
+	expected := []struct {
+		kind ChangeKind
+		id   string
+	}{
+		{Changed, "00002"},
+		{Removed, "00003"},
+		{Changed, "00004"},
+		{Numbered, "A1234"},
+		{Numbered, "A1235"},
+		{Added, "K19B00B"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %v changes got %+v", len(expected), changes)
+	}
+	for i, e := range expected {
+		if changes[i].Kind != e.kind || changes[i].ID != e.id {
+			t.Errorf("expected %v %v got %v %v", e.kind, e.id, changes[i].Kind, changes[i].ID)
+		}
+	}
+
+	if len(changes[0].Elements) != 1 || changes[0].Elements[0].Element != "a" {
+		t.Errorf("expected only the semimajor axis to change got %+v", changes[0].Elements)
+	}
+	if len(changes[2].Elements) != 1 || changes[2].Elements[0].Element != "H" {
+		t.Errorf("expected only the magnitude to change across an epoch update got %+v", changes[2].Elements)
+	}
+	if changes[3].OldID != "K19A00A" || len(changes[3].Elements) != 0 {
+		t.Errorf("expected newly numbered object to come from K19A00A unchanged got %+v", changes[3])
+	}
+	if changes[4].OldID != "K19C00C" || changes[4].Name != "(101235) Selwood" {
+		t.Errorf("expected newly named object to come from K19C00C got %+v", changes[4])
+	}
+	if changes[1].Old == nil || changes[1].New != nil || changes[5].New == nil || changes[5].Old != nil {
+		t.Errorf("expected removed and added objects to carry one side only")
+	}
+	if orbconvert.KmToAu(changes[0].New.SemimajorAxis) != 2.78 {
+		t.Errorf("expected the new orbit on the change")
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	c := New([]*orbcore.Orbit{diffOrbit("00001", "(1) Ceres", 2.77)})
+	if changes := Diff(c, c, DefaultTolerances); len(changes) != 0 {
+		t.Errorf("expected no changes got %+v", changes)
+	}
+}
@@ -0,0 +1,461 @@
+package orbcatalog
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+Query is a compiled filter expression that can be matched against orbits.
+
+Expressions compare fields of an orbit with numbers, strings or other fields and combine the results with &&, || and
+!, for example
+
+	a < 3.3 && e > 0.2 && class == "Apollo" && H < 22
+
+Comparisons use <, <=, >, >=, == and !=. Boolean fields such as neo can be used on their own. Parentheses group as
+usual and && binds tighter than ||. See Fields for the names that can be used.
+*/
+type Query struct {
+	source string
+	match  func(*orbcore.Orbit) bool
+}
+
+/*
+Fields describes the names that can be used in a query and their units.
+*/
+var Fields = map[string]string{
+	"a":        "semimajor axis in AU",
+	"e":        "eccentricity",
+	"i":        "inclination in degrees",
+	"H":        "absolute magnitude",
+	"G":        "slope parameter",
+	"q":        "perihelion distance in AU",
+	"Q":        "aphelion distance in AU",
+	"M":        "mean anomaly at epoch in degrees",
+	"w":        "argument of perihelion in degrees",
+	"node":     "longitude of the ascending node in degrees",
+	"n":        "mean daily motion in degrees per day",
+	"period":   "orbital period in years",
+	"id":       "packed designation",
+	"name":     "readable designation, \"(433) Eros\"",
+	"class":    "orbit type from the catalog or the elements, \"MBA\", \"Apollo\" and so on",
+	"U":        "uncertainty parameter",
+	"obs":      "number of observations",
+	"opp":      "number of oppositions",
+	"rms":      "rms residual in arc seconds",
+	"computer": "name of the computer of the orbit",
+	"neo":      "true for near earth objects",
+	"pha":      "true for potentially hazardous objects",
+}
+
+type kind int
+
+const (
+	numberKind kind = iota
+	stringKind
+	boolKind
+)
+
+func (k kind) String() string {
+	switch k {
+	case numberKind:
+		return "number"
+	case stringKind:
+		return "string"
+	}
+	return "bool"
+}
+
+type field struct {
+	kind kind
+	num  func(*orbcore.Orbit) float64
+	str  func(*orbcore.Orbit) string
+	bool func(*orbcore.Orbit) bool
+}
+
+func numberField(f func(*orbcore.Orbit) float64) field {
+	return field{kind: numberKind, num: f}
+}
+
+func metaString(f func(*orbcore.Metadata) string) field {
+	return field{kind: stringKind, str: func(orb *orbcore.Orbit) string {
+		if orb.Metadata == nil {
+			return ""
+		}
+		return f(orb.Metadata)
+	}}
+}
+
+func metaNumber(f func(*orbcore.Metadata) float64) field {
+	return numberField(func(orb *orbcore.Orbit) float64 {
+		if orb.Metadata == nil {
+			return 0
+		}
+		return f(orb.Metadata)
+	})
+}
+
+func metaBool(f func(*orbcore.Metadata) bool) field {
+	return field{kind: boolKind, bool: func(orb *orbcore.Orbit) bool {
+		return orb.Metadata != nil && f(orb.Metadata)
+	}}
+}
+
+func degrees(f func(*orbcore.Orbit) float64) field {
+	return numberField(func(orb *orbcore.Orbit) float64 {
+		return orbconvert.RadToDeg(f(orb))
+	})
+}
+
+var fields = map[string]field{
+	"a": numberField(SemimajorAxis.Value),
+	"e": numberField(Eccentricity.Value),
+	"i": numberField(Inclination.Value),
+	"H": numberField(AbsoluteMagnitude.Value),
+	"G": numberField(func(orb *orbcore.Orbit) float64 { return orb.Slope }),
+	"q": numberField(func(orb *orbcore.Orbit) float64 {
+		return orbconvert.KmToAu(orbcore.PerihelionDistance(orb))
+	}),
+	"Q": numberField(func(orb *orbcore.Orbit) float64 {
+		return orbconvert.KmToAu(orb.SemimajorAxis) * (1 + orb.OrbitalEccentricity)
+	}),
+	"M":    degrees(func(orb *orbcore.Orbit) float64 { return orb.MeanAnomalyEpoch }),
+	"w":    degrees(func(orb *orbcore.Orbit) float64 { return orb.ArgumentOfPerihelion }),
+	"node": degrees(func(orb *orbcore.Orbit) float64 { return orb.LongitudeOfTheAscendingNode }),
+	"n":    numberField(func(orb *orbcore.Orbit) float64 { return orb.MeanDailyMotion }),
+	"period": numberField(func(orb *orbcore.Orbit) float64 {
+		return math.Pow(orbconvert.KmToAu(orb.SemimajorAxis), 1.5)
+	}),
+	"id": {kind: stringKind, str: func(orb *orbcore.Orbit) string { return orb.ID }},
+	"name": metaString(func(m *orbcore.Metadata) string {
+		return m.ReadableDesignation
+	}),
+	"class": {kind: stringKind, str: orbconvert.OrbitClass},
+	"U":     metaString(func(m *orbcore.Metadata) string { return m.UncertaintyParameter }),
+	"obs":   metaNumber(func(m *orbcore.Metadata) float64 { return float64(m.NumberOfObservations) }),
+	"opp":   metaNumber(func(m *orbcore.Metadata) float64 { return float64(m.NumberOfOppositions) }),
+	"rms":   metaNumber(func(m *orbcore.Metadata) float64 { return m.RmsResidual }),
+	"computer": metaString(func(m *orbcore.Metadata) string {
+		return m.ComputerName
+	}),
+	"neo": metaBool(func(m *orbcore.Metadata) bool { return m.NEO }),
+	"pha": metaBool(func(m *orbcore.Metadata) bool { return m.PHA }),
+}
+
+/*
+ParseQuery compiles a filter expression. An empty expression matches everything.
+*/
+func ParseQuery(expr string) (*Query, error) {
+	if strings.TrimSpace(expr) == "" {
+		return &Query{source: expr, match: func(*orbcore.Orbit) bool { return true }}, nil
+	}
+
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, fmt.Errorf("position %d: unexpected %q", t.pos, t.text)
+	}
+	return &Query{source: expr, match: match}, nil
+}
+
+/*
+NumberField returns a function giving the value of one of the numeric Fields for an orbit, so the names used in
+queries can also pick values to sort, plot or colour by.
+*/
+func NumberField(name string) (func(*orbcore.Orbit) float64, error) {
+	f, ok := fields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", name)
+	}
+	if f.kind != numberKind {
+		return nil, fmt.Errorf("field %q is a %v not a number", name, f.kind)
+	}
+	return f.num, nil
+}
+
+/*
+Match returns true if the orbit passes the filter.
+*/
+func (q *Query) Match(orb *orbcore.Orbit) bool {
+	return q.match(orb)
+}
+
+func (q *Query) String() string {
+	return q.source
+}
+
+/*
+Query returns the orbits in the catalog that match q, in the order they were loaded.
+*/
+func (c *Catalog) Query(q *Query) []*orbcore.Orbit {
+	return c.Filter(q.Match)
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"&&", "||", "<=", ">=", "==", "!=", "<", ">", "!"}
+
+func lex(expr string) ([]token, error) {
+	var result []token
+	i := 0
+	for i < len(expr) {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			result = append(result, token{tokenOpen, "(", i})
+			i++
+		case c == ')':
+			result = append(result, token{tokenClose, ")", i})
+			i++
+		case c == '"':
+			end := strings.IndexByte(expr[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("position %d: unterminated string", i)
+			}
+			result = append(result, token{tokenString, expr[i+1 : i+1+end], i})
+			i += end + 2
+		case unicode.IsDigit(c) || c == '.' || c == '-':
+			start := i
+			i++
+			for i < len(expr) && (strings.IndexByte("0123456789.eE", expr[i]) >= 0 ||
+				(strings.IndexByte("+-", expr[i]) >= 0 && (expr[i-1] == 'e' || expr[i-1] == 'E'))) {
+				i++
+			}
+			result = append(result, token{tokenNumber, expr[start:i], start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(expr) && (unicode.IsLetter(rune(expr[i])) || unicode.IsDigit(rune(expr[i])) || expr[i] == '_') {
+				i++
+			}
+			result = append(result, token{tokenIdent, expr[start:i], start})
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(expr[i:], op) {
+					result = append(result, token{tokenOp, op, i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("position %d: unexpected character %q", i, c)
+			}
+		}
+	}
+	return append(result, token{tokenEnd, "end of expression", len(expr)}), nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (func(*orbcore.Orbit) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "||" {
+		p.take()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(orb *orbcore.Orbit) bool { return l(orb) || right(orb) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (func(*orbcore.Orbit) bool, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "&&" {
+		p.take()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(orb *orbcore.Orbit) bool { return l(orb) && right(orb) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (func(*orbcore.Orbit) bool, error) {
+	if t := p.peek(); t.kind == tokenOp && t.text == "!" {
+		p.take()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(orb *orbcore.Orbit) bool { return !inner(orb) }, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (func(*orbcore.Orbit) bool, error) {
+	if p.peek().kind == tokenOpen {
+		p.take()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.take(); t.kind != tokenClose {
+			return nil, fmt.Errorf("position %d: expected ) got %q", t.pos, t.text)
+		}
+		return inner, nil
+	}
+
+	start := p.peek()
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peek()
+	if op.kind != tokenOp || op.text == "&&" || op.text == "||" || op.text == "!" {
+		// A boolean field on its own is a complete condition.
+		if left.kind == boolKind {
+			return left.bool, nil
+		}
+		return nil, fmt.Errorf("position %d: expected a comparison after %q", start.pos, start.text)
+	}
+	p.take()
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind != right.kind {
+		return nil, fmt.Errorf("position %d: can not compare %v with %v", op.pos, left.kind, right.kind)
+	}
+
+	switch left.kind {
+	case numberKind:
+		return compareNumbers(op, left.num, right.num)
+	case stringKind:
+		return compareStrings(op, left.str, right.str)
+	}
+	return compareBools(op, left.bool, right.bool)
+}
+
+func (p *parser) parseOperand() (field, error) {
+	t := p.take()
+	switch t.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return field{}, fmt.Errorf("position %d: could not parse number %q", t.pos, t.text)
+		}
+		return numberField(func(*orbcore.Orbit) float64 { return v }), nil
+	case tokenString:
+		return field{kind: stringKind, str: func(*orbcore.Orbit) string { return t.text }}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			v := t.text == "true"
+			return field{kind: boolKind, bool: func(*orbcore.Orbit) bool { return v }}, nil
+		}
+		f, ok := fields[t.text]
+		if !ok {
+			return field{}, fmt.Errorf("position %d: unknown field %q", t.pos, t.text)
+		}
+		return f, nil
+	}
+	return field{}, fmt.Errorf("position %d: expected a field or value got %q", t.pos, t.text)
+}
+
+func compareNumbers(op token, l, r func(*orbcore.Orbit) float64) (func(*orbcore.Orbit) bool, error) {
+	switch op.text {
+	case "<":
+		return func(orb *orbcore.Orbit) bool { return l(orb) < r(orb) }, nil
+	case "<=":
+		return func(orb *orbcore.Orbit) bool { return l(orb) <= r(orb) }, nil
+	case ">":
+		return func(orb *orbcore.Orbit) bool { return l(orb) > r(orb) }, nil
+	case ">=":
+		return func(orb *orbcore.Orbit) bool { return l(orb) >= r(orb) }, nil
+	case "==":
+		return func(orb *orbcore.Orbit) bool { return l(orb) == r(orb) }, nil
+	case "!=":
+		return func(orb *orbcore.Orbit) bool { return l(orb) != r(orb) }, nil
+	}
+	return nil, fmt.Errorf("position %d: unknown operator %q", op.pos, op.text)
+}
+
+func compareStrings(op token, l, r func(*orbcore.Orbit) string) (func(*orbcore.Orbit) bool, error) {
+	switch op.text {
+	case "<":
+		return func(orb *orbcore.Orbit) bool { return l(orb) < r(orb) }, nil
+	case "<=":
+		return func(orb *orbcore.Orbit) bool { return l(orb) <= r(orb) }, nil
+	case ">":
+		return func(orb *orbcore.Orbit) bool { return l(orb) > r(orb) }, nil
+	case ">=":
+		return func(orb *orbcore.Orbit) bool { return l(orb) >= r(orb) }, nil
+	case "==":
+		return func(orb *orbcore.Orbit) bool { return l(orb) == r(orb) }, nil
+	case "!=":
+		return func(orb *orbcore.Orbit) bool { return l(orb) != r(orb) }, nil
+	}
+	return nil, fmt.Errorf("position %d: unknown operator %q", op.pos, op.text)
+}
+
+func compareBools(op token, l, r func(*orbcore.Orbit) bool) (func(*orbcore.Orbit) bool, error) {
+	switch op.text {
+	case "==":
+		return func(orb *orbcore.Orbit) bool { return l(orb) == r(orb) }, nil
+	case "!=":
+		return func(orb *orbcore.Orbit) bool { return l(orb) != r(orb) }, nil
+	}
+	return nil, fmt.Errorf("position %d: operator %q can not be used with bool values", op.pos, op.text)
+}
@@ -0,0 +1,114 @@
+package orbcatalog
+
+import (
+	"testing"
+
+	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+func queryCatalog() *Catalog {
+	c := testCatalog()
+	eros, _ := c.Lookup("Eros")
+	eros.Metadata.OrbitType = "Amor"
+	eros.Metadata.NEO = true
+	phaethon, _ := c.Lookup("Phaethon")
+	phaethon.Metadata.OrbitType = "Apollo"
+	phaethon.Metadata.NEO = true
+	phaethon.Metadata.PHA = true
+	return c
+}
+
+func TestQuery(t *testing.T) {
+	c := queryCatalog()
+
+	tests := map[string]string{
+		"":                                      "00001,00433,03200,K19A00A,A0001",
+		"a < 2.5":                               "00433,03200,A0001",
+		"a < 3.3 && e > 0.2 && H < 22":          "00433,03200",
+		`class == "Apollo"`:                     "03200",
+		`class != "Apollo" && neo`:              "00433",
+		"pha || H < 4":                          "00001,03200",
+		"!neo && (a > 3 || i < 6)":              "K19A00A,A0001",
+		"q < 1.3":                               "00433,03200",
+		"Q > 2.9":                               "00001,K19A00A",
+		`id == "K19A00A"`:                       "K19A00A",
+		`name == "(433) Eros"`:                  "00433",
+		"neo == true && e >= 0.223":             "00433,03200",
+		"H <= 3.53":                             "00001",
+		"e > -1 && i != 2.5 && G == 0 && n < 1": "00001,00433,03200,A0001",
+	}
+	for expr, expected := range tests {
+		q, err := ParseQuery(expr)
+		if err != nil {
+			t.Errorf("could not parse %q: %v", expr, err)
+			continue
+		}
+		if r := ids(c.Query(q)); r != expected {
+			t.Errorf("%q expected %v got %v", expr, expected, r)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	bad := []string{
+		"a <",
+		"a 3",
+		"a < 3 &&",
+		"(a < 3",
+		"a < 3)",
+		"x < 3",
+		`a < "3"`,
+		`class == "MBA`,
+		"neo > true",
+		"H",
+		"a # 3",
+	}
+	for _, expr := range bad {
+		if _, err := ParseQuery(expr); err == nil {
+			t.Errorf("expected %q to fail to parse", expr)
+		}
+	}
+}
+
+func TestNumberField(t *testing.T) {
+	c := queryCatalog()
+	eros, _ := c.Lookup("Eros")
+
+	a, err := NumberField("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := a(eros); v < 1.4599 || v > 1.4601 {
+		t.Errorf("expected the semimajor axis of eros in AU got %v", v)
+	}
+	for _, name := range []string{"class", "neo", "x"} {
+		if _, err := NumberField(name); err == nil {
+			t.Errorf("expected an error for %q", name)
+		}
+	}
+}
+
+func TestQueryNoMetadata(t *testing.T) {
+	q, err := ParseQuery(`name == "" && !neo && obs == 0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Match(&orbcore.Orbit{}) {
+		t.Errorf("expected an orbit without metadata to match empty values")
+	}
+
+	// without a type from the catalog the class comes from the elements
+	q, err = ParseQuery(`class == "Apollo"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comet := &orbcore.Orbit{SemimajorAxis: orbconvert.AuToKm(3), OrbitalEccentricity: 0.8}
+	if !q.Match(comet) {
+		t.Errorf("expected an orbit crossing the earth's to be an Apollo")
+	}
+	comet.OrbitalEccentricity = 1.1
+	if q.Match(comet) {
+		t.Errorf("did not expect a hyperbolic orbit to have a class")
+	}
+}
@@ -0,0 +1,211 @@
+package orbconvert
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+/*
+CCSDSFormat picks which of the two encodings of a CCSDS navigation message to write.
+*/
+type CCSDSFormat int
+
+const (
+	// KVN is the plain text keyword = value encoding.
+	KVN CCSDSFormat = iota
+	// XML is the NDM/XML encoding.
+	XML
+)
+
+// Defaults used when writing messages that do not say otherwise. ICRF is what most flight dynamics tools expect for
+// heliocentric trajectories.
+const (
+	DefaultFrame      = "ICRF"
+	DefaultCenter     = "SUN"
+	DefaultTimeSystem = "UTC"
+	originator        = "ORBCALC"
+)
+
+// ccsdsTimeLayout is the calendar form of a CCSDS epoch. The day of year form is also accepted when reading.
+const ccsdsTimeLayout = "2006-01-02T15:04:05.000000"
+
+// frameToEcliptic returns true if the frame needs rotating from the equator onto the ecliptic.
+func frameToEcliptic(frame string) (bool, error) {
+	switch strings.ToUpper(frame) {
+	case "ICRF", "EME2000", "GCRF", "J2000":
+		return true, nil
+	case orbcore.EclipticJ2000:
+		return false, nil
+	}
+	return false, fmt.Errorf("reference frame %q is not supported", frame)
+}
+
+func toEcliptic(x, y, z float64) (float64, float64, float64) {
+	c, s := math.Cos(orbdata.Obliquity), math.Sin(orbdata.Obliquity)
+	return x, c*y + s*z, -s*y + c*z
+}
+
+func toEquatorial(x, y, z float64) (float64, float64, float64) {
+	c, s := math.Cos(orbdata.Obliquity), math.Sin(orbdata.Obliquity)
+	return x, c*y - s*z, s*y + c*z
+}
+
+// inFrame returns a copy of a position converted from the frame it says it is in to frame.
+func inFrame(p *orbcore.Position, frame string) (*orbcore.Position, error) {
+	from, err := frameToEcliptic(valueOr(p.Frame, orbcore.EclipticJ2000))
+	if err != nil {
+		return nil, err
+	}
+	to, err := frameToEcliptic(frame)
+	if err != nil {
+		return nil, err
+	}
+	result := *p
+	result.Frame = frame
+	switch {
+	case from && !to:
+		result.X, result.Y, result.Z = toEcliptic(p.X, p.Y, p.Z)
+		result.VX, result.VY, result.VZ = toEcliptic(p.VX, p.VY, p.VZ)
+	case !from && to:
+		result.X, result.Y, result.Z = toEquatorial(p.X, p.Y, p.Z)
+		result.VX, result.VY, result.VZ = toEquatorial(p.VX, p.VY, p.VZ)
+	}
+	return &result, nil
+}
+
+/*
+parseCCSDSTime reads an epoch written in the given time system and returns it in UTC.
+
+TT is treated as TDB, the two never differ by more than two milliseconds.
+*/
+func parseCCSDSTime(in string, system string) (time.Time, error) {
+	in = strings.TrimSuffix(strings.TrimSpace(in), "Z")
+	var t time.Time
+	var err error
+	if len(in) > 8 && in[4] == '-' && in[8] == 'T' {
+		t, err = time.Parse("2006-002T15:04:05", in)
+	} else {
+		t, err = time.Parse("2006-01-02T15:04:05", in)
+	}
+	if err != nil {
+		return t, fmt.Errorf("could not parse epoch %q: %v", in, err)
+	}
+
+	switch strings.ToUpper(system) {
+	case "UTC":
+		return t, nil
+	case "TAI":
+		t = t.Add(32184 * time.Millisecond)
+		fallthrough
+	case "TT", "TDB":
+		return orbcore.TimeFromEphemerisTime(j2000Seconds(t)), nil
+	}
+	return t, fmt.Errorf("time system %q is not supported", system)
+}
+
+// formatCCSDSTime writes a UTC time as an epoch in the given time system.
+func formatCCSDSTime(t time.Time, system string) (string, error) {
+	switch strings.ToUpper(system) {
+	case "UTC":
+	case "TT", "TDB":
+		t = fromJ2000Seconds(orbcore.EphemerisTime(t))
+	case "TAI":
+		t = fromJ2000Seconds(orbcore.EphemerisTime(t)).Add(-32184 * time.Millisecond)
+	default:
+		return "", fmt.Errorf("time system %q is not supported", system)
+	}
+	return t.UTC().Format(ccsdsTimeLayout), nil
+}
+
+var j2000 = time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func j2000Seconds(t time.Time) float64 {
+	return float64(t.Unix()-j2000.Unix()) + float64(t.Nanosecond())/1e9
+}
+
+func fromJ2000Seconds(s float64) time.Time {
+	whole := math.Floor(s)
+	return j2000.Add(time.Duration(whole) * time.Second).Add(time.Duration((s - whole) * 1e9)).Round(time.Microsecond)
+}
+
+// kvnLine is one keyword = value line of a KVN message, a block marker such as META_START with no value, or a data
+// line with only fields.
+type kvnLine struct {
+	number int
+	key    string
+	value  string
+	fields []string
+}
+
+/*
+readKVN splits a KVN message into lines, dropping comments and blank lines. Units in square brackets after a value are
+removed.
+*/
+func readKVN(in io.Reader) ([]kvnLine, error) {
+	var result []kvnLine
+	scanner := bufio.NewScanner(in)
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "COMMENT") {
+			continue
+		}
+		if i := strings.Index(line, "="); i >= 0 {
+			value := strings.TrimSpace(line[i+1:])
+			if j := strings.Index(value, "["); j >= 0 {
+				value = strings.TrimSpace(value[:j])
+			}
+			result = append(result, kvnLine{number: number, key: strings.TrimSpace(line[:i]), value: value})
+			continue
+		}
+		if strings.HasSuffix(line, "_START") || strings.HasSuffix(line, "_STOP") {
+			result = append(result, kvnLine{number: number, key: line})
+			continue
+		}
+		result = append(result, kvnLine{number: number, fields: strings.Fields(line)})
+	}
+	return result, scanner.Err()
+}
+
+// readMessage reads a whole message and reports if it looks like XML.
+func readMessage(in io.Reader) ([]byte, bool, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")), nil
+}
+
+// lookupGM finds the gravitational parameter of a center body by name.
+func lookupGM(center string) (float64, error) {
+	if body, ok := orbdata.LookupBody(center); ok && body.GM > 0 {
+		return body.GM, nil
+	}
+	return 0, fmt.Errorf("no gravitational parameter known for center %q", center)
+}
+
+// centerName finds the CCSDS name of the body an orbit is around from its parent gravity.
+func centerName(orbit *orbcore.Orbit) (string, error) {
+	body, ok := orbdata.LookupBodyByGM(orbit.ParentGrav)
+	if !ok {
+		return "", fmt.Errorf("%v: no center body known with a gravitational parameter of %v", orbit.ID, orbit.ParentGrav)
+	}
+	return strings.ToUpper(body.Name), nil
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
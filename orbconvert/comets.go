@@ -0,0 +1,205 @@
+package orbconvert
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+// An eccentricity of exactly one has no finite semimajor axis, these are nudged just inside the parabolic limit.
+// The near parabolic propagation path treats both the same way.
+const parabolicNudge = 1e-10
+
+/*
+CometReader streams orbits out of an MPC comet elements file (CometEls.txt).
+*/
+type CometReader struct {
+	file    io.Closer
+	scanner *bufio.Scanner
+	line    int
+}
+
+/*
+NewCometReader opens the file at path ready to read comet orbits from it. Gzip and bzip2 compressed files are
+decompressed on the fly.
+*/
+func NewCometReader(path string) (*CometReader, error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	result := NewCometReaderFromReader(f)
+	result.file = f
+	return result, nil
+}
+
+/*
+NewCometReaderFromReader creates a CometReader that reads from an already open reader.
+*/
+func NewCometReaderFromReader(in io.Reader) *CometReader {
+	return &CometReader{
+		scanner: bufio.NewScanner(in),
+	}
+}
+
+/*
+ReadEntry returns the next comet orbit. At the end of the input io.EOF is returned.
+*/
+func (r *CometReader) ReadEntry() (*orbcore.Orbit, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		orb, err := ParseCometLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", r.line, err)
+		}
+		return orb, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+/*
+Close closes the underlying file if this reader opened it.
+*/
+func (r *CometReader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+/*
+ParseCometLine converts a line of the MPC comet elements file into an orbit.
+
+Comet elements are given as a perihelion distance and time of perihelion passage. The returned orbit has its epoch at
+perihelion with a true anomaly of zero and a semimajor axis of q/(1-e), which is negative for hyperbolic orbits. The
+comet magnitude parameters are kept in AbsoluteMagnitude (M1) and Slope, where K1 is 2.5 times the slope.
+*/
+func ParseCometLine(line string) (*orbcore.Orbit, error) {
+	if len(line) < 102 {
+		return nil, fmt.Errorf("comet line is %d characters long, expected at least 102", len(line))
+	}
+
+	field := func(start, end int) string {
+		if end > len(line) {
+			end = len(line)
+		}
+		return strings.TrimSpace(line[start:end])
+	}
+	number := func(name string, start, end int) (float64, error) {
+		v, err := strconv.ParseFloat(field(start, end), 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse %v %q: %v", name, field(start, end), err)
+		}
+		return v, nil
+	}
+
+	var result orbcore.Orbit
+	result.ParentGrav = orbdata.SunGrav
+
+	if n := field(0, 4); n != "" {
+		result.ID = line[0:5]
+	} else {
+		result.ID = field(4, 12)
+	}
+	if result.ID == "" {
+		return nil, fmt.Errorf("comet has no designation")
+	}
+
+	perihelionTime, err := parseCometDate(field(14, 29))
+	if err != nil {
+		return nil, err
+	}
+	result.Epoch = perihelionTime
+
+	q, err := number("perihelion distance", 30, 39)
+	if err != nil {
+		return nil, err
+	}
+	e, err := number("eccentricity", 41, 49)
+	if err != nil {
+		return nil, err
+	}
+	w, err := number("argument of perihelion", 51, 59)
+	if err != nil {
+		return nil, err
+	}
+	node, err := number("longitude of the ascending node", 61, 69)
+	if err != nil {
+		return nil, err
+	}
+	inc, err := number("inclination", 71, 79)
+	if err != nil {
+		return nil, err
+	}
+
+	if e == 1 {
+		e -= parabolicNudge
+	}
+
+	result.MeanAnomalyEpoch = 0
+	result.ArgumentOfPerihelion = DegToRad(w)
+	result.LongitudeOfTheAscendingNode = DegToRad(node)
+	result.InclinationToTheEcliptic = DegToRad(inc)
+	result.OrbitalEccentricity = e
+	result.SemimajorAxis = AuToKm(q) / (1 - e)
+	if e < 1 {
+		result.MeanDailyMotion = RadToDeg(math.Sqrt(result.ParentGrav/math.Pow(result.SemimajorAxis, 3))) * 86400
+	}
+
+	if h := field(91, 95); h != "" {
+		if result.AbsoluteMagnitude, err = number("absolute magnitude", 91, 95); err != nil {
+			return nil, err
+		}
+	}
+	if g := field(96, 100); g != "" {
+		if result.Slope, err = number("slope", 96, 100); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Metadata = &orbcore.Metadata{
+		ReadableDesignation: field(102, 158),
+		Reference:           field(159, 168),
+	}
+
+	return &result, nil
+}
+
+// parseCometDate handles perihelion times in the form "YYYY MM DD.dddd"
+func parseCometDate(in string) (time.Time, error) {
+	parts := strings.Fields(in)
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("could not parse perihelion time %q", in)
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse perihelion year %q: %v", in, err)
+	}
+	month, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse perihelion month %q: %v", in, err)
+	}
+	day, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse perihelion day %q: %v", in, err)
+	}
+
+	whole := math.Floor(day)
+	fraction := time.Duration(math.Round((day - whole) * float64(24*time.Hour)))
+	return time.Date(year, time.Month(month), int(whole), 0, 0, 0, 0, time.UTC).Add(fraction), nil
+}
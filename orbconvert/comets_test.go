@@ -0,0 +1,87 @@
+package orbconvert
+
+import (
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+const testComets = `0001P         1986 02  9.4589  0.587104  0.967277  111.8630   59.3977  162.1842  20170101   4.0  6.0  1P/Halley                                                98,  48
+    CK17U010  2017 09  9.4886  0.255240  1.201134  241.6845   24.5997  122.6778  20171104  23.4  2.0  C/2017 U1                                                 MPC107687
+    CK19Y040  2020 05 31.0000  0.253000  1.000000  177.3879  120.5707   45.3845             8.0  4.0  C/2019 Y4 (ATLAS)                                        MPEC
+`
+
+func readTestComets(t *testing.T) []*orbcore.Orbit {
+	reader := NewCometReaderFromReader(strings.NewReader(testComets))
+	defer reader.Close()
+
+	var result []*orbcore.Orbit
+	orb, err := reader.ReadEntry()
+	for err == nil {
+		result = append(result, orb)
+		orb, err = reader.ReadEntry()
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestParseCometLine(t *testing.T) {
+	comets := readTestComets(t)
+	if len(comets) != 3 {
+		t.Fatalf("expected 3 comets got %v", len(comets))
+	}
+
+	halley := comets[0]
+	if halley.ID != "0001P" || halley.Metadata.ReadableDesignation != "1P/Halley" {
+		t.Errorf("unexpected designation %v %v", halley.ID, halley.Metadata.ReadableDesignation)
+	}
+	expectedEpoch := time.Date(1986, 2, 9, 0, 0, 0, 0, time.UTC).Add(time.Duration(0.4589 * float64(24*time.Hour)))
+	if halley.Epoch.Sub(expectedEpoch) > time.Millisecond || expectedEpoch.Sub(halley.Epoch) > time.Millisecond {
+		t.Errorf("expected perihelion at %v got %v", expectedEpoch, halley.Epoch)
+	}
+	if q := KmToAu(orbcore.PerihelionDistance(halley)); math.Abs(q-0.587104) > 1e-9 {
+		t.Errorf("expected perihelion distance 0.587104 got %v", q)
+	}
+	if math.Abs(KmToAu(halley.SemimajorAxis)-17.9416) > 1e-3 {
+		t.Errorf("expected semimajor axis of about 17.94 AU got %v", KmToAu(halley.SemimajorAxis))
+	}
+	if halley.AbsoluteMagnitude != 4 || halley.Slope != 6 {
+		t.Errorf("unexpected magnitude parameters %v %v", halley.AbsoluteMagnitude, halley.Slope)
+	}
+
+	hyperbolic := comets[1]
+	if hyperbolic.ID != "CK17U010" || hyperbolic.SemimajorAxis >= 0 || hyperbolic.MeanDailyMotion != 0 {
+		t.Errorf("unexpected hyperbolic orbit %v", hyperbolic)
+	}
+
+	parabolic := comets[2]
+	if q := KmToAu(orbcore.PerihelionDistance(parabolic)); math.Abs(q-0.253) > 1e-9 {
+		t.Errorf("expected perihelion distance 0.253 got %v", q)
+	}
+}
+
+func TestPropagateComets(t *testing.T) {
+	for _, orb := range readTestComets(t) {
+		q := orbcore.PerihelionDistance(orb)
+
+		start := orbcore.OrbitToPosition(orb)
+		r := math.Sqrt(start.X*start.X + start.Y*start.Y + start.Z*start.Z)
+		if math.Abs(r-q) > 1 {
+			t.Errorf("%v: expected to be at perihelion %v got %v", orb.ID, q, r)
+		}
+
+		for _, days := range []time.Duration{-30, 30} {
+			p := orbcore.OrbitToPosition(orbcore.MeanMotion(orb, days*24*time.Hour))
+			d := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+			if math.IsNaN(d) || d <= q {
+				t.Errorf("%v: expected to be further out than perihelion %v after %v days got %v", orb.ID, q, days, d)
+			}
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package orbconvert
 
 import (
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
 
 	"github.com/emilyselwood/gompcreader"
+	"github.com/emilyselwood/orbcalc/orbconvert/designation"
 	"github.com/emilyselwood/orbcalc/orbcore"
 	"github.com/emilyselwood/orbcalc/orbdata"
 )
@@ -14,8 +18,8 @@ ConvertFromMinorPlanet converts the columns in a minor planet into the requred t
 func ConvertFromMinorPlanet(mpc *gompcreader.MinorPlanet) *orbcore.Orbit {
 	var result orbcore.Orbit
 
-	// TODO: convert more types as needed
 	result.ID = mpc.ID
+	result.ParentGrav = orbdata.SunGrav
 	result.Epoch = mpc.Epoch
 	result.MeanAnomalyEpoch = DegToRad(mpc.MeanAnomalyEpoch)
 	result.ArgumentOfPerihelion = DegToRad(mpc.ArgumentOfPerihelion)
@@ -24,10 +28,127 @@ func ConvertFromMinorPlanet(mpc *gompcreader.MinorPlanet) *orbcore.Orbit {
 	result.OrbitalEccentricity = mpc.OrbitalEccentricity
 	result.MeanDailyMotion = mpc.MeanDailyMotion
 	result.SemimajorAxis = AuToKm(mpc.SemimajorAxis)
+	result.AbsoluteMagnitude = mpc.AbsoluteMagnitude
+	result.Slope = mpc.Slope
+	result.Metadata = ConvertMetadata(mpc)
 
 	return &result
 }
 
+/*
+ConvertMetadata pulls the catalog information out of a minor planet record.
+*/
+func ConvertMetadata(mpc *gompcreader.MinorPlanet) *orbcore.Metadata {
+	result := &orbcore.Metadata{
+		ReadableDesignation:          mpc.ReadableDesignation,
+		PrincipalDesignation:         principalDesignation(mpc.ReadableDesignation),
+		UncertaintyParameter:         mpc.UncertaintyParameter,
+		Reference:                    mpc.Reference,
+		NumberOfObservations:         mpc.NumberOfObservations,
+		NumberOfOppositions:          mpc.NumberOfOppositions,
+		YearOfFirstObservation:       mpc.YearOfFirstObservation,
+		YearOfLastObservation:        mpc.YearOfLastObservation,
+		ArcLength:                    mpc.ArcLength,
+		RmsResidual:                  mpc.RMSResidual,
+		CoarseIndicatorOfPerturbers:  mpc.CoarseIndicatorOfPerturbers,
+		PreciseIndicatorOfPerturbers: mpc.PreciseIndicatorOfPerturbers,
+		ComputerName:                 mpc.ComputerName,
+		Flags:                        fmt.Sprintf("%04X", mpc.HexDigitFlags),
+		LastObservation:              mpc.DateOfLastObservation,
+	}
+	applyFlags(result)
+	return result
+}
+
+// principalDesignation pulls the provisional designation out of an MPCORB readable designation. Numbered objects
+// without a name have it after the number, "(101234) 2019 AA". Named objects do not carry it so nothing is returned.
+func principalDesignation(readable string) string {
+	if strings.HasPrefix(readable, "(") {
+		end := strings.Index(readable, ")")
+		if end < 0 {
+			return ""
+		}
+		readable = strings.TrimSpace(readable[end+1:])
+	}
+	if _, err := designation.Pack(readable); err != nil {
+		return ""
+	}
+	return readable
+}
+
+// Orbit types encoded in the bottom six bits of the MPCORB hex flags, named the same way as the extended json catalog.
+var orbitTypes = map[uint64]string{
+	0:  "MBA",
+	1:  "Atira",
+	2:  "Aten",
+	3:  "Apollo",
+	4:  "Amor",
+	5:  "Object with perihelion distance < 1.665 AU",
+	6:  "Hungaria",
+	7:  "Phocaea",
+	8:  "Hilda",
+	9:  "Jupiter Trojan",
+	10: "Distant Object",
+}
+
+const (
+	neoFlag = 1 << 11
+	phaFlag = 1 << 15
+)
+
+// applyFlags fills in the orbit type, NEO and PHA fields from the hex flags column of MPCORB.DAT.
+func applyFlags(meta *orbcore.Metadata) {
+	flags, err := strconv.ParseUint(meta.Flags, 16, 16)
+	if err != nil {
+		return
+	}
+	meta.OrbitType = orbitTypes[flags&0x3f]
+	meta.NEO = flags&neoFlag != 0
+	meta.PHA = flags&phaFlag != 0
+}
+
+/*
+OrbitClass returns the orbit type of orb, one of the names MPC uses such as "MBA" or "Apollo". The type from the
+catalog is used when there is one, otherwise it is worked out roughly from the elements. Hyperbolic and parabolic
+orbits give an empty string.
+*/
+func OrbitClass(orb *orbcore.Orbit) string {
+	if orb.Metadata != nil && orb.Metadata.OrbitType != "" {
+		return orb.Metadata.OrbitType
+	}
+	e := orb.OrbitalEccentricity
+	if e >= 1 {
+		return ""
+	}
+	a := KmToAu(orb.SemimajorAxis)
+	q, bigQ := a*(1-e), a*(1+e)
+	i := RadToDeg(orb.InclinationToTheEcliptic)
+
+	switch {
+	case a < 1 && bigQ < 0.983:
+		return orbitTypes[1]
+	case a < 1:
+		return orbitTypes[2]
+	case q < 1.017:
+		return orbitTypes[3]
+	case q < 1.3:
+		return orbitTypes[4]
+	case q < 1.665:
+		return orbitTypes[5]
+	case a > 1.78 && a < 2 && e < 0.18 && i > 16 && i < 34:
+		return orbitTypes[6]
+	case a > 2.25 && a < 2.5 && e > 0.1 && i > 18 && i < 32:
+		return orbitTypes[7]
+	case a > 3.7 && a < 4.2 && e < 0.3 && i < 20:
+		return orbitTypes[8]
+	case a > 5.05 && a < 5.35 && e < 0.3:
+		return orbitTypes[9]
+	case a < 5.05:
+		return orbitTypes[0]
+	}
+	return orbitTypes[10]
+}
+
 const toRad = math.Pi / 180.0
 
 /*
@@ -50,3 +171,10 @@ KmToAu converts a distance in Km to AU
 func KmToAu(in float64) float64 {
 	return in / orbdata.AU
 }
+
+/*
+RadToDeg converts radians to degrees
+*/
+func RadToDeg(in float64) float64 {
+	return in / toRad
+}
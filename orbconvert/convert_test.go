@@ -0,0 +1,98 @@
+package orbconvert
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/gompcreader"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+func minorPlanet() *gompcreader.MinorPlanet {
+	return &gompcreader.MinorPlanet{
+		ID:                           "00433",
+		AbsoluteMagnitude:            10.38,
+		Slope:                        0.46,
+		Epoch:                        time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC),
+		MeanAnomalyEpoch:             310.55432,
+		ArgumentOfPerihelion:         178.92986,
+		LongitudeOfTheAscendingNode:  304.27008,
+		InclinationToTheEcliptic:     10.82841,
+		OrbitalEccentricity:          0.2228359,
+		MeanDailyMotion:              0.55976627,
+		SemimajorAxis:                1.4579341,
+		UncertaintyParameter:         "0",
+		Reference:                    "E2024-V47",
+		NumberOfObservations:         9130,
+		NumberOfOppositions:          58,
+		YearOfFirstObservation:       1893,
+		YearOfLastObservation:        2024,
+		RMSResidual:                  0.4,
+		CoarseIndicatorOfPerturbers:  "M-v",
+		PreciseIndicatorOfPerturbers: "3Ek",
+		ComputerName:                 "MPCLINUX",
+		HexDigitFlags:                0x0804,
+		ReadableDesignation:          "(433) Eros",
+		DateOfLastObservation:        time.Date(2024, 10, 30, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestConvertFromMinorPlanet(t *testing.T) {
+	orb := ConvertFromMinorPlanet(minorPlanet())
+
+	if orb.ID != "00433" || orb.ParentGrav != orbdata.SunGrav {
+		t.Errorf("unexpected id %v or parent gravity %v", orb.ID, orb.ParentGrav)
+	}
+	if math.Abs(orb.SemimajorAxis-AuToKm(1.4579341)) > 1e-6 {
+		t.Errorf("expected semimajor axis in km got %v", orb.SemimajorAxis)
+	}
+	if math.Abs(orb.InclinationToTheEcliptic-DegToRad(10.82841)) > 1e-12 {
+		t.Errorf("expected inclination in radians got %v", orb.InclinationToTheEcliptic)
+	}
+	if orb.AbsoluteMagnitude != 10.38 || orb.Slope != 0.46 {
+		t.Errorf("unexpected magnitude parameters %v %v", orb.AbsoluteMagnitude, orb.Slope)
+	}
+}
+
+func TestConvertMetadata(t *testing.T) {
+	meta := ConvertMetadata(minorPlanet())
+
+	if meta.ReadableDesignation != "(433) Eros" || meta.Reference != "E2024-V47" || meta.ComputerName != "MPCLINUX" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+	if meta.PrincipalDesignation != "" {
+		t.Errorf("expected no principal designation for a named object got %q", meta.PrincipalDesignation)
+	}
+	if meta.NumberOfObservations != 9130 || meta.NumberOfOppositions != 58 || meta.RmsResidual != 0.4 {
+		t.Errorf("unexpected observation counts %+v", meta)
+	}
+	if meta.YearOfFirstObservation != 1893 || meta.YearOfLastObservation != 2024 {
+		t.Errorf("unexpected arc %v-%v", meta.YearOfFirstObservation, meta.YearOfLastObservation)
+	}
+	if meta.CoarseIndicatorOfPerturbers != "M-v" || meta.PreciseIndicatorOfPerturbers != "3Ek" {
+		t.Errorf("expected perturbers M-v and 3Ek got %q and %q",
+			meta.CoarseIndicatorOfPerturbers, meta.PreciseIndicatorOfPerturbers)
+	}
+	if meta.Flags != "0804" || meta.OrbitType != "Amor" || !meta.NEO || meta.PHA {
+		t.Errorf("unexpected flags %v %v neo: %v pha: %v", meta.Flags, meta.OrbitType, meta.NEO, meta.PHA)
+	}
+	if !meta.LastObservation.Equal(time.Date(2024, 10, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected last observation %v", meta.LastObservation)
+	}
+}
+
+func TestPrincipalDesignation(t *testing.T) {
+	cases := map[string]string{
+		"(101234) 2019 AA": "2019 AA",
+		"2019 AA":          "2019 AA",
+		"(433) Eros":       "",
+		"(2060) Chiron":    "",
+		"":                 "",
+	}
+	for readable, expected := range cases {
+		if r := principalDesignation(readable); r != expected {
+			t.Errorf("%v: expected %q got %q", readable, expected, r)
+		}
+	}
+}
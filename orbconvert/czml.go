@@ -0,0 +1,276 @@
+package orbconvert
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+/*
+CZMLDocument describes the time range of a CZML document and how often positions are sampled. Objects are shown from
+Start to End, sampled every Step, one day if zero. Multiplier sets how many seconds of simulation time pass each real
+second when the document is opened, a day a second if zero.
+*/
+type CZMLDocument struct {
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Step       time.Duration
+	Multiplier float64
+}
+
+/*
+CZMLStyle controls how an object is drawn. Color is red, green, blue and alpha from 0 to 255. A zero TrailTime or
+LeadTime draws the whole path behind or ahead of the object, set them to show only part of it.
+*/
+type CZMLStyle struct {
+	Color     [4]int
+	PointSize float64
+	PathWidth float64
+	Label     bool
+	TrailTime time.Duration
+	LeadTime  time.Duration
+}
+
+/*
+DefaultCZMLStyle draws a small white point with its whole path and a label.
+*/
+var DefaultCZMLStyle = CZMLStyle{
+	Color:     [4]int{255, 255, 255, 255},
+	PointSize: 4,
+	PathWidth: 1,
+	Label:     true,
+}
+
+// czmlInterpolationDegree is the degree of the Lagrange polynomial Cesium should use between samples.
+const czmlInterpolationDegree = 5
+
+/*
+CZMLWriter streams objects into a CZML document for viewing in Cesium.
+
+Positions are written in metres relative to the Sun, in the ICRF axes Cesium calls INERTIAL. Cesium places the origin
+of that frame at the center of the Earth, so the Sun appears there.
+*/
+type CZMLWriter struct {
+	w   *bufio.Writer
+	doc CZMLDocument
+}
+
+/*
+NewCZMLWriter starts a CZML document on out, writing the document packet with the clock set to the time range. Close
+must be called to finish the document.
+*/
+func NewCZMLWriter(out io.Writer, doc CZMLDocument) (*CZMLWriter, error) {
+	if !doc.End.After(doc.Start) {
+		return nil, fmt.Errorf("end %v is not after start %v", doc.End, doc.Start)
+	}
+	if doc.Step <= 0 {
+		doc.Step = 24 * time.Hour
+	}
+	if doc.Multiplier <= 0 {
+		doc.Multiplier = 86400
+	}
+
+	cw := CZMLWriter{w: bufio.NewWriterSize(out, 64*1024), doc: doc}
+	packet := czmlPacket{
+		ID:      "document",
+		Name:    doc.Name,
+		Version: "1.0",
+		Clock: &czmlClock{
+			Interval:    cw.interval(),
+			CurrentTime: czmlTime(doc.Start),
+			Multiplier:  doc.Multiplier,
+			Range:       "LOOP_STOP",
+			Step:        "SYSTEM_CLOCK_MULTIPLIER",
+		},
+	}
+	if _, err := cw.w.WriteString("[\n"); err != nil {
+		return nil, err
+	}
+	if err := cw.writePacket(&packet, ""); err != nil {
+		return nil, err
+	}
+	return &cw, nil
+}
+
+/*
+WriteOrbit propagates orbit across the document time range and adds it to the document.
+*/
+func (cw *CZMLWriter) WriteOrbit(orbit *orbcore.Orbit, style *CZMLStyle) error {
+	var positions []*orbcore.Position
+	for _, t := range cw.times() {
+		positions = append(positions, orbcore.OrbitToPosition(orbcore.MeanMotionToDate(orbit, t)))
+	}
+	return cw.WritePositions(orbit.ID, positions, style)
+}
+
+/*
+WriteBody adds a body from orbdata to the document, using its heliocentric position so planets follow their
+approximate elements and moons their parents.
+*/
+func (cw *CZMLWriter) WriteBody(body *orbdata.Body, style *CZMLStyle) error {
+	var positions []*orbcore.Position
+	for _, t := range cw.times() {
+		positions = append(positions, body.HeliocentricPosition(t))
+	}
+	return cw.WritePositions(body.Name, positions, style)
+}
+
+/*
+WritePositions adds an object to the document from heliocentric positions in the J2000 ecliptic frame, in km. The
+positions must be in time order, a nil style uses DefaultCZMLStyle.
+*/
+func (cw *CZMLWriter) WritePositions(id string, positions []*orbcore.Position, style *CZMLStyle) error {
+	if len(positions) == 0 {
+		return fmt.Errorf("%v: no positions to write", id)
+	}
+	if style == nil {
+		style = &DefaultCZMLStyle
+	}
+
+	epoch := positions[0].Epoch
+	cartesian := make([]float64, 0, 4*len(positions))
+	for _, p := range positions {
+		x, y, z := toEquatorial(p.X, p.Y, p.Z)
+		cartesian = append(cartesian, p.Epoch.Sub(epoch).Seconds(), x*1000, y*1000, z*1000)
+	}
+
+	color := &czmlColor{RGBA: style.Color}
+	packet := czmlPacket{
+		ID:           id,
+		Name:         id,
+		Availability: czmlTime(epoch) + "/" + czmlTime(positions[len(positions)-1].Epoch),
+		Position: &czmlPosition{
+			Epoch:                  czmlTime(epoch),
+			InterpolationAlgorithm: "LAGRANGE",
+			InterpolationDegree:    czmlInterpolationDegree,
+			ReferenceFrame:         "INERTIAL",
+			Cartesian:              cartesian,
+		},
+		Point: &czmlPoint{Color: color, PixelSize: style.PointSize},
+		Path: &czmlPath{
+			Material:  czmlMaterial{SolidColor: czmlSolidColor{Color: color}},
+			Width:     style.PathWidth,
+			TrailTime: style.TrailTime.Seconds(),
+			LeadTime:  style.LeadTime.Seconds(),
+		},
+	}
+	if style.Label {
+		packet.Label = &czmlLabel{
+			Text:        id,
+			FillColor:   color,
+			Font:        "11pt sans-serif",
+			PixelOffset: &czmlCartesian2{Cartesian2: [2]float64{8, 0}},
+			Horizontal:  "LEFT",
+		}
+	}
+	return cw.writePacket(&packet, ",\n")
+}
+
+/*
+Close finishes the document and flushes it. It does not close the underlying writer.
+*/
+func (cw *CZMLWriter) Close() error {
+	if _, err := cw.w.WriteString("\n]\n"); err != nil {
+		return err
+	}
+	return cw.w.Flush()
+}
+
+func (cw *CZMLWriter) writePacket(packet *czmlPacket, separator string) error {
+	data, err := json.Marshal(packet)
+	if err != nil {
+		return err
+	}
+	if _, err := cw.w.WriteString(separator); err != nil {
+		return err
+	}
+	_, err = cw.w.Write(data)
+	return err
+}
+
+// times lists every sample time, always including the end of the document.
+func (cw *CZMLWriter) times() []time.Time {
+	var result []time.Time
+	for t := cw.doc.Start; t.Before(cw.doc.End); t = t.Add(cw.doc.Step) {
+		result = append(result, t)
+	}
+	return append(result, cw.doc.End)
+}
+
+func (cw *CZMLWriter) interval() string {
+	return czmlTime(cw.doc.Start) + "/" + czmlTime(cw.doc.End)
+}
+
+func czmlTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+type czmlPacket struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name,omitempty"`
+	Version      string        `json:"version,omitempty"`
+	Clock        *czmlClock    `json:"clock,omitempty"`
+	Availability string        `json:"availability,omitempty"`
+	Position     *czmlPosition `json:"position,omitempty"`
+	Point        *czmlPoint    `json:"point,omitempty"`
+	Path         *czmlPath     `json:"path,omitempty"`
+	Label        *czmlLabel    `json:"label,omitempty"`
+}
+
+type czmlClock struct {
+	Interval    string  `json:"interval"`
+	CurrentTime string  `json:"currentTime"`
+	Multiplier  float64 `json:"multiplier"`
+	Range       string  `json:"range"`
+	Step        string  `json:"step"`
+}
+
+type czmlPosition struct {
+	Epoch                  string    `json:"epoch"`
+	InterpolationAlgorithm string    `json:"interpolationAlgorithm"`
+	InterpolationDegree    int       `json:"interpolationDegree"`
+	ReferenceFrame         string    `json:"referenceFrame"`
+	Cartesian              []float64 `json:"cartesian"`
+}
+
+type czmlColor struct {
+	RGBA [4]int `json:"rgba"`
+}
+
+type czmlPoint struct {
+	Color     *czmlColor `json:"color"`
+	PixelSize float64    `json:"pixelSize,omitempty"`
+}
+
+type czmlSolidColor struct {
+	Color *czmlColor `json:"color"`
+}
+
+type czmlMaterial struct {
+	SolidColor czmlSolidColor `json:"solidColor"`
+}
+
+type czmlPath struct {
+	Material  czmlMaterial `json:"material"`
+	Width     float64      `json:"width,omitempty"`
+	TrailTime float64      `json:"trailTime,omitempty"`
+	LeadTime  float64      `json:"leadTime,omitempty"`
+}
+
+type czmlCartesian2 struct {
+	Cartesian2 [2]float64 `json:"cartesian2"`
+}
+
+type czmlLabel struct {
+	Text        string          `json:"text"`
+	FillColor   *czmlColor      `json:"fillColor"`
+	Font        string          `json:"font,omitempty"`
+	PixelOffset *czmlCartesian2 `json:"pixelOffset,omitempty"`
+	Horizontal  string          `json:"horizontalOrigin,omitempty"`
+}
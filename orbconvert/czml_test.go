@@ -0,0 +1,101 @@
+package orbconvert
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+type testPacket struct {
+	ID           string
+	Availability string
+	Clock        *struct {
+		Interval   string
+		Multiplier float64
+	}
+	Position *struct {
+		Epoch               string
+		InterpolationDegree int
+		ReferenceFrame      string
+		Cartesian           []float64
+	}
+	Label *struct{ Text string }
+	Path  *struct{ TrailTime float64 }
+}
+
+func TestCZMLWriter(t *testing.T) {
+	start := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10*24*time.Hour + 12*time.Hour)
+
+	var buf bytes.Buffer
+	w, err := NewCZMLWriter(&buf, CZMLDocument{Name: "test", Start: start, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteBody(orbdata.Earth, nil); err != nil {
+		t.Fatal(err)
+	}
+	style := CZMLStyle{Color: [4]int{128, 128, 128, 255}, PointSize: 2, TrailTime: 30 * 24 * time.Hour}
+	if err := w.WriteOrbit(ceres(), &style); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var packets []testPacket
+	if err := json.Unmarshal(buf.Bytes(), &packets); err != nil {
+		t.Fatalf("%v\n%v", err, buf.String())
+	}
+	if len(packets) != 3 {
+		t.Fatalf("expected 3 packets got %v", len(packets))
+	}
+
+	doc := packets[0]
+	if doc.ID != "document" || doc.Clock == nil || doc.Clock.Interval != "2025-05-05T00:00:00Z/2025-05-15T12:00:00Z" || doc.Clock.Multiplier != 86400 {
+		t.Errorf("unexpected document packet %+v", doc)
+	}
+
+	earth := packets[1]
+	if earth.ID != "Earth" || earth.Label == nil || earth.Label.Text != "Earth" || earth.Availability != doc.Clock.Interval {
+		t.Errorf("unexpected earth packet %+v", earth)
+	}
+	p := earth.Position
+	if p.Epoch != "2025-05-05T00:00:00Z" || p.ReferenceFrame != "INERTIAL" || p.InterpolationDegree != 5 {
+		t.Errorf("unexpected position %+v", p)
+	}
+	// Samples every day from the start plus the end.
+	if len(p.Cartesian) != 4*12 || p.Cartesian[4*11] != 10.5*86400 {
+		t.Fatalf("unexpected samples %v", p.Cartesian)
+	}
+
+	pos := orbdata.Earth.HeliocentricPosition(start)
+	x, y, z := toEquatorial(pos.X, pos.Y, pos.Z)
+	if math.Abs(p.Cartesian[1]-x*1000) > 1e-3 || math.Abs(p.Cartesian[2]-y*1000) > 1e-3 || math.Abs(p.Cartesian[3]-z*1000) > 1e-3 {
+		t.Errorf("expected equatorial metres %v,%v,%v got %v", x*1000, y*1000, z*1000, p.Cartesian[1:4])
+	}
+	if r := math.Sqrt(p.Cartesian[1]*p.Cartesian[1]+p.Cartesian[2]*p.Cartesian[2]+p.Cartesian[3]*p.Cartesian[3]) / 1000; math.Abs(r/orbdata.AU-1) > 0.02 {
+		t.Errorf("expected the earth about 1 AU from the sun got %v km", r)
+	}
+
+	asteroid := packets[2]
+	if asteroid.ID != "00001" || asteroid.Label != nil || asteroid.Path == nil || asteroid.Path.TrailTime != 30*86400 {
+		t.Errorf("unexpected asteroid packet %+v", asteroid)
+	}
+}
+
+func TestCZMLWriterErrors(t *testing.T) {
+	start := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	if _, err := NewCZMLWriter(&buf, CZMLDocument{Start: start, End: start}); err == nil {
+		t.Error("expected an error for an empty time range")
+	}
+	w, _ := NewCZMLWriter(&buf, CZMLDocument{Start: start, End: start.Add(time.Hour)})
+	if err := w.WritePositions("empty", nil, nil); err == nil {
+		t.Error("expected an error with no positions")
+	}
+}
@@ -0,0 +1,46 @@
+package designation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+PackDate converts a date into the five character packed form used for MPC epochs, 2019-01-03 becomes K1913.
+Any time of day is dropped.
+*/
+func PackDate(t time.Time) (string, error) {
+	t = t.UTC()
+	year, err := packYear(t.Year())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%c%c", year, digits[int(t.Month())], digits[t.Day()]), nil
+}
+
+/*
+UnpackDate converts a five character packed date into midnight UTC of that day.
+*/
+func UnpackDate(packed string) (time.Time, error) {
+	if len(packed) != 5 {
+		return time.Time{}, fmt.Errorf("packed date %q should be five characters", packed)
+	}
+
+	year, err := unpackYear(packed[0:3])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	month := strings.IndexByte(digits, packed[3])
+	day := strings.IndexByte(digits, packed[4])
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("could not unpack date %q", packed)
+	}
+
+	result := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if result.Day() != day {
+		return time.Time{}, fmt.Errorf("packed date %q is not a valid day", packed)
+	}
+	return result, nil
+}
@@ -0,0 +1,378 @@
+/*
+Package designation converts between the packed designations used in MPC files and the human readable forms.
+
+Permanent numbers ("433", "620000"), provisional designations ("1995 XA12"), survey designations ("2040 P-L"), comets
+("1P", "C/1995 O1", "P/2019 A4-B") and natural satellites ("Jupiter XIII", "S/2019 J 1") are supported.
+*/
+package designation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// base62 digits as used by the MPC for numbers and cycle counts.
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Beyond this number permanent designations are packed as a tilde followed by four base 62 digits.
+const tildeStart = 620000
+
+var planets = map[string]byte{
+	"Earth":   'E',
+	"Mars":    'M',
+	"Jupiter": 'J',
+	"Saturn":  'S',
+	"Uranus":  'U',
+	"Neptune": 'N',
+}
+
+var planetNames = map[byte]string{
+	'E': "Earth",
+	'M': "Mars",
+	'J': "Jupiter",
+	'S': "Saturn",
+	'U': "Uranus",
+	'N': "Neptune",
+}
+
+var (
+	numberRegex             = regexp.MustCompile(`^\(?(\d+)\)?$`)
+	provisionalRegex        = regexp.MustCompile(`^(\d{4}) ([A-Z])([A-Z])(\d*)$`)
+	surveyRegex             = regexp.MustCompile(`^(\d{4}) (P-L|T-1|T-2|T-3)$`)
+	numberedCometRegex      = regexp.MustCompile(`^(\d+)([PDCXI])$`)
+	provisionalCometRegex   = regexp.MustCompile(`^([PDCXIA])/(\d{4}) ([A-Z])(\d+)(?:-([A-Z]))?$`)
+	provisionalSatRegex     = regexp.MustCompile(`^S/(\d{4}) ([EMJSUN]) (\d+)$`)
+	permanentSatelliteRegex = regexp.MustCompile(`^([A-Z][a-z]+) ([IVXLCDM]+)$`)
+)
+
+/*
+Pack converts a readable designation into its packed form.
+*/
+func Pack(readable string) (string, error) {
+	readable = strings.TrimSpace(readable)
+
+	if m := numberRegex.FindStringSubmatch(readable); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return "", err
+		}
+		return PackNumber(n)
+	}
+
+	if m := provisionalRegex.FindStringSubmatch(readable); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		cycle := 0
+		if m[4] != "" {
+			cycle, _ = strconv.Atoi(m[4])
+		}
+		return packProvisional(year, m[2][0], m[3][0], cycle)
+	}
+
+	if m := surveyRegex.FindStringSubmatch(readable); m != nil {
+		return strings.Replace(m[2], "-", "", 1) + "S" + m[1], nil
+	}
+
+	if m := numberedCometRegex.FindStringSubmatch(readable); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if n > 9999 {
+			return "", fmt.Errorf("comet number %d is too large to pack", n)
+		}
+		return fmt.Sprintf("%04d%s", n, m[2]), nil
+	}
+
+	if m := provisionalCometRegex.FindStringSubmatch(readable); m != nil {
+		year, _ := strconv.Atoi(m[2])
+		order, _ := strconv.Atoi(m[4])
+		packed, err := packComet(year, m[3][0], order)
+		if err != nil {
+			return "", err
+		}
+		fragment := "0"
+		if m[5] != "" {
+			fragment = strings.ToLower(m[5])
+		}
+		return m[1] + packed + fragment, nil
+	}
+
+	if m := provisionalSatRegex.FindStringSubmatch(readable); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		order, _ := strconv.Atoi(m[3])
+		packed, err := packComet(year, m[2][0], order)
+		if err != nil {
+			return "", err
+		}
+		return "S" + packed + "0", nil
+	}
+
+	if m := permanentSatelliteRegex.FindStringSubmatch(readable); m != nil {
+		planet, ok := planets[m[1]]
+		if !ok {
+			return "", fmt.Errorf("unknown planet %q", m[1])
+		}
+		n, err := fromRoman(m[2])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%c%03dS", planet, n), nil
+	}
+
+	return "", fmt.Errorf("could not pack designation %q", readable)
+}
+
+/*
+Unpack converts a packed designation into its readable form. Permanent numbers are returned without brackets.
+*/
+func Unpack(packed string) (string, error) {
+	packed = strings.TrimSpace(packed)
+
+	switch len(packed) {
+	case 5:
+		if packed[4] == 'S' && strings.IndexByte("EMJSUN", packed[0]) >= 0 && isDigits(packed[1:4]) {
+			n, _ := strconv.Atoi(packed[1:4])
+			return planetNames[packed[0]] + " " + toRoman(n), nil
+		}
+		if strings.IndexByte("PDCXI", packed[4]) >= 0 && isDigits(packed[0:4]) {
+			n, _ := strconv.Atoi(packed[0:4])
+			return fmt.Sprintf("%d%c", n, packed[4]), nil
+		}
+		n, err := UnpackNumber(packed)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(n), nil
+
+	case 7:
+		if isDigits(packed[3:7]) {
+			switch packed[0:3] {
+			case "PLS":
+				return packed[3:7] + " P-L", nil
+			case "T1S", "T2S", "T3S":
+				return packed[3:7] + " T-" + packed[1:2], nil
+			}
+		}
+		return unpackProvisional(packed)
+
+	case 8:
+		year, halfMonth, order, err := unpackComet(packed[1:7])
+		if err != nil {
+			return "", err
+		}
+		if packed[0] == 'S' {
+			return fmt.Sprintf("S/%d %c %d", year, halfMonth, order), nil
+		}
+		if strings.IndexByte("PDCXIA", packed[0]) < 0 {
+			return "", fmt.Errorf("unknown comet type in %q", packed)
+		}
+		result := fmt.Sprintf("%c/%d %c%d", packed[0], year, halfMonth, order)
+		if packed[7] != '0' {
+			result += "-" + strings.ToUpper(packed[7:8])
+		}
+		return result, nil
+	}
+
+	return "", fmt.Errorf("could not unpack designation %q", packed)
+}
+
+/*
+PackNumber packs a permanent minor planet number.
+*/
+func PackNumber(n int) (string, error) {
+	switch {
+	case n <= 0:
+		return "", fmt.Errorf("number %d can not be packed", n)
+	case n < 100000:
+		return fmt.Sprintf("%05d", n), nil
+	case n < tildeStart:
+		return fmt.Sprintf("%c%04d", digits[n/10000], n%10000), nil
+	case n < tildeStart+62*62*62*62:
+		return "~" + toBase62(n-tildeStart, 4), nil
+	}
+	return "", fmt.Errorf("number %d is too large to pack", n)
+}
+
+/*
+UnpackNumber converts a packed permanent minor planet number back into an integer.
+*/
+func UnpackNumber(packed string) (int, error) {
+	if len(packed) != 5 {
+		return 0, fmt.Errorf("packed number %q should be five characters", packed)
+	}
+
+	if packed[0] == '~' {
+		v, err := fromBase62(packed[1:])
+		if err != nil {
+			return 0, err
+		}
+		return v + tildeStart, nil
+	}
+
+	if !isDigits(packed[1:]) {
+		return 0, fmt.Errorf("could not unpack number %q", packed)
+	}
+	high := strings.IndexByte(digits, packed[0])
+	if high < 0 {
+		return 0, fmt.Errorf("could not unpack number %q", packed)
+	}
+	low, _ := strconv.Atoi(packed[1:])
+	return high*10000 + low, nil
+}
+
+func packProvisional(year int, halfMonth, second byte, cycle int) (string, error) {
+	c, err := packYear(year)
+	if err != nil {
+		return "", err
+	}
+	cc, err := packCycle(cycle)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%c%s%c", c, halfMonth, cc, second), nil
+}
+
+func unpackProvisional(packed string) (string, error) {
+	year, err := unpackYear(packed[0:3])
+	if err != nil {
+		return "", err
+	}
+	cycle, err := unpackCycle(packed[4:6])
+	if err != nil {
+		return "", err
+	}
+	if !isUpper(packed[3]) || !isUpper(packed[6]) {
+		return "", fmt.Errorf("could not unpack provisional designation %q", packed)
+	}
+
+	result := fmt.Sprintf("%d %c%c", year, packed[3], packed[6])
+	if cycle > 0 {
+		result += strconv.Itoa(cycle)
+	}
+	return result, nil
+}
+
+// packComet packs the year, half month and order of a comet or satellite designation into six characters.
+func packComet(year int, halfMonth byte, order int) (string, error) {
+	c, err := packYear(year)
+	if err != nil {
+		return "", err
+	}
+	o, err := packCycle(order)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%c%s", c, halfMonth, o), nil
+}
+
+func unpackComet(packed string) (int, byte, int, error) {
+	year, err := unpackYear(packed[0:3])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	order, err := unpackCycle(packed[4:6])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return year, packed[3], order, nil
+}
+
+func packYear(year int) (string, error) {
+	century := year / 100
+	if century < 10 || century > 35 {
+		return "", fmt.Errorf("year %d can not be packed", year)
+	}
+	return fmt.Sprintf("%c%02d", digits[century], year%100), nil
+}
+
+func unpackYear(packed string) (int, error) {
+	century := strings.IndexByte(digits, packed[0])
+	if century < 10 || century > 35 || !isDigits(packed[1:3]) {
+		return 0, fmt.Errorf("could not unpack year %q", packed)
+	}
+	y, _ := strconv.Atoi(packed[1:3])
+	return century*100 + y, nil
+}
+
+func packCycle(cycle int) (string, error) {
+	if cycle < 0 || cycle >= 620 {
+		return "", fmt.Errorf("cycle count %d can not be packed", cycle)
+	}
+	return fmt.Sprintf("%c%d", digits[cycle/10], cycle%10), nil
+}
+
+func unpackCycle(packed string) (int, error) {
+	high := strings.IndexByte(digits, packed[0])
+	if high < 0 || !isDigits(packed[1:2]) {
+		return 0, fmt.Errorf("could not unpack cycle count %q", packed)
+	}
+	return high*10 + int(packed[1]-'0'), nil
+}
+
+func toBase62(n int, width int) string {
+	result := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		result[i] = digits[n%62]
+		n /= 62
+	}
+	return string(result)
+}
+
+func fromBase62(in string) (int, error) {
+	result := 0
+	for i := 0; i < len(in); i++ {
+		v := strings.IndexByte(digits, in[i])
+		if v < 0 {
+			return 0, fmt.Errorf("invalid base 62 digit %q", in[i])
+		}
+		result = result*62 + v
+	}
+	return result, nil
+}
+
+var romanValues = []struct {
+	value  int
+	symbol string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"},
+	{100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"},
+	{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+func toRoman(n int) string {
+	var sb strings.Builder
+	for _, r := range romanValues {
+		for n >= r.value {
+			sb.WriteString(r.symbol)
+			n -= r.value
+		}
+	}
+	return sb.String()
+}
+
+func fromRoman(in string) (int, error) {
+	result := 0
+	rest := in
+	for _, r := range romanValues {
+		for strings.HasPrefix(rest, r.symbol) {
+			result += r.value
+			rest = rest[len(r.symbol):]
+		}
+	}
+	if rest != "" || result == 0 || toRoman(result) != in {
+		return 0, fmt.Errorf("invalid roman numeral %q", in)
+	}
+	return result, nil
+}
+
+func isDigits(in string) bool {
+	for i := 0; i < len(in); i++ {
+		if in[i] < '0' || in[i] > '9' {
+			return false
+		}
+	}
+	return len(in) > 0
+}
+
+func isUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
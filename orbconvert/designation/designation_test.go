@@ -0,0 +1,118 @@
+package designation
+
+import (
+	"testing"
+	"time"
+)
+
+var designationCases = []struct {
+	packed   string
+	readable string
+}{
+	{"00001", "1"},
+	{"A0345", "100345"},
+	{"a0017", "360017"},
+	{"K3289", "203289"},
+	{"~0000", "620000"},
+	{"~000z", "620061"},
+	{"~AZaz", "3140113"},
+	{"~zzzz", "15396335"},
+	{"J95X00A", "1995 XA"},
+	{"J95X01L", "1995 XL1"},
+	{"J95F13B", "1995 FB13"},
+	{"J98SA8Q", "1998 SQ108"},
+	{"J98SC7V", "1998 SV127"},
+	{"K99AJ3Z", "2099 AZ193"},
+	{"K08Aa0A", "2008 AA360"},
+	{"K07Tf8A", "2007 TA418"},
+	{"PLS2040", "2040 P-L"},
+	{"T1S3138", "3138 T-1"},
+	{"T3S4101", "4101 T-3"},
+	{"0001P", "1P"},
+	{"0073P", "73P"},
+	{"CJ95O010", "C/1995 O1"},
+	{"PJ94P01b", "P/1994 P1-B"},
+	{"PK19A040", "P/2019 A4"},
+	{"J013S", "Jupiter XIII"},
+	{"N002S", "Neptune II"},
+	{"SK19J010", "S/2019 J 1"},
+}
+
+func TestUnpack(t *testing.T) {
+	for _, c := range designationCases {
+		r, err := Unpack(c.packed)
+		if err != nil {
+			t.Errorf("%v: %v", c.packed, err)
+			continue
+		}
+		if r != c.readable {
+			t.Errorf("%v: expected %q got %q", c.packed, c.readable, r)
+		}
+	}
+}
+
+func TestPack(t *testing.T) {
+	for _, c := range designationCases {
+		r, err := Pack(c.readable)
+		if err != nil {
+			t.Errorf("%v: %v", c.readable, err)
+			continue
+		}
+		if r != c.packed {
+			t.Errorf("%v: expected %q got %q", c.readable, c.packed, r)
+		}
+	}
+
+	if r, err := Pack("(433)"); err != nil || r != "00433" {
+		t.Errorf("expected bracketed numbers to pack, got %v %v", r, err)
+	}
+}
+
+func TestPackInvalid(t *testing.T) {
+	for _, in := range []string{"", "0", "1995 xa", "2019 AA620", "Pluto I", "Jupiter IIII", "Ceres"} {
+		if r, err := Pack(in); err == nil {
+			t.Errorf("%q: expected an error got %v", in, r)
+		}
+	}
+}
+
+func TestUnpackInvalid(t *testing.T) {
+	for _, in := range []string{"", "0000!", "J95X0!A", "z95X00A", "QJ95O010"} {
+		if r, err := Unpack(in); err == nil {
+			t.Errorf("%q: expected an error got %v", in, r)
+		}
+	}
+}
+
+func TestPackedDates(t *testing.T) {
+	cases := map[string]time.Time{
+		"J9611": time.Date(1996, 1, 1, 0, 0, 0, 0, time.UTC),
+		"J961A": time.Date(1996, 1, 10, 0, 0, 0, 0, time.UTC),
+		"J969U": time.Date(1996, 9, 30, 0, 0, 0, 0, time.UTC),
+		"K01AM": time.Date(2001, 10, 22, 0, 0, 0, 0, time.UTC),
+		"K1913": time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+		"I00CA": time.Date(1800, 12, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	for packed, date := range cases {
+		r, err := UnpackDate(packed)
+		if err != nil {
+			t.Errorf("%v: %v", packed, err)
+		} else if !r.Equal(date) {
+			t.Errorf("%v: expected %v got %v", packed, date, r)
+		}
+
+		p, err := PackDate(date)
+		if err != nil {
+			t.Errorf("%v: %v", date, err)
+		} else if p != packed {
+			t.Errorf("%v: expected %v got %v", date, packed, p)
+		}
+	}
+
+	for _, in := range []string{"K19", "K19D1", "K192U"} {
+		if _, err := UnpackDate(in); err == nil {
+			t.Errorf("%v: expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package orbconvert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbconvert/designation"
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+/*
+MpcJSONReader streams orbits out of the MPC extended json catalog (mpcorb_extended.json).
+
+The catalog is one very large json array. Entries are decoded one at a time so the whole document is never held in
+memory. Gzip or bzip2 compressed input is detected and decompressed on the fly.
+*/
+type MpcJSONReader struct {
+	closers []io.Closer
+	decoder *json.Decoder
+	started bool
+}
+
+/*
+NewMpcJSONReader opens the file at path ready to read orbits from it.
+*/
+func NewMpcJSONReader(path string) (*MpcJSONReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := NewMpcJSONReaderFromReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	result.closers = append(result.closers, f)
+	return result, nil
+}
+
+/*
+NewMpcJSONReaderFromReader creates an MpcJSONReader that reads from an already open reader.
+*/
+func NewMpcJSONReaderFromReader(in io.Reader) (*MpcJSONReader, error) {
+	var result MpcJSONReader
+
+	source, err := orbcore.NewDecompressingReader(in)
+	if err != nil {
+		return nil, err
+	}
+	result.closers = append(result.closers, source)
+
+	result.decoder = json.NewDecoder(source)
+	return &result, nil
+}
+
+/*
+ReadEntry returns the next orbit in the catalog. At the end of the input io.EOF is returned.
+*/
+func (r *MpcJSONReader) ReadEntry() (*orbcore.Orbit, error) {
+	if !r.started {
+		t, err := r.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := t.(json.Delim); !ok || d != '[' {
+			return nil, fmt.Errorf("expected the catalog to be a json array got %v", t)
+		}
+		r.started = true
+	}
+
+	if !r.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var entry MpcJSONEntry
+	if err := r.decoder.Decode(&entry); err != nil {
+		return nil, err
+	}
+	return ConvertFromMpcJSON(&entry)
+}
+
+/*
+Close closes the decompressor and file if this reader opened them.
+*/
+func (r *MpcJSONReader) Close() error {
+	var result error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if err := r.closers[i].Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+/*
+MpcJSONEntry is a single object from the MPC extended json catalog. Fields not present for an object are left zero.
+*/
+type MpcJSONEntry struct {
+	Number           string  `json:"Number"`
+	Name             string  `json:"Name"`
+	PrincipalDesig   string  `json:"Principal_desig"`
+	H                float64 `json:"H"`
+	G                float64 `json:"G"`
+	Epoch            float64 `json:"Epoch"` // julian date
+	M                float64 `json:"M"`
+	Peri             float64 `json:"Peri"`
+	Node             float64 `json:"Node"`
+	I                float64 `json:"i"`
+	E                float64 `json:"e"`
+	N                float64 `json:"n"`
+	A                float64 `json:"a"`
+	U                string  `json:"U"`
+	Ref              string  `json:"Ref"`
+	NumObs           int64   `json:"Num_obs"`
+	NumOpps          int64   `json:"Num_opps"`
+	ArcYears         string  `json:"Arc_years"`
+	ArcLength        float64 `json:"Arc_length"` // days
+	RMS              float64 `json:"rms"`
+	Perturbers       string  `json:"Perturbers"`
+	Perturbers2      string  `json:"Perturbers_2"`
+	Computer         string  `json:"Computer"`
+	HexFlags         string  `json:"Hex_flags"`
+	LastObs          string  `json:"Last_obs"`
+	OrbitType        string  `json:"Orbit_type"`
+	NEOFlag          int     `json:"NEO_flag"`
+	OneKmNEOFlag     int     `json:"One_km_NEO_flag"`
+	PHAFlag          int     `json:"PHA_flag"`
+	PerihelionDist   float64 `json:"Perihelion_dist"`
+	AphelionDist     float64 `json:"Aphelion_dist"`
+	OrbitalPeriod    float64 `json:"Orbital_period"`
+	TisserandJupiter float64 `json:"Tisserand_jupiter"`
+}
+
+/*
+ConvertFromMpcJSON converts an entry from the extended json catalog into an orbit. The ID is the packed designation
+so it matches orbits read from MPCORB.DAT.
+*/
+func ConvertFromMpcJSON(entry *MpcJSONEntry) (*orbcore.Orbit, error) {
+	var result orbcore.Orbit
+
+	readable := entry.PrincipalDesig
+	if entry.Number != "" {
+		readable = entry.Number
+	}
+	id, err := designation.Pack(readable)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ID = id
+	result.ParentGrav = orbdata.SunGrav
+	result.Epoch = orbcore.TimeFromJulianDate(entry.Epoch)
+	result.MeanAnomalyEpoch = DegToRad(entry.M)
+	result.ArgumentOfPerihelion = DegToRad(entry.Peri)
+	result.LongitudeOfTheAscendingNode = DegToRad(entry.Node)
+	result.InclinationToTheEcliptic = DegToRad(entry.I)
+	result.OrbitalEccentricity = entry.E
+	result.MeanDailyMotion = entry.N
+	result.SemimajorAxis = AuToKm(entry.A)
+	result.AbsoluteMagnitude = entry.H
+	result.Slope = entry.G
+
+	meta := orbcore.Metadata{
+		UncertaintyParameter:         entry.U,
+		Reference:                    entry.Ref,
+		NumberOfObservations:         entry.NumObs,
+		NumberOfOppositions:          entry.NumOpps,
+		ArcLength:                    int64(entry.ArcLength),
+		RmsResidual:                  entry.RMS,
+		CoarseIndicatorOfPerturbers:  entry.Perturbers,
+		PreciseIndicatorOfPerturbers: entry.Perturbers2,
+		ComputerName:                 entry.Computer,
+		Flags:                        entry.HexFlags,
+		OrbitType:                    entry.OrbitType,
+		NEO:                          entry.NEOFlag == 1,
+		PHA:                          entry.PHAFlag == 1,
+	}
+	meta.ReadableDesignation = readableDesignation(entry)
+	meta.PrincipalDesignation = entry.PrincipalDesig
+
+	if first, last, ok := parseArcYears(entry.ArcYears); ok {
+		meta.YearOfFirstObservation = first
+		meta.YearOfLastObservation = last
+	}
+	if entry.LastObs != "" {
+		if meta.LastObservation, err = time.Parse("2006-01-02", entry.LastObs); err != nil {
+			return nil, fmt.Errorf("could not parse last observation date for %v: %v", id, err)
+		}
+	}
+	result.Metadata = &meta
+
+	return &result, nil
+}
+
+// readableDesignation builds the MPCORB style readable designation, "(1) Ceres" for named objects.
+func readableDesignation(entry *MpcJSONEntry) string {
+	switch {
+	case entry.Number != "" && entry.Name != "":
+		return entry.Number + " " + entry.Name
+	case entry.Number != "":
+		return entry.Number + " " + entry.PrincipalDesig
+	}
+	return entry.PrincipalDesig
+}
+
+func parseArcYears(in string) (int64, int64, bool) {
+	parts := strings.Split(in, "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	first, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	last, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return first, last, true
+}
@@ -0,0 +1,104 @@
+package orbconvert
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+const testJSONCatalog = `[
+{"H":3.54,"G":0.12,"Num_obs":7330,"rms":0.8,"U":"0","Arc_years":"1801-2024","Perturbers":"M-v","Perturbers_2":"30k",
+ "Number":"(1)","Name":"Ceres","Principal_desig":"A899 OF","Other_desigs":["1943 XB"],"Epoch":2460600.5,"M":145.84905,
+ "Peri":73.27653,"Node":80.26736,"i":10.5879,"e":0.079184,"n":0.21424861,"a":2.7656975,"Ref":"E2024-V47","Num_opps":125,
+ "Computer":"MPCLINUX","Hex_flags":"0000","Last_obs":"2024-11-01","Tp":2460208.3,"Orbital_period":4.6,
+ "Perihelion_dist":2.547,"Aphelion_dist":2.984,"Semilatus_rectum":1.374,"Synodic_period":1.277,"Orbit_type":"MBA"},
+{"H":19.1,"G":0.15,"Num_obs":42,"rms":0.35,"U":"6","Arc_length":12,"Principal_desig":"2019 AA","Epoch":2458480.5,
+ "M":10.5,"Peri":120.1,"Node":300.2,"i":5.1,"e":0.45,"n":0.5,"a":1.6,"Ref":"MPEC 2019-A01","Num_opps":1,
+ "Computer":"MPCW","Hex_flags":"0803","Last_obs":"2019-01-14","Orbit_type":"Apollo","NEO_flag":1,"PHA_flag":1}
+]`
+
+func readJSONCatalog(t *testing.T, in io.Reader) []*orbcore.Orbit {
+	reader, err := NewMpcJSONReaderFromReader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var result []*orbcore.Orbit
+	orb, err := reader.ReadEntry()
+	for err == nil {
+		result = append(result, orb)
+		orb, err = reader.ReadEntry()
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestMpcJSONReader(t *testing.T) {
+	result := readJSONCatalog(t, strings.NewReader(testJSONCatalog))
+	if len(result) != 2 {
+		t.Fatalf("expected 2 orbits got %v", len(result))
+	}
+
+	ceres := result[0]
+	if ceres.ID != "00001" {
+		t.Errorf("expected packed id 00001 got %v", ceres.ID)
+	}
+	if !ceres.Epoch.Equal(time.Date(2024, 10, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected epoch %v", ceres.Epoch)
+	}
+	if ceres.AbsoluteMagnitude != 3.54 || ceres.Slope != 0.12 {
+		t.Errorf("unexpected magnitude parameters %v %v", ceres.AbsoluteMagnitude, ceres.Slope)
+	}
+	meta := ceres.Metadata
+	if meta.ReadableDesignation != "(1) Ceres" || meta.YearOfFirstObservation != 1801 || meta.YearOfLastObservation != 2024 {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+	if meta.PrincipalDesignation != "A899 OF" {
+		t.Errorf("expected principal designation A899 OF got %q", meta.PrincipalDesignation)
+	}
+	if meta.CoarseIndicatorOfPerturbers != "M-v" || meta.PreciseIndicatorOfPerturbers != "30k" || meta.OrbitType != "MBA" || meta.NEO {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+
+	neo := result[1]
+	if neo.ID != "K19A00A" || neo.Metadata.ReadableDesignation != "2019 AA" {
+		t.Errorf("unexpected designation %v %v", neo.ID, neo.Metadata.ReadableDesignation)
+	}
+	if neo.Metadata.ArcLength != 12 || !neo.Metadata.NEO || !neo.Metadata.PHA || neo.Metadata.OrbitType != "Apollo" {
+		t.Errorf("unexpected metadata %+v", neo.Metadata)
+	}
+}
+
+func TestMpcJSONReaderGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(testJSONCatalog)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := readJSONCatalog(t, &buf)
+	if len(result) != 2 {
+		t.Fatalf("expected 2 orbits got %v", len(result))
+	}
+}
+
+func TestMpcJSONReaderNotArray(t *testing.T) {
+	reader, err := NewMpcJSONReaderFromReader(strings.NewReader(`{"Number":"(1)"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.ReadEntry(); err == nil {
+		t.Error("expected an error for a document that is not an array")
+	}
+}
@@ -0,0 +1,281 @@
+package orbconvert
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emilyselwood/gompcreader"
+	"github.com/emilyselwood/orbcalc/orbconvert/designation"
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+MpcorbWriter writes orbits out in the fixed width format of the MPCORB.DAT file.
+*/
+type MpcorbWriter struct {
+	w *bufio.Writer
+}
+
+/*
+NewMpcorbWriter creates a writer that writes MPCORB formatted lines to out. Flush must be called once all the entries
+have been written.
+*/
+func NewMpcorbWriter(out io.Writer) *MpcorbWriter {
+	return &MpcorbWriter{
+		w: bufio.NewWriterSize(out, 64*1024),
+	}
+}
+
+/*
+WriteEntry writes a single orbit as one line.
+*/
+func (mw *MpcorbWriter) WriteEntry(orb *orbcore.Orbit) error {
+	line, err := FormatMpcorbLine(orb)
+	if err != nil {
+		return err
+	}
+	if _, err := mw.w.WriteString(line); err != nil {
+		return err
+	}
+	return mw.w.WriteByte('\n')
+}
+
+/*
+Flush writes any buffered data to the underlying writer.
+*/
+func (mw *MpcorbWriter) Flush() error {
+	return mw.w.Flush()
+}
+
+/*
+FormatMpcorbLine converts an orbit into a line of the MPCORB.DAT file.
+
+The ID of the orbit must already be a packed designation. MPCORB epochs are whole days so an orbit with an epoch part
+way through a day is propagated to the nearest midnight before it is written. Catalog columns are taken from the
+orbit Metadata when there is some, otherwise they are left blank.
+*/
+func FormatMpcorbLine(orb *orbcore.Orbit) (string, error) {
+	if len(orb.ID) == 0 || len(orb.ID) > 7 {
+		return "", fmt.Errorf("id %q can not be written as a packed designation", orb.ID)
+	}
+
+	orb = toWholeDay(orb)
+	epoch, err := designation.PackDate(orb.Epoch)
+	if err != nil {
+		return "", err
+	}
+
+	meanDailyMotion := orb.MeanDailyMotion
+	if meanDailyMotion == 0 && orb.ParentGrav != 0 {
+		meanDailyMotion = RadToDeg(math.Sqrt(orb.ParentGrav/math.Pow(orb.SemimajorAxis, 3))) * 86400
+	}
+
+	var sb strings.Builder
+	sb.Grow(202)
+
+	fmt.Fprintf(&sb, "%-7s %5.2f %5.2f %5s %9.5f  %9.5f  %9.5f  %9.5f  %9.7f %11.8f %11.7f",
+		orb.ID,
+		orb.AbsoluteMagnitude,
+		orb.Slope,
+		epoch,
+		normaliseDegrees(RadToDeg(orb.MeanAnomalyEpoch)),
+		normaliseDegrees(RadToDeg(orb.ArgumentOfPerihelion)),
+		normaliseDegrees(RadToDeg(orb.LongitudeOfTheAscendingNode)),
+		RadToDeg(orb.InclinationToTheEcliptic),
+		orb.OrbitalEccentricity,
+		meanDailyMotion,
+		KmToAu(orb.SemimajorAxis),
+	)
+
+	meta := orb.Metadata
+	if meta == nil {
+		meta = &orbcore.Metadata{}
+	}
+
+	lastObservation := ""
+	if !meta.LastObservation.IsZero() {
+		lastObservation = meta.LastObservation.Format("20060102")
+	}
+	designation := meta.ReadableDesignation
+	if designation == "" {
+		designation = orb.ID
+	}
+	// Numbered objects have their number right aligned so the names line up.
+	if strings.HasPrefix(designation, "(") {
+		if end := strings.Index(designation, ")"); end > 0 {
+			designation = fmt.Sprintf("%8s%s", designation[:end+1], designation[end+1:])
+		}
+	}
+
+	fmt.Fprintf(&sb, "  %1s %-9s %5s %3s %9s %4s %-3s %-3s %-10s %4s %-28s%8s",
+		meta.UncertaintyParameter,
+		meta.Reference,
+		blankIfZero(meta.NumberOfObservations, "%5d"),
+		blankIfZero(meta.NumberOfOppositions, "%3d"),
+		formatArc(meta),
+		formatRms(meta.RmsResidual, meta.NumberOfObservations),
+		meta.CoarseIndicatorOfPerturbers,
+		meta.PreciseIndicatorOfPerturbers,
+		meta.ComputerName,
+		meta.Flags,
+		designation,
+		lastObservation,
+	)
+
+	return sb.String(), nil
+}
+
+/*
+ParseMpcorbLine converts a line of the MPCORB.DAT file into an orbit. Only the orbital elements are required, the
+catalog columns after them may be blank or missing.
+*/
+func ParseMpcorbLine(line string) (*orbcore.Orbit, error) {
+	mp, err := ParseMinorPlanet(line)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertFromMinorPlanet(mp), nil
+}
+
+/*
+ParseMinorPlanet reads a line of the MPCORB.DAT file into a gompcreader minor planet, in the units of the file, so it
+can be converted the same way as the records from gompcreader. The gompcreader MpcReader can only open a file by name,
+this lets the columns be read from any source such as a decompressed stream.
+*/
+func ParseMinorPlanet(line string) (*gompcreader.MinorPlanet, error) {
+	if len(line) < 103 {
+		return nil, fmt.Errorf("mpcorb line is %d characters long, expected at least 103", len(line))
+	}
+
+	field := func(start, end int) string {
+		if start >= len(line) {
+			return ""
+		}
+		if end > len(line) {
+			end = len(line)
+		}
+		return strings.TrimSpace(line[start:end])
+	}
+	var err error
+	number := func(name string, start, end int) float64 {
+		if err != nil {
+			return 0
+		}
+		var v float64
+		if v, err = strconv.ParseFloat(field(start, end), 64); err != nil {
+			err = fmt.Errorf("could not parse %v %q: %v", name, field(start, end), err)
+		}
+		return v
+	}
+	optional := func(name string, start, end int) float64 {
+		if field(start, end) == "" {
+			return 0
+		}
+		return number(name, start, end)
+	}
+	integer := func(name string, start, end int) int64 {
+		return int64(optional(name, start, end))
+	}
+
+	var result gompcreader.MinorPlanet
+	result.ID = field(0, 7)
+	if result.ID == "" {
+		return nil, fmt.Errorf("orbit has no designation")
+	}
+	if result.Epoch, err = designation.UnpackDate(field(20, 25)); err != nil {
+		return nil, err
+	}
+
+	result.AbsoluteMagnitude = optional("absolute magnitude", 8, 13)
+	result.Slope = optional("slope", 14, 19)
+	result.MeanAnomalyEpoch = number("mean anomaly", 26, 35)
+	result.ArgumentOfPerihelion = number("argument of perihelion", 37, 46)
+	result.LongitudeOfTheAscendingNode = number("longitude of the ascending node", 48, 57)
+	result.InclinationToTheEcliptic = number("inclination", 59, 68)
+	result.OrbitalEccentricity = number("eccentricity", 70, 79)
+	result.MeanDailyMotion = number("mean daily motion", 80, 91)
+	result.SemimajorAxis = number("semimajor axis", 92, 103)
+
+	result.UncertaintyParameter = field(105, 106)
+	result.Reference = field(107, 116)
+	result.NumberOfObservations = integer("number of observations", 117, 122)
+	result.NumberOfOppositions = integer("number of oppositions", 123, 126)
+	result.RMSResidual = optional("rms residual", 137, 141)
+	result.CoarseIndicatorOfPerturbers = field(142, 145)
+	result.PreciseIndicatorOfPerturbers = field(146, 149)
+	result.ComputerName = field(150, 160)
+	result.ReadableDesignation = field(166, 194)
+	if err != nil {
+		return nil, err
+	}
+	if flags := field(161, 165); flags != "" {
+		if result.HexDigitFlags, err = strconv.ParseInt(flags, 16, 64); err != nil {
+			return nil, fmt.Errorf("could not parse hex flags %q: %v", flags, err)
+		}
+	}
+
+	arc := field(127, 136)
+	if strings.HasSuffix(arc, "days") {
+		result.ArcLength = integer("arc length", 127, 132)
+	} else if first, last, ok := parseArcYears(arc); ok {
+		result.YearOfFirstObservation = first
+		result.YearOfLastObservation = last
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if last := field(194, 202); last != "" {
+		if result.DateOfLastObservation, err = time.Parse("20060102", last); err != nil {
+			return nil, fmt.Errorf("could not parse last observation date %q: %v", last, err)
+		}
+	}
+
+	return &result, nil
+}
+
+// toWholeDay moves an orbit to the nearest midnight so that its epoch can be packed.
+func toWholeDay(orb *orbcore.Orbit) *orbcore.Orbit {
+	day := orb.Epoch.UTC().Round(24 * time.Hour)
+	if day.Equal(orb.Epoch) {
+		return orb
+	}
+	return orbcore.MeanMotion(orb, day.Sub(orb.Epoch))
+}
+
+func formatArc(meta *orbcore.Metadata) string {
+	if meta.NumberOfOppositions > 1 {
+		return fmt.Sprintf("%04d-%04d", meta.YearOfFirstObservation, meta.YearOfLastObservation)
+	}
+	if meta.ArcLength > 0 {
+		return fmt.Sprintf("%4d days", meta.ArcLength)
+	}
+	return ""
+}
+
+func formatRms(rms float64, observations int64) string {
+	if observations == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%4.2f", rms)
+}
+
+func blankIfZero(v int64, format string) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf(format, v)
+}
+
+func normaliseDegrees(in float64) float64 {
+	in = math.Mod(in, 360)
+	if in < 0 {
+		in += 360
+	}
+	return in
+}
@@ -0,0 +1,269 @@
+package orbconvert
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+func ceres() *orbcore.Orbit {
+	return &orbcore.Orbit{
+		ID:                          "00001",
+		ParentGrav:                  orbdata.SunGrav,
+		Epoch:                       time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC),
+		MeanAnomalyEpoch:            DegToRad(145.84905),
+		ArgumentOfPerihelion:        DegToRad(73.27653),
+		LongitudeOfTheAscendingNode: DegToRad(80.26736),
+		InclinationToTheEcliptic:    DegToRad(10.5879),
+		OrbitalEccentricity:         0.079184,
+		MeanDailyMotion:             0.21424861,
+		SemimajorAxis:               AuToKm(2.7656975),
+		AbsoluteMagnitude:           3.53,
+		Slope:                       0.15,
+		Metadata: &orbcore.Metadata{
+			ReadableDesignation:          "(1) Ceres",
+			UncertaintyParameter:         "0",
+			Reference:                    "E2024-V47",
+			NumberOfObservations:         7330,
+			NumberOfOppositions:          125,
+			YearOfFirstObservation:       1801,
+			YearOfLastObservation:        2024,
+			RmsResidual:                  0.8,
+			CoarseIndicatorOfPerturbers:  "M-v",
+			PreciseIndicatorOfPerturbers: "30k",
+			ComputerName:                 "MPCLINUX",
+			Flags:                        "4000",
+			LastObservation:              time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+			OrbitType:                    "MBA",
+		},
+	}
+}
+
+func TestFormatMpcorbLine(t *testing.T) {
+	expected := "00001    3.53  0.15 K2555 145.84905   73.27653   80.26736   10.58790  0.0791840  0.21424861   2.7656975" +
+		"  0 E2024-V47  7330 125 1801-2024 0.80 M-v 30k MPCLINUX   4000      (1) Ceres              20241101"
+
+	r, err := FormatMpcorbLine(ceres())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, r)
+	}
+}
+
+func TestFormatMpcorbLineNoMetadata(t *testing.T) {
+	orb := ceres()
+	orb.ID = "K19A00A"
+	orb.Metadata = nil
+	orb.MeanDailyMotion = 0
+
+	r, err := FormatMpcorbLine(orb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 202 {
+		t.Errorf("expected a 202 character line got %v", len(r))
+	}
+	if r[80:91] != " 0.21428733" {
+		t.Errorf("expected mean daily motion to be derived from the semimajor axis got %q", r[80:91])
+	}
+	if r[166:173] != "K19A00A" {
+		t.Errorf("expected readable designation to fall back to the id got %q", r[166:173])
+	}
+}
+
+func TestFormatMpcorbLinePartDay(t *testing.T) {
+	orb := ceres()
+	orb.Epoch = orb.Epoch.Add(-3 * time.Hour)
+
+	r, err := FormatMpcorbLine(orb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r[20:25] != "K2555" {
+		t.Errorf("expected epoch to be moved to K2555 got %v", r[20:25])
+	}
+	if r[26:35] == "145.84905" {
+		t.Errorf("expected the orbit to be propagated to the new epoch")
+	}
+}
+
+func TestMpcorbWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewMpcorbWriter(&buf)
+	for i := 0; i < 3; i++ {
+		if err := w.WriteEntry(ceres()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() != 3*203 {
+		t.Errorf("expected three lines got %v bytes", buf.Len())
+	}
+}
+
+func TestParseMpcorbLineRoundTrip(t *testing.T) {
+	line, err := FormatMpcorbLine(ceres())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orb, err := ParseMpcorbLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(orb.Metadata, ceres().Metadata) {
+		t.Errorf("expected metadata\n%+v\ngot\n%+v", ceres().Metadata, orb.Metadata)
+	}
+
+	again, err := FormatMpcorbLine(orb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != line {
+		t.Errorf("expected\n%q\ngot\n%q", line, again)
+	}
+}
+
+func TestParseMinorPlanet(t *testing.T) {
+	line, err := FormatMpcorbLine(ceres())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mp, err := ParseMinorPlanet(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mp.ID != "00001" || mp.MeanAnomalyEpoch != 145.84905 || mp.SemimajorAxis != 2.7656975 {
+		t.Errorf("expected the elements in degrees and AU got %+v", mp)
+	}
+	if mp.HexDigitFlags != 0x4000 || mp.CoarseIndicatorOfPerturbers != "M-v" || mp.PreciseIndicatorOfPerturbers != "30k" {
+		t.Errorf("unexpected catalog columns %+v", mp)
+	}
+}
+
+func TestParseMpcorbLineShortArc(t *testing.T) {
+	orb := ceres()
+	orb.Metadata.NumberOfOppositions = 1
+	orb.Metadata.ArcLength = 30
+	orb.Metadata.YearOfFirstObservation = 0
+	orb.Metadata.YearOfLastObservation = 0
+	orb.AbsoluteMagnitude = 0
+
+	line, err := FormatMpcorbLine(orb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Objects without a magnitude have it left blank.
+	line = line[:8] + "     " + line[13:]
+
+	r, err := ParseMpcorbLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Metadata.ArcLength != 30 || r.Metadata.YearOfFirstObservation != 0 {
+		t.Errorf("expected a 30 day arc got %+v", r.Metadata)
+	}
+	if r.AbsoluteMagnitude != 0 {
+		t.Errorf("expected a blank magnitude to be zero got %v", r.AbsoluteMagnitude)
+	}
+}
+
+func TestParseMpcorbLineErrors(t *testing.T) {
+	line, err := FormatMpcorbLine(ceres())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseMpcorbLine(line[:90]); err == nil {
+		t.Errorf("expected an error for a truncated line")
+	}
+	if _, err := ParseMpcorbLine(line[:70] + "0.07x1840" + line[79:]); err == nil {
+		t.Errorf("expected an error for a bad eccentricity")
+	}
+}
+
+func TestMpcorbReaderSkipsHeader(t *testing.T) {
+	line, err := FormatMpcorbLine(ceres())
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "MINOR PLANET CENTER ORBIT DATABASE (MPCORB)\n\n" +
+		"Des'n     H     G   Epoch     M        Peri.      Node       Incl.       e            n           a\n" +
+		strings.Repeat("-", 160) + "\n" +
+		line + "\n\n" + line + "\n"
+
+	reader := NewMpcorbReaderFromReader(strings.NewReader(input))
+	defer reader.Close()
+
+	count := 0
+	_, err = reader.ReadEntry()
+	for err == nil {
+		count++
+		_, err = reader.ReadEntry()
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 orbits got %v", count)
+	}
+}
+
+func TestMpcorbReaderGzip(t *testing.T) {
+	line, err := FormatMpcorbLine(ceres())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(line + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := orbcore.NewDecompressingReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := NewMpcorbReaderFromReader(source)
+	orb, err := reader.ReadEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orb.ID != "00001" {
+		t.Errorf("expected 00001 got %v", orb.ID)
+	}
+}
+
+func TestParseMpcorbLineFlags(t *testing.T) {
+	orb := ceres()
+	orb.Metadata.Flags = "8803"
+
+	line, err := FormatMpcorbLine(orb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseMpcorbLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Metadata.OrbitType != "Apollo" || !r.Metadata.NEO || !r.Metadata.PHA {
+		t.Errorf("expected a potentially hazardous Apollo got %+v", r.Metadata)
+	}
+}
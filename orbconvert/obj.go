@@ -0,0 +1,92 @@
+package orbconvert
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+OBJWriter writes orbits and paths as polylines in the Wavefront OBJ format, each one a separate named object, so they
+can be imported into Blender alongside a point cloud from a PLYWriter. Coordinates are the same as a PLYWriter with
+the same Unit.
+*/
+type OBJWriter struct {
+	Unit float64
+
+	w        *bufio.Writer
+	vertices int
+}
+
+/*
+NewOBJWriter creates a writer that writes to out. Flush must be called once everything has been written.
+*/
+func NewOBJWriter(out io.Writer) *OBJWriter {
+	ow := OBJWriter{w: bufio.NewWriter(out)}
+	ow.w.WriteString("# orbcalc orbits\n")
+	return &ow
+}
+
+/*
+WriteOrbit writes the full ellipse of an orbit as a closed line named after the orbit ID. The points are evenly
+spaced in true anomaly so the line is smooth at both ends of very eccentric orbits. Orbits that do not close are an
+error.
+*/
+func (ow *OBJWriter) WriteOrbit(orb *orbcore.Orbit, segments int) error {
+	if orb.OrbitalEccentricity >= 1 {
+		return fmt.Errorf("%v: orbit with eccentricity %v does not close", orb.ID, orb.OrbitalEccentricity)
+	}
+	if segments < 3 {
+		return fmt.Errorf("%v: need at least 3 segments got %d", orb.ID, segments)
+	}
+
+	positions := make([]*orbcore.Position, segments)
+	o := orb.Clone()
+	for i := range positions {
+		o.MeanAnomalyEpoch = 2 * math.Pi * float64(i) / float64(segments)
+		positions[i] = orbcore.OrbitToPosition(o)
+	}
+	return ow.WriteLine(orb.ID, positions, true)
+}
+
+/*
+WriteLine writes a path through positions as a line named name. A closed line joins the last position back to the
+first.
+*/
+func (ow *OBJWriter) WriteLine(name string, positions []*orbcore.Position, closed bool) error {
+	if len(positions) < 2 {
+		return fmt.Errorf("%v: need at least 2 positions for a line got %d", name, len(positions))
+	}
+
+	fmt.Fprintf(ow.w, "o %v\n", strings.Join(strings.Fields(name), "_"))
+	for _, p := range positions {
+		p, err := inFrame(p, orbcore.EclipticJ2000)
+		if err != nil {
+			return err
+		}
+		x, y, z := scaled(p, ow.Unit)
+		fmt.Fprintf(ow.w, "v %v %v %v\n", x, y, z)
+	}
+
+	ow.w.WriteString("l")
+	for i := range positions {
+		fmt.Fprintf(ow.w, " %d", ow.vertices+i+1)
+	}
+	if closed {
+		fmt.Fprintf(ow.w, " %d", ow.vertices+1)
+	}
+	ow.vertices += len(positions)
+	_, err := ow.w.WriteString("\n")
+	return err
+}
+
+/*
+Flush writes any buffered data to the underlying writer.
+*/
+func (ow *OBJWriter) Flush() error {
+	return ow.w.Flush()
+}
@@ -0,0 +1,59 @@
+package orbconvert
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+func TestOBJWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewOBJWriter(&buf)
+	if err := w.WriteOrbit(ceres(), 8); err != nil {
+		t.Fatal(err)
+	}
+	line := []*orbcore.Position{{X: 0}, {X: AuToKm(1), Y: AuToKm(2), Z: AuToKm(3)}}
+	if err := w.WriteLine("path of (1) Ceres", line, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 15 {
+		t.Fatalf("expected 15 lines got\n%v", buf.String())
+	}
+	if lines[1] != "o 00001" || lines[10] != "l 1 2 3 4 5 6 7 8 1" {
+		t.Errorf("unexpected orbit\n%v", buf.String())
+	}
+	if lines[11] != "o path_of_(1)_Ceres" || lines[13] != "v 1 2 3" || lines[14] != "l 9 10" {
+		t.Errorf("unexpected line\n%v", buf.String())
+	}
+
+	// The first point is at perihelion and the fifth at aphelion.
+	orb := ceres()
+	for i, expected := range map[int]float64{2: orbcore.PerihelionDistance(orb), 6: orb.SemimajorAxis * (1 + orb.OrbitalEccentricity)} {
+		var r float64
+		for _, f := range strings.Fields(lines[i])[1:] {
+			v, _ := strconv.ParseFloat(f, 64)
+			r += v * v
+		}
+		if math.Abs(AuToKm(math.Sqrt(r))/expected-1) > 1e-9 {
+			t.Errorf("line %v: expected a distance of %v got %v", i, expected, AuToKm(math.Sqrt(r)))
+		}
+	}
+
+	hyperbolic := ceres()
+	hyperbolic.OrbitalEccentricity = 1.5
+	if err := w.WriteOrbit(hyperbolic, 8); err == nil {
+		t.Error("expected an error for a hyperbolic orbit")
+	}
+	if err := w.WriteLine("point", line[:1], false); err == nil {
+		t.Error("expected an error for a single position")
+	}
+}
@@ -0,0 +1,420 @@
+package orbconvert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+OEM is a CCSDS Orbit Ephemeris Message, one or more segments of state vectors for an object.
+*/
+type OEM struct {
+	Originator string
+	Created    time.Time
+	Segments   []*OEMSegment
+}
+
+/*
+OEMSegment is a run of state vectors for one object sharing the same center, frame and time system. Frame and
+TimeSystem are what the message used, or should use when written. States read from a message are in the J2000 ecliptic
+frame with UTC epochs, states to be written are converted from whatever frame they say they are in.
+*/
+type OEMSegment struct {
+	ObjectName string
+	ObjectID   string
+	Center     string
+	Frame      string
+	TimeSystem string
+	Start      time.Time
+	Stop       time.Time
+	States     []*orbcore.Position
+}
+
+/*
+NewOEMSegment propagates orbit from start to stop every step and records the state vectors. The segment is centered
+on the body the orbit is around, found from its parent gravity, in the ICRF frame with UTC epochs. An error is
+returned if no body has that gravitational parameter.
+*/
+func NewOEMSegment(orbit *orbcore.Orbit, start time.Time, stop time.Time, step time.Duration) (*OEMSegment, error) {
+	center, err := centerName(orbit)
+	if err != nil {
+		return nil, err
+	}
+	segment := OEMSegment{
+		ObjectName: orbit.ID,
+		ObjectID:   orbit.ID,
+		Center:     center,
+		Frame:      DefaultFrame,
+		TimeSystem: DefaultTimeSystem,
+		Start:      start,
+		Stop:       stop,
+	}
+	for t := start; !t.After(stop); t = t.Add(step) {
+		state := orbcore.OrbitToPosition(orbcore.MeanMotionToDate(orbit, t))
+		state.Center = center
+		segment.States = append(segment.States, state)
+	}
+	return &segment, nil
+}
+
+/*
+Positions returns the states of the object in the segment, in the J2000 ecliptic frame relative to the center. The
+slice is shared with States.
+*/
+func (s *OEMSegment) Positions() []*orbcore.Position {
+	return s.States
+}
+
+/*
+ReadOEMFile reads an OEM from a file in either KVN or XML form. Gzip and bzip2 compressed files are decompressed on the
+fly.
+*/
+func ReadOEMFile(path string) (*OEM, error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	result, err := ReadOEM(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return result, nil
+}
+
+/*
+ReadOEM reads an OEM in either KVN or XML form, XML is detected by the message starting with a '<'. Covariance blocks
+and optional metadata are skipped, acceleration values on a data line are ignored.
+*/
+func ReadOEM(in io.Reader) (*OEM, error) {
+	data, isXML, err := readMessage(in)
+	if err != nil {
+		return nil, err
+	}
+	if isXML {
+		return readOEMXML(data)
+	}
+	return readOEMKVN(data)
+}
+
+func readOEMKVN(data []byte) (*OEM, error) {
+	lines, err := readKVN(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || lines[0].key != "CCSDS_OEM_VERS" {
+		return nil, fmt.Errorf("not an OEM, expected CCSDS_OEM_VERS first")
+	}
+
+	var result OEM
+	var segment *OEMSegment
+	var meta map[string]string
+	inMeta, inCovariance := false, false
+	for _, line := range lines {
+		switch {
+		case line.key == "COVARIANCE_START":
+			inCovariance = true
+		case line.key == "COVARIANCE_STOP":
+			inCovariance = false
+		case inCovariance:
+		case inMeta && (line.fields != nil || line.key == "META_START"):
+			return nil, fmt.Errorf("line %d: metadata block is not closed", line.number)
+		case line.key == "META_START":
+			inMeta = true
+			meta = make(map[string]string)
+		case line.key == "META_STOP":
+			inMeta = false
+			segment, err = oemSegmentFromMetadata(meta)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line.number, err)
+			}
+			result.Segments = append(result.Segments, segment)
+		case inMeta:
+			meta[line.key] = line.value
+		case line.key == "CREATION_DATE":
+			if result.Created, err = parseCCSDSTime(line.value, "UTC"); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line.number, err)
+			}
+		case line.key == "ORIGINATOR":
+			result.Originator = line.value
+		case line.fields != nil:
+			if segment == nil {
+				return nil, fmt.Errorf("line %d: data before any metadata", line.number)
+			}
+			state, err := parseOEMDataLine(line.fields, segment)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line.number, err)
+			}
+			segment.States = append(segment.States, state)
+		}
+	}
+	if inMeta {
+		return nil, fmt.Errorf("metadata block is not closed")
+	}
+	return &result, nil
+}
+
+func oemSegmentFromMetadata(meta map[string]string) (*OEMSegment, error) {
+	for _, key := range []string{"OBJECT_NAME", "CENTER_NAME", "REF_FRAME", "TIME_SYSTEM", "START_TIME", "STOP_TIME"} {
+		if meta[key] == "" {
+			return nil, fmt.Errorf("metadata is missing %v", key)
+		}
+	}
+	segment := OEMSegment{
+		ObjectName: meta["OBJECT_NAME"],
+		ObjectID:   meta["OBJECT_ID"],
+		Center:     meta["CENTER_NAME"],
+		Frame:      meta["REF_FRAME"],
+		TimeSystem: meta["TIME_SYSTEM"],
+	}
+	if _, err := frameToEcliptic(segment.Frame); err != nil {
+		return nil, err
+	}
+	var err error
+	if segment.Start, err = parseCCSDSTime(meta["START_TIME"], segment.TimeSystem); err != nil {
+		return nil, err
+	}
+	if segment.Stop, err = parseCCSDSTime(meta["STOP_TIME"], segment.TimeSystem); err != nil {
+		return nil, err
+	}
+	return &segment, nil
+}
+
+func parseOEMDataLine(fields []string, segment *OEMSegment) (*orbcore.Position, error) {
+	if len(fields) != 7 && len(fields) != 10 {
+		return nil, fmt.Errorf("expected an epoch and 6 or 9 values got %d fields", len(fields))
+	}
+	var values [6]float64
+	for i := range values {
+		v, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	state, err := newState(fields[0], values, segment.Frame, segment.TimeSystem)
+	if err != nil {
+		return nil, err
+	}
+	state.ID, state.Center = valueOr(segment.ObjectName, segment.ObjectID), segment.Center
+	return state, nil
+}
+
+// newState reads a state vector given in frame and the time system and converts it to the ecliptic and UTC.
+func newState(epoch string, values [6]float64, frame string, system string) (*orbcore.Position, error) {
+	t, err := parseCCSDSTime(epoch, system)
+	if err != nil {
+		return nil, err
+	}
+	state := orbcore.Position{
+		Epoch:       t,
+		X:           values[0],
+		Y:           values[1],
+		Z:           values[2],
+		VX:          values[3],
+		VY:          values[4],
+		VZ:          values[5],
+		HasVelocity: true,
+		Frame:       frame,
+	}
+	return inFrame(&state, orbcore.EclipticJ2000)
+}
+
+type xmlHeader struct {
+	CreationDate string `xml:"CREATION_DATE"`
+	Originator   string `xml:"ORIGINATOR"`
+}
+
+type xmlStateVector struct {
+	Epoch string  `xml:"EPOCH"`
+	X     float64 `xml:"X"`
+	Y     float64 `xml:"Y"`
+	Z     float64 `xml:"Z"`
+	XDot  float64 `xml:"X_DOT"`
+	YDot  float64 `xml:"Y_DOT"`
+	ZDot  float64 `xml:"Z_DOT"`
+}
+
+type xmlOEM struct {
+	XMLName  xml.Name        `xml:"oem"`
+	ID       string          `xml:"id,attr"`
+	Version  string          `xml:"version,attr"`
+	Header   xmlHeader       `xml:"header"`
+	Segments []xmlOEMSegment `xml:"body>segment"`
+}
+
+type xmlOEMSegment struct {
+	Metadata struct {
+		ObjectName string `xml:"OBJECT_NAME"`
+		ObjectID   string `xml:"OBJECT_ID"`
+		CenterName string `xml:"CENTER_NAME"`
+		RefFrame   string `xml:"REF_FRAME"`
+		TimeSystem string `xml:"TIME_SYSTEM"`
+		StartTime  string `xml:"START_TIME"`
+		StopTime   string `xml:"STOP_TIME"`
+	} `xml:"metadata"`
+	States []xmlStateVector `xml:"data>stateVector"`
+}
+
+func readOEMXML(data []byte) (*OEM, error) {
+	var message xmlOEM
+	if err := xml.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+
+	var result OEM
+	var err error
+	result.Originator = message.Header.Originator
+	if message.Header.CreationDate != "" {
+		if result.Created, err = parseCCSDSTime(message.Header.CreationDate, "UTC"); err != nil {
+			return nil, err
+		}
+	}
+	for i, s := range message.Segments {
+		m := s.Metadata
+		segment, err := oemSegmentFromMetadata(map[string]string{
+			"OBJECT_NAME": m.ObjectName,
+			"OBJECT_ID":   m.ObjectID,
+			"CENTER_NAME": m.CenterName,
+			"REF_FRAME":   m.RefFrame,
+			"TIME_SYSTEM": m.TimeSystem,
+			"START_TIME":  m.StartTime,
+			"STOP_TIME":   m.StopTime,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %v", i+1, err)
+		}
+		for _, v := range s.States {
+			state, err := newState(v.Epoch, [6]float64{v.X, v.Y, v.Z, v.XDot, v.YDot, v.ZDot}, segment.Frame, segment.TimeSystem)
+			if err != nil {
+				return nil, fmt.Errorf("segment %d: %v", i+1, err)
+			}
+			state.ID, state.Center = valueOr(segment.ObjectName, segment.ObjectID), segment.Center
+			segment.States = append(segment.States, state)
+		}
+		result.Segments = append(result.Segments, segment)
+	}
+	return &result, nil
+}
+
+// prepareOEMSegment converts a segment into the frame and time system it is to be written in.
+func prepareOEMSegment(s *OEMSegment) (*xmlOEMSegment, error) {
+	var out xmlOEMSegment
+	m := &out.Metadata
+	m.ObjectName = s.ObjectName
+	m.ObjectID = valueOr(s.ObjectID, s.ObjectName)
+	m.CenterName = valueOr(s.Center, DefaultCenter)
+	m.RefFrame = valueOr(s.Frame, DefaultFrame)
+	m.TimeSystem = valueOr(s.TimeSystem, DefaultTimeSystem)
+
+	start, stop := s.Start, s.Stop
+	if start.IsZero() && len(s.States) > 0 {
+		start = s.States[0].Epoch
+	}
+	if stop.IsZero() && len(s.States) > 0 {
+		stop = s.States[len(s.States)-1].Epoch
+	}
+	var err error
+	if m.StartTime, err = formatCCSDSTime(start, m.TimeSystem); err != nil {
+		return nil, err
+	}
+	if m.StopTime, err = formatCCSDSTime(stop, m.TimeSystem); err != nil {
+		return nil, err
+	}
+
+	for _, state := range s.States {
+		v, err := inFrame(state, m.RefFrame)
+		if err != nil {
+			return nil, err
+		}
+		epoch, err := formatCCSDSTime(v.Epoch, m.TimeSystem)
+		if err != nil {
+			return nil, err
+		}
+		out.States = append(out.States, xmlStateVector{epoch, v.X, v.Y, v.Z, v.VX, v.VY, v.VZ})
+	}
+	return &out, nil
+}
+
+/*
+WriteOEM writes an OEM in the given format. Segments without a frame, center or time system get the defaults, ICRF
+centered on the Sun in UTC. A zero creation date is written as the current time.
+*/
+func WriteOEM(out io.Writer, oem *OEM, format CCSDSFormat) error {
+	message := xmlOEM{ID: "CCSDS_OEM_VERS", Version: "2.0"}
+	message.Header = newXMLHeader(oem.Originator, oem.Created)
+	for i, s := range oem.Segments {
+		segment, err := prepareOEMSegment(s)
+		if err != nil {
+			return fmt.Errorf("segment %d: %v", i+1, err)
+		}
+		message.Segments = append(message.Segments, *segment)
+	}
+
+	if format == XML {
+		return writeXML(out, message)
+	}
+
+	w := bufio.NewWriter(out)
+	writeKVNHeader(w, "CCSDS_OEM_VERS", message.Header)
+	for _, s := range message.Segments {
+		m := s.Metadata
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "META_START")
+		writeKVNValue(w, "OBJECT_NAME", m.ObjectName)
+		writeKVNValue(w, "OBJECT_ID", m.ObjectID)
+		writeKVNValue(w, "CENTER_NAME", m.CenterName)
+		writeKVNValue(w, "REF_FRAME", m.RefFrame)
+		writeKVNValue(w, "TIME_SYSTEM", m.TimeSystem)
+		writeKVNValue(w, "START_TIME", m.StartTime)
+		writeKVNValue(w, "STOP_TIME", m.StopTime)
+		fmt.Fprintln(w, "META_STOP")
+		fmt.Fprintln(w)
+		for _, v := range s.States {
+			fmt.Fprintf(w, "%s %.6f %.6f %.6f %.9f %.9f %.9f\n", v.Epoch, v.X, v.Y, v.Z, v.XDot, v.YDot, v.ZDot)
+		}
+	}
+	return w.Flush()
+}
+
+func newXMLHeader(origin string, created time.Time) xmlHeader {
+	if created.IsZero() {
+		created = time.Now()
+	}
+	return xmlHeader{
+		CreationDate: created.UTC().Format(ccsdsTimeLayout),
+		Originator:   valueOr(origin, originator),
+	}
+}
+
+func writeKVNHeader(w *bufio.Writer, version string, header xmlHeader) {
+	writeKVNValue(w, version, "2.0")
+	writeKVNValue(w, "CREATION_DATE", header.CreationDate)
+	writeKVNValue(w, "ORIGINATOR", header.Originator)
+}
+
+func writeKVNValue(w *bufio.Writer, key string, value string) {
+	fmt.Fprintf(w, "%-20s = %s\n", key, value)
+}
+
+func writeXML(out io.Writer, message interface{}) error {
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	data, err := xml.MarshalIndent(message, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, "\n")
+	return err
+}
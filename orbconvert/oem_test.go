@@ -0,0 +1,233 @@
+package orbconvert
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+const testOEM = `CCSDS_OEM_VERS = 2.0
+COMMENT Example with a covariance block and accelerations
+CREATION_DATE = 2019-06-15T10:00:00
+ORIGINATOR = JPL
+
+META_START
+OBJECT_NAME = CERES
+OBJECT_ID = 2000001
+CENTER_NAME = SUN
+REF_FRAME = EME2000
+TIME_SYSTEM = UTC
+START_TIME = 2019-001T00:00:00
+STOP_TIME = 2019-01-02T00:00:00.000
+INTERPOLATION = HERMITE
+INTERPOLATION_DEGREE = 7
+META_STOP
+
+COMMENT Positions in km, velocities in km/s
+2019-01-01T00:00:00.000 100000000.0 200000000.0 -50000000.0 10.0 -5.0 2.0
+2019-01-02T00:00:00.000 100864000.0 199568000.0 -49827200.0 10.0 -5.0 2.0 0.0 0.0 0.0
+
+COVARIANCE_START
+EPOCH = 2019-01-01T00:00:00.000
+COV_REF_FRAME = RTN
+3.3313494e-04
+4.6189273e-04 6.7824216e-04
+COVARIANCE_STOP
+
+META_START
+OBJECT_NAME = VESTA
+CENTER_NAME = SUN
+REF_FRAME = ECLIPJ2000
+TIME_SYSTEM = UTC
+START_TIME = 2019-01-01T00:00:00
+STOP_TIME = 2019-01-01T00:00:00
+META_STOP
+2019-01-01T00:00:00 1.0 2.0 3.0 4.0 5.0 6.0
+`
+
+func TestReadOEMKVN(t *testing.T) {
+	oem, err := ReadOEM(strings.NewReader(testOEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oem.Originator != "JPL" || !oem.Created.Equal(time.Date(2019, 6, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected header %v %v", oem.Originator, oem.Created)
+	}
+	if len(oem.Segments) != 2 {
+		t.Fatalf("expected 2 segments got %v", len(oem.Segments))
+	}
+
+	s := oem.Segments[0]
+	if s.ObjectName != "CERES" || s.ObjectID != "2000001" || s.Center != "SUN" || s.Frame != "EME2000" || s.TimeSystem != "UTC" {
+		t.Errorf("unexpected metadata %+v", s)
+	}
+	if !s.Start.Equal(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)) || !s.Stop.Equal(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected range %v %v", s.Start, s.Stop)
+	}
+	if len(s.States) != 2 {
+		t.Fatalf("expected 2 states got %v", len(s.States))
+	}
+
+	v := s.States[0]
+	c, sin := math.Cos(orbdata.Obliquity), math.Sin(orbdata.Obliquity)
+	if v.X != 1e8 || math.Abs(v.Y-(c*2e8-sin*5e7)) > 1e-6 || math.Abs(v.Z-(-sin*2e8-c*5e7)) > 1e-6 {
+		t.Errorf("expected the position on the ecliptic got %+v", v)
+	}
+	if v.VX != 10 || math.Abs(v.VY-(-5*c+2*sin)) > 1e-12 || math.Abs(v.VZ-(5*sin+2*c)) > 1e-12 {
+		t.Errorf("expected the velocity on the ecliptic got %+v", v)
+	}
+
+	vesta := oem.Segments[1].States[0]
+	expected := orbcore.Position{
+		ID: "VESTA", Epoch: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), X: 1, Y: 2, Z: 3, VX: 4, VY: 5, VZ: 6,
+		HasVelocity: true, Frame: orbcore.EclipticJ2000, Center: "SUN",
+	}
+	if *vesta != expected {
+		t.Errorf("expected the ecliptic frame to be left alone got %+v", vesta)
+	}
+
+	positions := s.Positions()
+	if len(positions) != 2 || positions[1].ID != "CERES" || positions[1].X != 100864000 {
+		t.Errorf("unexpected positions %v", positions)
+	}
+}
+
+func TestOEMRoundTrip(t *testing.T) {
+	start := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	segment, err := NewOEMSegment(ceres(), start, start.AddDate(0, 0, 10), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segment.States) != 11 {
+		t.Fatalf("expected 11 states got %v", len(segment.States))
+	}
+
+	for _, format := range []CCSDSFormat{KVN, XML} {
+		for _, system := range []string{"UTC", "TDB", "TAI"} {
+			segment.TimeSystem = system
+			var buf bytes.Buffer
+			if err := WriteOEM(&buf, &OEM{Segments: []*OEMSegment{segment}}, format); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), "ICRF") || !strings.Contains(buf.String(), "SUN") {
+				t.Errorf("expected the frame and center in the output got\n%v", buf.String())
+			}
+
+			oem, err := ReadOEM(&buf)
+			if err != nil {
+				t.Fatalf("%v %v: %v", format, system, err)
+			}
+			if len(oem.Segments) != 1 || oem.Originator != originator {
+				t.Fatalf("unexpected message %+v", oem)
+			}
+			result := oem.Segments[0]
+			if result.ObjectName != "00001" || result.TimeSystem != system || !result.Start.Equal(start) {
+				t.Errorf("%v %v: unexpected metadata %+v", format, system, result)
+			}
+			for i, expected := range segment.States {
+				got := result.States[i]
+				if d := got.Epoch.Sub(expected.Epoch); d > 2*time.Millisecond || d < -2*time.Millisecond {
+					t.Errorf("%v %v: expected epoch %v got %v", format, system, expected.Epoch, got.Epoch)
+				}
+				if math.Abs(got.X-expected.X) > 1e-5 || math.Abs(got.Z-expected.Z) > 1e-5 || math.Abs(got.VY-expected.VY) > 1e-8 {
+					t.Errorf("%v %v: expected %+v got %+v", format, system, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteOEMHeader(t *testing.T) {
+	start := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	segment, err := NewOEMSegment(ceres(), start, start, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oem := OEM{
+		Originator: "TEST",
+		Created:    start,
+		Segments:   []*OEMSegment{segment},
+	}
+	var buf bytes.Buffer
+	if err := WriteOEM(&buf, &oem, KVN); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"CCSDS_OEM_VERS       = 2.0\n",
+		"CREATION_DATE        = 2025-05-05T00:00:00.000000\n",
+		"ORIGINATOR           = TEST\n",
+		"CENTER_NAME          = SUN\n",
+		"REF_FRAME            = ICRF\n",
+		"TIME_SYSTEM          = UTC\n",
+		"START_TIME           = 2025-05-05T00:00:00.000000\n",
+		"\n2025-05-05T00:00:00.000000 ",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q in\n%v", expected, buf.String())
+		}
+	}
+}
+
+func TestReadOEMErrors(t *testing.T) {
+	cases := map[string]string{
+		"not an oem":   "CCSDS_OPM_VERS = 2.0\n",
+		"bad frame":    strings.Replace(testOEM, "EME2000", "ITRF", 1),
+		"bad time":     strings.Replace(testOEM, "TIME_SYSTEM = UTC", "TIME_SYSTEM = GPS", 1),
+		"no center":    strings.Replace(testOEM, "CENTER_NAME = SUN", "", 1),
+		"short line":   strings.Replace(testOEM, " 10.0 -5.0 2.0\n", "\n", 1),
+		"bad number":   strings.Replace(testOEM, "200000000.0", "2x", 1),
+		"no meta stop": strings.Replace(testOEM, "META_STOP", "", 1),
+		"bad xml":      "<oem><body>",
+	}
+	for name, in := range cases {
+		if _, err := ReadOEM(strings.NewReader(in)); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
+
+func TestParseCCSDSTime(t *testing.T) {
+	utc := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := map[string][2]string{
+		"day of year": {"2019-001T00:00:00", "UTC"},
+		"zulu":        {"2019-01-01T00:00:00.000Z", "UTC"},
+		"tai":         {"2019-01-01T00:00:37", "TAI"},
+		"tt":          {"2019-01-01T00:01:09.184", "TT"},
+	}
+	for name, c := range cases {
+		r, err := parseCCSDSTime(c[0], c[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := r.Sub(utc); d > 2*time.Millisecond || d < -2*time.Millisecond {
+			t.Errorf("%v: expected %v got %v", name, utc, r)
+		}
+	}
+}
+
+func TestWriteOEMPositionFrames(t *testing.T) {
+	epoch := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	segment := OEMSegment{
+		ObjectName: "TEST",
+		States: []*orbcore.Position{
+			{Epoch: epoch, X: 1, Y: 2, Z: 3, VX: 4, VY: 5, VZ: 6, Frame: orbcore.ICRF},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteOEM(&buf, &OEM{Segments: []*OEMSegment{&segment}}, KVN); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "2019-01-01T00:00:00.000000 1.000000 2.000000 3.000000 4.000000000 5.000000000 6.000000000\n") {
+		t.Errorf("expected an ICRF state to be written unchanged got\n%v", buf.String())
+	}
+
+	segment.States[0].Frame = "ITRF"
+	if err := WriteOEM(&buf, &OEM{Segments: []*OEMSegment{&segment}}, KVN); err == nil {
+		t.Error("expected an error for a state in an unknown frame")
+	}
+}
@@ -0,0 +1,327 @@
+package orbconvert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"gonum.org/v1/gonum/mat"
+)
+
+/*
+OPM is a CCSDS Orbit Parameter Message, the state of a single object at an epoch. Frame and TimeSystem are what the
+message used, or should use when written. A state read from a message is in the J2000 ecliptic frame with a UTC epoch, a
+state to be written is converted from whatever frame it says it is in. GM is the gravitational parameter of the center
+in km^3 s^-2 if the message gave one.
+*/
+type OPM struct {
+	Originator string
+	Created    time.Time
+	ObjectName string
+	ObjectID   string
+	Center     string
+	Frame      string
+	TimeSystem string
+	State      orbcore.Position
+	GM         float64
+}
+
+/*
+NewOPM creates an OPM holding the state of an orbit at its epoch. The message is centered on the body the orbit is
+around, found from its parent gravity, in the ICRF frame with a UTC epoch. An error is returned if no body has that
+gravitational parameter.
+*/
+func NewOPM(orbit *orbcore.Orbit) (*OPM, error) {
+	center, err := centerName(orbit)
+	if err != nil {
+		return nil, err
+	}
+	state := orbcore.OrbitToPosition(orbit)
+	state.Center = center
+	return &OPM{
+		ObjectName: orbit.ID,
+		ObjectID:   orbit.ID,
+		Center:     center,
+		Frame:      DefaultFrame,
+		TimeSystem: DefaultTimeSystem,
+		State:      *state,
+		GM:         orbit.ParentGrav,
+	}, nil
+}
+
+/*
+Orbit works out the orbit of the object from the state vector. The GM from the message is used if there was one,
+otherwise it is looked up from the center name.
+*/
+func (o *OPM) Orbit() (*orbcore.Orbit, error) {
+	gm, err := o.gm()
+	if err != nil {
+		return nil, err
+	}
+	state, err := inFrame(&o.State, orbcore.EclipticJ2000)
+	if err != nil {
+		return nil, err
+	}
+	r, v := vectors(state)
+	orb := orbcore.VectorToOrbit(r, v, gm, o.State.Epoch)
+	orb.ID = valueOr(o.ObjectName, o.ObjectID)
+	return orb, nil
+}
+
+func (o *OPM) gm() (float64, error) {
+	if o.GM > 0 {
+		return o.GM, nil
+	}
+	return lookupGM(valueOr(o.Center, DefaultCenter))
+}
+
+func vectors(p *orbcore.Position) (*mat.VecDense, *mat.VecDense) {
+	return mat.NewVecDense(3, []float64{p.X, p.Y, p.Z}), mat.NewVecDense(3, []float64{p.VX, p.VY, p.VZ})
+}
+
+/*
+ReadOPMFile reads an OPM from a file in either KVN or XML form. Gzip and bzip2 compressed files are decompressed on the
+fly.
+*/
+func ReadOPMFile(path string) (*OPM, error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	result, err := ReadOPM(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return result, nil
+}
+
+/*
+ReadOPM reads an OPM in either KVN or XML form, XML is detected by the message starting with a '<'. Only the header,
+metadata, state vector and the GM from the Keplerian elements are used. Spacecraft parameters, covariance and
+maneuvers are skipped.
+*/
+func ReadOPM(in io.Reader) (*OPM, error) {
+	data, isXML, err := readMessage(in)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	if isXML {
+		err = readOPMXML(data, values)
+	} else {
+		err = readOPMKVN(data, values)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return opmFromValues(values)
+}
+
+var opmRequired = []string{
+	"OBJECT_NAME", "CENTER_NAME", "REF_FRAME", "TIME_SYSTEM", "EPOCH", "X", "Y", "Z", "X_DOT", "Y_DOT", "Z_DOT",
+}
+
+func readOPMKVN(data []byte, values map[string]string) error {
+	lines, err := readKVN(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 || lines[0].key != "CCSDS_OPM_VERS" {
+		return fmt.Errorf("not an OPM, expected CCSDS_OPM_VERS first")
+	}
+	for _, line := range lines {
+		// Maneuvers and the covariance reuse some keywords, only the first of each is wanted.
+		if _, ok := values[line.key]; !ok && line.fields == nil {
+			values[line.key] = line.value
+		}
+	}
+	return nil
+}
+
+type xmlOPM struct {
+	XMLName  xml.Name   `xml:"opm"`
+	ID       string     `xml:"id,attr"`
+	Version  string     `xml:"version,attr"`
+	Header   xmlHeader  `xml:"header"`
+	Metadata xmlOPMMeta `xml:"body>segment>metadata"`
+	Data     xmlOPMData `xml:"body>segment>data"`
+}
+
+type xmlOPMMeta struct {
+	ObjectName string `xml:"OBJECT_NAME"`
+	ObjectID   string `xml:"OBJECT_ID"`
+	CenterName string `xml:"CENTER_NAME"`
+	RefFrame   string `xml:"REF_FRAME"`
+	TimeSystem string `xml:"TIME_SYSTEM"`
+}
+
+type xmlOPMData struct {
+	State  xmlStateVector `xml:"stateVector"`
+	Kepler *xmlKeplerian  `xml:"keplerianElements,omitempty"`
+}
+
+type xmlKeplerian struct {
+	SemimajorAxis    float64 `xml:"SEMI_MAJOR_AXIS"`
+	Eccentricity     float64 `xml:"ECCENTRICITY"`
+	Inclination      float64 `xml:"INCLINATION"`
+	AscendingNode    float64 `xml:"RA_OF_ASC_NODE"`
+	ArgOfPericenter  float64 `xml:"ARG_OF_PERICENTER"`
+	TrueAnomaly      float64 `xml:"TRUE_ANOMALY"`
+	GravityParameter float64 `xml:"GM"`
+}
+
+func readOPMXML(data []byte, values map[string]string) error {
+	var message xmlOPM
+	if err := xml.Unmarshal(data, &message); err != nil {
+		return err
+	}
+	m, s := message.Metadata, message.Data.State
+	for key, value := range map[string]string{
+		"CREATION_DATE": message.Header.CreationDate,
+		"ORIGINATOR":    message.Header.Originator,
+		"OBJECT_NAME":   m.ObjectName,
+		"OBJECT_ID":     m.ObjectID,
+		"CENTER_NAME":   m.CenterName,
+		"REF_FRAME":     m.RefFrame,
+		"TIME_SYSTEM":   m.TimeSystem,
+		"EPOCH":         s.Epoch,
+	} {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	// The state vector is required so zero values here are real values rather than missing ones.
+	for key, value := range map[string]float64{"X": s.X, "Y": s.Y, "Z": s.Z, "X_DOT": s.XDot, "Y_DOT": s.YDot, "Z_DOT": s.ZDot} {
+		values[key] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	if message.Data.Kepler != nil && message.Data.Kepler.GravityParameter > 0 {
+		values["GM"] = strconv.FormatFloat(message.Data.Kepler.GravityParameter, 'g', -1, 64)
+	}
+	return nil
+}
+
+func opmFromValues(values map[string]string) (*OPM, error) {
+	for _, key := range opmRequired {
+		if values[key] == "" {
+			return nil, fmt.Errorf("OPM is missing %v", key)
+		}
+	}
+
+	result := OPM{
+		Originator: values["ORIGINATOR"],
+		ObjectName: values["OBJECT_NAME"],
+		ObjectID:   values["OBJECT_ID"],
+		Center:     values["CENTER_NAME"],
+		Frame:      values["REF_FRAME"],
+		TimeSystem: values["TIME_SYSTEM"],
+	}
+	var err error
+	if values["CREATION_DATE"] != "" {
+		if result.Created, err = parseCCSDSTime(values["CREATION_DATE"], "UTC"); err != nil {
+			return nil, err
+		}
+	}
+	if values["GM"] != "" {
+		if result.GM, err = strconv.ParseFloat(values["GM"], 64); err != nil {
+			return nil, fmt.Errorf("GM: %v", err)
+		}
+	}
+
+	var state [6]float64
+	for i, key := range opmRequired[5:] {
+		if state[i], err = strconv.ParseFloat(values[key], 64); err != nil {
+			return nil, fmt.Errorf("%v: %v", key, err)
+		}
+	}
+	s, err := newState(values["EPOCH"], state, result.Frame, result.TimeSystem)
+	if err != nil {
+		return nil, err
+	}
+	s.ID, s.Center = valueOr(result.ObjectName, result.ObjectID), result.Center
+	result.State = *s
+	return &result, nil
+}
+
+/*
+WriteOPM writes an OPM in the given format. Along with the state vector the osculating Keplerian elements are written
+in the frame of the message, when the GM of the center is known. Missing frame, center and time system values get the
+defaults, ICRF centered on the Sun in UTC.
+*/
+func WriteOPM(out io.Writer, opm *OPM, format CCSDSFormat) error {
+	message := xmlOPM{ID: "CCSDS_OPM_VERS", Version: "2.0"}
+	message.Header = newXMLHeader(opm.Originator, opm.Created)
+	message.Metadata = xmlOPMMeta{
+		ObjectName: opm.ObjectName,
+		ObjectID:   valueOr(opm.ObjectID, opm.ObjectName),
+		CenterName: valueOr(opm.Center, DefaultCenter),
+		RefFrame:   valueOr(opm.Frame, DefaultFrame),
+		TimeSystem: valueOr(opm.TimeSystem, DefaultTimeSystem),
+	}
+
+	s, err := inFrame(&opm.State, message.Metadata.RefFrame)
+	if err != nil {
+		return err
+	}
+	epoch, err := formatCCSDSTime(s.Epoch, message.Metadata.TimeSystem)
+	if err != nil {
+		return err
+	}
+	message.Data.State = xmlStateVector{epoch, s.X, s.Y, s.Z, s.VX, s.VY, s.VZ}
+
+	if gm, err := opm.gm(); err == nil {
+		r, v := vectors(s)
+		orb := orbcore.VectorToOrbit(r, v, gm, s.Epoch)
+		message.Data.Kepler = &xmlKeplerian{
+			SemimajorAxis:    orb.SemimajorAxis,
+			Eccentricity:     orb.OrbitalEccentricity,
+			Inclination:      RadToDeg(orb.InclinationToTheEcliptic),
+			AscendingNode:    RadToDeg(orb.LongitudeOfTheAscendingNode),
+			ArgOfPericenter:  RadToDeg(orb.ArgumentOfPerihelion),
+			TrueAnomaly:      RadToDeg(orb.MeanAnomalyEpoch),
+			GravityParameter: gm,
+		}
+	}
+
+	if format == XML {
+		return writeXML(out, message)
+	}
+
+	w := bufio.NewWriter(out)
+	writeKVNHeader(w, "CCSDS_OPM_VERS", message.Header)
+	m := message.Metadata
+	fmt.Fprintln(w)
+	writeKVNValue(w, "OBJECT_NAME", m.ObjectName)
+	writeKVNValue(w, "OBJECT_ID", m.ObjectID)
+	writeKVNValue(w, "CENTER_NAME", m.CenterName)
+	writeKVNValue(w, "REF_FRAME", m.RefFrame)
+	writeKVNValue(w, "TIME_SYSTEM", m.TimeSystem)
+	fmt.Fprintln(w)
+	writeKVNValue(w, "EPOCH", epoch)
+	writeKVNValue(w, "X", formatKVNFloat(s.X, 6)+" [km]")
+	writeKVNValue(w, "Y", formatKVNFloat(s.Y, 6)+" [km]")
+	writeKVNValue(w, "Z", formatKVNFloat(s.Z, 6)+" [km]")
+	writeKVNValue(w, "X_DOT", formatKVNFloat(s.VX, 9)+" [km/s]")
+	writeKVNValue(w, "Y_DOT", formatKVNFloat(s.VY, 9)+" [km/s]")
+	writeKVNValue(w, "Z_DOT", formatKVNFloat(s.VZ, 9)+" [km/s]")
+	if k := message.Data.Kepler; k != nil {
+		fmt.Fprintln(w)
+		writeKVNValue(w, "SEMI_MAJOR_AXIS", formatKVNFloat(k.SemimajorAxis, 6)+" [km]")
+		writeKVNValue(w, "ECCENTRICITY", formatKVNFloat(k.Eccentricity, 12))
+		writeKVNValue(w, "INCLINATION", formatKVNFloat(k.Inclination, 9)+" [deg]")
+		writeKVNValue(w, "RA_OF_ASC_NODE", formatKVNFloat(k.AscendingNode, 9)+" [deg]")
+		writeKVNValue(w, "ARG_OF_PERICENTER", formatKVNFloat(k.ArgOfPericenter, 9)+" [deg]")
+		writeKVNValue(w, "TRUE_ANOMALY", formatKVNFloat(k.TrueAnomaly, 9)+" [deg]")
+		writeKVNValue(w, "GM", formatKVNFloat(k.GravityParameter, 6)+" [km**3/s**2]")
+	}
+	return w.Flush()
+}
+
+func formatKVNFloat(v float64, places int) string {
+	return strconv.FormatFloat(v, 'f', places, 64)
+}
@@ -0,0 +1,150 @@
+package orbconvert
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+const testOPM = `CCSDS_OPM_VERS = 2.0
+CREATION_DATE = 2019-06-15T10:00:00
+ORIGINATOR = GSOC
+COMMENT Geostationary satellite
+
+OBJECT_NAME = GODZILLA 5
+OBJECT_ID = 1998-057A
+CENTER_NAME = EARTH
+REF_FRAME = GCRF
+TIME_SYSTEM = UTC
+
+EPOCH = 2019-01-01T00:00:00.000
+X = 42164.0 [km]
+Y = 0.0 [km]
+Z = 0.0 [km]
+X_DOT = 0.0 [km/s]
+Y_DOT = 3.0746676 [km/s]
+Z_DOT = 0.0 [km/s]
+
+MASS = 1913.000 [kg]
+SOLAR_RAD_AREA = 10.000 [m**2]
+
+MAN_EPOCH_IGNITION = 2019-01-02T00:00:00.000
+MAN_DURATION = 132.60 [s]
+MAN_REF_FRAME = RSW
+X_DOT = 1.0
+`
+
+func TestReadOPMKVN(t *testing.T) {
+	opm, err := ReadOPM(strings.NewReader(testOPM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opm.Originator != "GSOC" || opm.ObjectName != "GODZILLA 5" || opm.ObjectID != "1998-057A" || opm.Center != "EARTH" {
+		t.Errorf("unexpected message %+v", opm)
+	}
+	if opm.GM != 0 {
+		t.Errorf("expected no GM got %v", opm.GM)
+	}
+	if !opm.State.Epoch.Equal(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)) || opm.State.X != 42164 || opm.State.VX != 0 {
+		t.Errorf("unexpected state %+v", opm.State)
+	}
+
+	// An equatorial orbit in the GCRF frame is inclined by the obliquity to the ecliptic.
+	orb, err := opm.Orbit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orb.ID != "GODZILLA 5" || orb.ParentGrav != orbdata.EarthGrav {
+		t.Errorf("unexpected orbit %v", orb)
+	}
+	if math.Abs(orb.InclinationToTheEcliptic-orbdata.Obliquity) > 1e-12 || math.Abs(orb.SemimajorAxis-42164)/42164 > 1e-3 {
+		t.Errorf("unexpected orbit %v", orb)
+	}
+}
+
+func TestOPMRoundTrip(t *testing.T) {
+	expected := ceres()
+	for _, format := range []CCSDSFormat{KVN, XML} {
+		for _, frame := range []string{"ICRF", "ECLIPJ2000"} {
+			opm, err := NewOPM(expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opm.Frame = frame
+			opm.TimeSystem = "TDB"
+
+			var buf bytes.Buffer
+			if err := WriteOPM(&buf, opm, format); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), "TRUE_ANOMALY") {
+				t.Errorf("expected keplerian elements in\n%v", buf.String())
+			}
+			if frame == "ECLIPJ2000" && format == KVN && !strings.Contains(buf.String(), "INCLINATION          = 10.587900000 [deg]") {
+				t.Errorf("expected the ecliptic inclination in\n%v", buf.String())
+			}
+
+			read, err := ReadOPM(&buf)
+			if err != nil {
+				t.Fatalf("%v %v: %v", format, frame, err)
+			}
+			if read.GM != orbdata.SunGrav || read.Frame != frame || read.TimeSystem != "TDB" {
+				t.Errorf("%v %v: unexpected message %+v", format, frame, read)
+			}
+			orb, err := read.Orbit()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := orb.Epoch.Sub(expected.Epoch); d > time.Millisecond || d < -time.Millisecond {
+				t.Errorf("%v %v: expected epoch %v got %v", format, frame, expected.Epoch, orb.Epoch)
+			}
+			if orb.ID != expected.ID || math.Abs(orb.SemimajorAxis/expected.SemimajorAxis-1) > 1e-9 ||
+				math.Abs(orb.OrbitalEccentricity-expected.OrbitalEccentricity) > 1e-9 ||
+				math.Abs(orb.InclinationToTheEcliptic-expected.InclinationToTheEcliptic) > 1e-9 ||
+				math.Abs(orb.MeanAnomalyEpoch-expected.MeanAnomalyEpoch) > 1e-9 {
+				t.Errorf("%v %v: expected %v got %v", format, frame, expected, orb)
+			}
+		}
+	}
+}
+
+func TestNewOPMCenter(t *testing.T) {
+	opm, err := NewOPM(&orbdata.MoonOrbit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opm.Center != "EARTH" || opm.State.Center != "EARTH" || opm.GM != orbdata.MoonOrbit.ParentGrav {
+		t.Errorf("expected a geocentric message got %v %v %v", opm.Center, opm.State.Center, opm.GM)
+	}
+
+	unknown := ceres()
+	unknown.ParentGrav = 1
+	if _, err := NewOPM(unknown); err == nil {
+		t.Error("expected an error for an orbit around an unknown body")
+	}
+}
+
+func TestReadOPMErrors(t *testing.T) {
+	cases := map[string]string{
+		"not an opm":  strings.Replace(testOPM, "CCSDS_OPM_VERS", "CCSDS_OEM_VERS", 1),
+		"no epoch":    strings.Replace(testOPM, "EPOCH = 2019-01-01T00:00:00.000", "", 1),
+		"bad number":  strings.Replace(testOPM, "42164.0", "4x", 1),
+		"bad frame":   strings.Replace(testOPM, "GCRF", "TOD", 1),
+		"no state":    `<opm><body><segment><metadata><OBJECT_NAME>X</OBJECT_NAME></metadata></segment></body></opm>`,
+		"bad element": `<opm><body><segment><data><stateVector><X>a</X></stateVector></data></segment></body></opm>`,
+	}
+	for name, in := range cases {
+		if _, err := ReadOPM(strings.NewReader(in)); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+
+	opm, _ := ReadOPM(strings.NewReader(strings.Replace(testOPM, "EARTH", "ALPHA CENTAURI", 1)))
+	if _, err := opm.Orbit(); err == nil {
+		t.Error("expected an error for an unknown center without a GM")
+	}
+}
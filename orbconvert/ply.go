@@ -0,0 +1,169 @@
+package orbconvert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+/*
+ClassColors gives a colour for each orbit type OrbitClass can return. Near earth objects are warm colours, the main
+belt and its families cool ones and things further out pale.
+*/
+var ClassColors = map[string]color.RGBA{
+	orbitTypes[0]:  {90, 120, 200, 255},
+	orbitTypes[1]:  {255, 40, 40, 255},
+	orbitTypes[2]:  {255, 110, 30, 255},
+	orbitTypes[3]:  {255, 170, 0, 255},
+	orbitTypes[4]:  {255, 230, 60, 255},
+	orbitTypes[5]:  {200, 230, 120, 255},
+	orbitTypes[6]:  {60, 200, 140, 255},
+	orbitTypes[7]:  {40, 170, 200, 255},
+	orbitTypes[8]:  {180, 110, 230, 255},
+	orbitTypes[9]:  {120, 230, 80, 255},
+	orbitTypes[10]: {230, 230, 230, 255},
+}
+
+/*
+UnknownColor is used for orbits with no class in ClassColors and values that are not numbers.
+*/
+var UnknownColor = color.RGBA{128, 128, 128, 255}
+
+/*
+ClassColor returns the colour from ClassColors for the class of orb.
+*/
+func ClassColor(orb *orbcore.Orbit) color.RGBA {
+	if c, ok := ClassColors[OrbitClass(orb)]; ok {
+		return c
+	}
+	return UnknownColor
+}
+
+// scalarRamp is a perceptually even ramp from dark purple through blue and green to yellow, close to viridis.
+var scalarRamp = []color.RGBA{
+	{68, 1, 84, 255},
+	{59, 82, 139, 255},
+	{33, 145, 140, 255},
+	{94, 201, 98, 255},
+	{253, 231, 37, 255},
+}
+
+/*
+ScalarColor maps value onto a colour ramp running from dark purple at min to yellow at max. Values outside the range
+are given the colour of the nearest end.
+*/
+func ScalarColor(value, min, max float64) color.RGBA {
+	if math.IsNaN(value) {
+		return UnknownColor
+	}
+	f := 0.0
+	if max > min {
+		f = math.Min(math.Max((value-min)/(max-min), 0), 1)
+	}
+	f *= float64(len(scalarRamp) - 1)
+	i := int(f)
+	if i >= len(scalarRamp)-1 {
+		return scalarRamp[len(scalarRamp)-1]
+	}
+	a, b := scalarRamp[i], scalarRamp[i+1]
+	f -= float64(i)
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + f*(float64(y)-float64(x))))
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+/*
+PLYWriter writes positions as a coloured point cloud in the PLY format, which Blender, MeshLab and most other 3D tools
+can import.
+
+Points are written in the J2000 ecliptic frame relative to the Sun, so the z axis points to the north ecliptic pole.
+Unit is the length in km of one unit in the output, an AU if zero, as km make numbers too large for most tools to
+handle well. The file is binary unless ASCII is set.
+
+The header has to give the number of points so they are held in memory until Close, about 15 bytes each in binary.
+*/
+type PLYWriter struct {
+	Unit  float64
+	ASCII bool
+
+	out      io.Writer
+	vertices bytes.Buffer
+	count    int
+}
+
+/*
+NewPLYWriter creates a writer that writes to out. Close must be called to write the file.
+*/
+func NewPLYWriter(out io.Writer) *PLYWriter {
+	return &PLYWriter{out: out}
+}
+
+/*
+WritePoint adds a position to the point cloud in colour c, the alpha is ignored.
+*/
+func (pw *PLYWriter) WritePoint(p *orbcore.Position, c color.RGBA) error {
+	p, err := inFrame(p, orbcore.EclipticJ2000)
+	if err != nil {
+		return err
+	}
+	x, y, z := scaled(p, pw.Unit)
+
+	pw.count++
+	if pw.ASCII {
+		_, err = fmt.Fprintf(&pw.vertices, "%v %v %v %d %d %d\n", float32(x), float32(y), float32(z), c.R, c.G, c.B)
+		return err
+	}
+	var vertex [15]byte
+	binary.LittleEndian.PutUint32(vertex[0:], math.Float32bits(float32(x)))
+	binary.LittleEndian.PutUint32(vertex[4:], math.Float32bits(float32(y)))
+	binary.LittleEndian.PutUint32(vertex[8:], math.Float32bits(float32(z)))
+	vertex[12], vertex[13], vertex[14] = c.R, c.G, c.B
+	_, err = pw.vertices.Write(vertex[:])
+	return err
+}
+
+/*
+Close writes the header and all the points to the output. It does not close the underlying writer.
+*/
+func (pw *PLYWriter) Close() error {
+	format := "binary_little_endian"
+	if pw.ASCII {
+		format = "ascii"
+	}
+
+	w := bufio.NewWriter(pw.out)
+	fmt.Fprintf(w, "ply\nformat %v 1.0\n", format)
+	fmt.Fprintf(w, "comment orbcalc positions relative to the sun in the J2000 ecliptic frame, %v\n", unitName(pw.Unit))
+	fmt.Fprintf(w, "element vertex %d\n", pw.count)
+	for _, property := range []string{"float x", "float y", "float z", "uchar red", "uchar green", "uchar blue"} {
+		fmt.Fprintf(w, "property %v\n", property)
+	}
+	w.WriteString("end_header\n")
+	if _, err := pw.vertices.WriteTo(w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// scaled gives the coordinates of p in units of unit km, or AU if unit is zero.
+func scaled(p *orbcore.Position, unit float64) (float64, float64, float64) {
+	if unit <= 0 {
+		unit = orbdata.AU
+	}
+	return p.X / unit, p.Y / unit, p.Z / unit
+}
+
+func unitName(unit float64) string {
+	if unit <= 0 || unit == orbdata.AU {
+		return "in AU"
+	}
+	return fmt.Sprintf("in units of %v km", unit)
+}
@@ -0,0 +1,131 @@
+package orbconvert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+func TestOrbitClass(t *testing.T) {
+	orbit := func(a, e, i float64) *orbcore.Orbit {
+		return &orbcore.Orbit{SemimajorAxis: AuToKm(a), OrbitalEccentricity: e, InclinationToTheEcliptic: DegToRad(i)}
+	}
+	cases := map[string]*orbcore.Orbit{
+		"MBA":            ceres(),
+		"Atira":          orbit(0.74, 0.32, 25.6),
+		"Aten":           orbit(0.92, 0.19, 3.3),
+		"Apollo":         orbit(1.27, 0.89, 22.3),
+		"Amor":           orbit(1.46, 0.22, 10.8),
+		"Hungaria":       orbit(1.94, 0.07, 22.5),
+		"Hilda":          orbit(3.97, 0.14, 7.8),
+		"Jupiter Trojan": orbit(5.2, 0.15, 22),
+		"Distant Object": orbit(39.5, 0.25, 17.1),
+		"":               orbit(-1, 1.2, 0),
+	}
+	for expected, orb := range cases {
+		if r := OrbitClass(orb); r != expected {
+			t.Errorf("expected %q got %q", expected, r)
+		}
+	}
+
+	orb := orbit(2.7, 0.1, 5)
+	orb.Metadata = &orbcore.Metadata{OrbitType: "Phocaea"}
+	if r := OrbitClass(orb); r != "Phocaea" {
+		t.Errorf("expected the catalog type to be used got %q", r)
+	}
+	if ClassColor(orb) != ClassColors["Phocaea"] || ClassColor(orbit(-1, 1.2, 0)) != UnknownColor {
+		t.Error("unexpected class colours")
+	}
+}
+
+func TestScalarColor(t *testing.T) {
+	cases := []struct {
+		value    float64
+		expected color.RGBA
+	}{
+		{0, scalarRamp[0]},
+		{-5, scalarRamp[0]},
+		{10, scalarRamp[4]},
+		{20, scalarRamp[4]},
+		{5, scalarRamp[2]},
+		{1.25, color.RGBA{64, 42, 112, 255}},
+		{math.NaN(), UnknownColor},
+	}
+	for _, c := range cases {
+		if r := ScalarColor(c.value, 0, 10); r != c.expected {
+			t.Errorf("%v: expected %v got %v", c.value, c.expected, r)
+		}
+	}
+}
+
+func TestPLYWriter(t *testing.T) {
+	epoch := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	positions := []*orbcore.Position{
+		{ID: "a", Epoch: epoch, X: orbdata.AU, Y: -2 * orbdata.AU, Z: 0.5 * orbdata.AU},
+		{ID: "b", Epoch: epoch, X: 0, Y: orbdata.AU, Z: 0, Frame: orbcore.ICRF},
+	}
+	colors := []color.RGBA{{1, 2, 3, 255}, {250, 251, 252, 255}}
+
+	var buf bytes.Buffer
+	w := NewPLYWriter(&buf)
+	w.ASCII = true
+	for i, p := range positions {
+		if err := w.WritePoint(p, colors[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c, s := float32(math.Cos(orbdata.Obliquity)), float32(math.Sin(orbdata.Obliquity))
+	expected := "ply\nformat ascii 1.0\n" +
+		"comment orbcalc positions relative to the sun in the J2000 ecliptic frame, in AU\n" +
+		"element vertex 2\n" +
+		"property float x\nproperty float y\nproperty float z\n" +
+		"property uchar red\nproperty uchar green\nproperty uchar blue\n" +
+		"end_header\n"
+	if !strings.HasPrefix(buf.String(), expected) {
+		t.Fatalf("unexpected header\n%v", buf.String())
+	}
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(buf.String(), expected)), "\n")
+	if len(lines) != 2 || lines[0] != "1 -2 0.5 1 2 3" {
+		t.Errorf("unexpected points %q", lines)
+	}
+
+	buf.Reset()
+	w = NewPLYWriter(&buf)
+	w.Unit = 1000
+	for i, p := range positions {
+		if err := w.WritePoint(p, colors[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	header := strings.Replace(strings.Replace(expected, "ascii", "binary_little_endian", 1), "in AU", "in units of 1000 km", 1)
+	if !strings.HasPrefix(buf.String(), header) {
+		t.Fatalf("unexpected header\n%v", buf.String())
+	}
+	data := buf.Bytes()[len(header):]
+	if len(data) != 30 {
+		t.Fatalf("expected 30 bytes of points got %v", len(data))
+	}
+	y := math.Float32frombits(binary.LittleEndian.Uint32(data[19:]))
+	z := math.Float32frombits(binary.LittleEndian.Uint32(data[23:]))
+	scale := float32(orbdata.AU / 1000)
+	if math.Abs(float64(y/scale-c)) > 1e-6 || math.Abs(float64(z/scale+s)) > 1e-6 || data[29] != 252 {
+		t.Errorf("expected the ICRF point moved to the ecliptic got %v %v %v", y, z, data[27:])
+	}
+
+	if err := w.WritePoint(&orbcore.Position{Frame: "ITRF"}, colors[0]); err == nil {
+		t.Error("expected an error for an unknown frame")
+	}
+}
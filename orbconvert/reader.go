@@ -0,0 +1,110 @@
+package orbconvert
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+OrbitReader is implemented by the readers that stream orbits out of catalog files. ReadEntry returns io.EOF once the
+input is exhausted.
+*/
+type OrbitReader interface {
+	ReadEntry() (*orbcore.Orbit, error)
+	Close() error
+}
+
+/*
+OpenOrbitReader opens a catalog file picking the reader from the file name. Files ending in .json, .json.gz or
+.json.bz2 are read as the MPC extended json catalog, anything else is treated as MPCORB.DAT. Compressed files are
+decompressed on the fly whatever they are called.
+*/
+func OpenOrbitReader(path string) (OrbitReader, error) {
+	name := strings.TrimSuffix(strings.TrimSuffix(path, ".gz"), ".bz2")
+	if strings.HasSuffix(name, ".json") {
+		return NewMpcJSONReader(path)
+	}
+	return NewMpcorbReader(path)
+}
+
+/*
+MpcorbReader streams orbits out of an MPCORB.DAT formatted file. The header at the top of the full catalog is skipped
+as are the blank lines between its sections. Lines are read with ParseMinorPlanet and converted with
+ConvertFromMinorPlanet, the same as records from gompcreader, whose own reader can only open a file by name.
+*/
+type MpcorbReader struct {
+	file     io.Closer
+	scanner  *bufio.Scanner
+	line     int
+	inHeader bool
+}
+
+/*
+NewMpcorbReader opens an MPCORB.DAT formatted file. Gzip and bzip2 compressed files are decompressed on the fly.
+*/
+func NewMpcorbReader(path string) (*MpcorbReader, error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	result := NewMpcorbReaderFromReader(f)
+	result.file = f
+	return result, nil
+}
+
+/*
+NewMpcorbReaderFromReader creates an MpcorbReader that reads from an already open reader.
+*/
+func NewMpcorbReaderFromReader(in io.Reader) *MpcorbReader {
+	return &MpcorbReader{
+		scanner:  bufio.NewScanner(in),
+		inHeader: true,
+	}
+}
+
+/*
+ReadEntry reads the next minor planet orbit. At the end of the input io.EOF is returned.
+*/
+func (r *MpcorbReader) ReadEntry() (*orbcore.Orbit, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if r.inHeader && strings.HasPrefix(line, "-----") {
+			r.inHeader = false
+			continue
+		}
+
+		orb, err := ParseMpcorbLine(line)
+		if err != nil {
+			// Anything before the first orbit that does not parse is part of the header.
+			if r.inHeader {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %v", r.line, err)
+		}
+		r.inHeader = false
+		return orb, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+/*
+Close closes the underlying file if this reader opened it.
+*/
+func (r *MpcorbReader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package orbcore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+var bzip2Magic = []byte("BZh")
+
+/*
+OpenDecompressed opens a file for reading. Gzip and bzip2 compressed files are detected from their first few bytes and
+decompressed on the fly, anything else is read as is.
+*/
+func OpenDecompressed(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewDecompressingReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &multiCloser{Reader: r, closers: []io.Closer{r, f}}, nil
+}
+
+/*
+NewDecompressingReader wraps in so that gzip or bzip2 compressed data is decompressed as it is read. Closing the
+result does not close in.
+*/
+func NewDecompressingReader(in io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReaderSize(in, 64*1024)
+	magic, err := buffered.Peek(3)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return gz, nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return ioutil.NopCloser(bzip2.NewReader(buffered)), nil
+	}
+	return ioutil.NopCloser(buffered), nil
+}
+
+/*
+CreateCompressed creates a file for writing. If the path ends in .gz the output is gzip compressed. Close must be
+called to flush the compressed stream.
+*/
+func CreateCompressed(path string) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+
+	gz := gzip.NewWriter(f)
+	return &multiCloser{Writer: gz, closers: []io.Closer{gz, f}}, nil
+}
+
+/*
+FinishCompressed calls flush to write out anything the writer wrapping w is holding and then closes w, returning the
+first error. w is closed even if flush fails. Closing finishes the compressed stream so either error means the file
+is truncated.
+*/
+func FinishCompressed(w io.Closer, flush func() error) error {
+	err := flush()
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// multiCloser closes a stack of readers or writers in order, returning the first error.
+type multiCloser struct {
+	io.Reader
+	io.Writer
+	closers []io.Closer
+}
+
+func (mc *multiCloser) Close() error {
+	var result error
+	for _, c := range mc.closers {
+		if err := c.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
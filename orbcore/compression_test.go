@@ -0,0 +1,117 @@
+package orbcore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecompressingReaderPlain(t *testing.T) {
+	r, err := NewDecompressingReader(bytes.NewBufferString("plain text"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "plain text" {
+		t.Errorf("expected plain text got %q", b)
+	}
+}
+
+func TestDecompressingReaderEmpty(t *testing.T) {
+	r, err := NewDecompressingReader(bytes.NewBuffer(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil || len(b) != 0 {
+		t.Errorf("expected nothing got %q %v", b, err)
+	}
+}
+
+func TestDecompressingReaderGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("compressed text"))
+	gz.Close()
+
+	r, err := NewDecompressingReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "compressed text" {
+		t.Errorf("expected compressed text got %q", b)
+	}
+}
+
+func TestCompressedPositionFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orbcore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "positions.csv.gz")
+
+	w, err := CreateCompressed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("00001,2019-01-01T00:00:00Z,1,2,3\n"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(raw, gzipMagic) {
+		t.Errorf("expected the file to be gzip compressed")
+	}
+
+	pos, err := ReadPositionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pos) != 1 || pos[0].ID != "00001" || pos[0].Z != 3 {
+		t.Errorf("unexpected positions %v", pos)
+	}
+}
+
+type closeRecorder struct {
+	closed bool
+	err    error
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func TestFinishCompressed(t *testing.T) {
+	flushErr := errors.New("flush")
+	closeErr := errors.New("close")
+
+	c := &closeRecorder{err: closeErr}
+	if err := FinishCompressed(c, func() error { return flushErr }); err != flushErr {
+		t.Errorf("expected the flush error got %v", err)
+	}
+	if !c.closed {
+		t.Error("expected the writer to be closed after a flush error")
+	}
+
+	c = &closeRecorder{err: closeErr}
+	if err := FinishCompressed(c, func() error { return nil }); err != closeErr {
+		t.Errorf("expected the close error got %v", err)
+	}
+}
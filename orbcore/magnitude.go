@@ -0,0 +1,166 @@
+package orbcore
+
+import (
+	"math"
+)
+
+/*
+MagnitudeHG predicts the apparent visual magnitude of an asteroid using the IAU H,G phase function.
+
+r is the distance from the sun and delta the distance from the observer, both in AU. alpha is the phase angle in
+radians.
+*/
+func MagnitudeHG(h, g, r, delta, alpha float64) float64 {
+	tanHalf := math.Tan(math.Abs(alpha) / 2)
+	phi1 := math.Exp(-3.33 * math.Pow(tanHalf, 0.63))
+	phi2 := math.Exp(-1.87 * math.Pow(tanHalf, 1.22))
+
+	return h + 5*math.Log10(r*delta) - 2.5*math.Log10((1-g)*phi1+g*phi2)
+}
+
+/*
+MagnitudeHG1G2 predicts the apparent visual magnitude of an asteroid using the H,G1,G2 phase function of
+Muinonen et al. (2010).
+
+r, delta and alpha are the same as for MagnitudeHG.
+*/
+func MagnitudeHG1G2(h, g1, g2, r, delta, alpha float64) float64 {
+	alpha = math.Abs(alpha)
+	phase := g1*hg1g2Phi1(alpha) + g2*hg1g2Phi2(alpha) + (1-g1-g2)*hg1g2Phi3(alpha)
+
+	return h + 5*math.Log10(r*delta) - 2.5*math.Log10(phase)
+}
+
+/*
+MagnitudeComet predicts the total visual magnitude of a comet from its absolute total magnitude m1 and slope k1.
+
+r is the distance from the sun and delta the distance from the observer, both in AU.
+*/
+func MagnitudeComet(m1, k1, r, delta float64) float64 {
+	return m1 + 5*math.Log10(delta) + k1*math.Log10(r)
+}
+
+/*
+PhaseAngle works out the sun-object-observer angle in radians from the distances between the three. The distances
+can be in any units as long as they are all the same.
+*/
+func PhaseAngle(objectSun, objectObserver, observerSun float64) float64 {
+	c := (objectSun*objectSun + objectObserver*objectObserver - observerSun*observerSun) / (2 * objectSun * objectObserver)
+	return math.Acos(math.Max(-1, math.Min(1, c)))
+}
+
+/*
+PhaseGeometry returns the distance of the object from the sun, its distance from the observer and the phase angle.
+Both positions must be heliocentric and the distances are returned in the units of the positions.
+*/
+func PhaseGeometry(object *Position, observer *Position) (float64, float64, float64) {
+	r := math.Sqrt(object.X*object.X + object.Y*object.Y + object.Z*object.Z)
+	R := math.Sqrt(observer.X*observer.X + observer.Y*observer.Y + observer.Z*observer.Z)
+	dx, dy, dz := object.X-observer.X, object.Y-observer.Y, object.Z-observer.Z
+	delta := math.Sqrt(dx*dx + dy*dy + dz*dz)
+
+	return r, delta, PhaseAngle(r, delta, R)
+}
+
+// Basis functions for the H,G1,G2 system. Below 7.5 degrees phi1 and phi2 are linear, above they are cubic splines
+// through the nodes given by Muinonen et al. phi3 is a spline up to 30 degrees and zero beyond.
+var hg1g2Spline1 = newClampedSpline(
+	degreesToRadians([]float64{7.5, 30, 60, 90, 120, 150}),
+	[]float64{7.5e-1, 3.3486016e-1, 1.3410560e-1, 5.1104756e-2, 2.1465687e-2, 3.6396989e-3},
+	-1.9098593, -9.1328612e-2,
+)
+
+var hg1g2Spline2 = newClampedSpline(
+	degreesToRadians([]float64{7.5, 30, 60, 90, 120, 150}),
+	[]float64{9.25e-1, 6.2884169e-1, 3.1755495e-1, 1.2716367e-1, 2.2373903e-2, 1.6505689e-4},
+	-5.7295780e-1, 8.6573138e-8,
+)
+
+var hg1g2Spline3 = newClampedSpline(
+	degreesToRadians([]float64{0, 0.3, 1, 2, 4, 8, 12, 20, 30}),
+	[]float64{1, 8.3381185e-1, 5.7735424e-1, 4.2144772e-1, 2.3174230e-1, 1.0348178e-1, 6.1733473e-2, 1.6107006e-2, 0},
+	-1.0630097e-1, 0,
+)
+
+var hg1g2Linear = 7.5 * math.Pi / 180.0
+
+func hg1g2Phi1(alpha float64) float64 {
+	if alpha < hg1g2Linear {
+		return 1 - 6*alpha/math.Pi
+	}
+	return hg1g2Spline1.at(alpha)
+}
+
+func hg1g2Phi2(alpha float64) float64 {
+	if alpha < hg1g2Linear {
+		return 1 - 9*alpha/(5*math.Pi)
+	}
+	return hg1g2Spline2.at(alpha)
+}
+
+func hg1g2Phi3(alpha float64) float64 {
+	if alpha >= hg1g2Spline3.x[len(hg1g2Spline3.x)-1] {
+		return 0
+	}
+	return hg1g2Spline3.at(alpha)
+}
+
+// clampedSpline is a cubic spline with fixed first derivatives at each end.
+type clampedSpline struct {
+	x  []float64
+	y  []float64
+	y2 []float64
+}
+
+func newClampedSpline(x, y []float64, d0, dn float64) clampedSpline {
+	n := len(x)
+	y2 := make([]float64, n)
+	u := make([]float64, n)
+
+	y2[0] = -0.5
+	u[0] = (3 / (x[1] - x[0])) * ((y[1]-y[0])/(x[1]-x[0]) - d0)
+
+	for i := 1; i < n-1; i++ {
+		sig := (x[i] - x[i-1]) / (x[i+1] - x[i-1])
+		p := sig*y2[i-1] + 2
+		y2[i] = (sig - 1) / p
+		u[i] = (y[i+1]-y[i])/(x[i+1]-x[i]) - (y[i]-y[i-1])/(x[i]-x[i-1])
+		u[i] = (6*u[i]/(x[i+1]-x[i-1]) - sig*u[i-1]) / p
+	}
+
+	qn := 0.5
+	un := (3 / (x[n-1] - x[n-2])) * (dn - (y[n-1]-y[n-2])/(x[n-1]-x[n-2]))
+	y2[n-1] = (un - qn*u[n-2]) / (qn*y2[n-2] + 1)
+
+	for k := n - 2; k >= 0; k-- {
+		y2[k] = y2[k]*y2[k+1] + u[k]
+	}
+
+	return clampedSpline{x: x, y: y, y2: y2}
+}
+
+func (s clampedSpline) at(v float64) float64 {
+	lo := 0
+	hi := len(s.x) - 1
+	for hi-lo > 1 {
+		mid := (hi + lo) / 2
+		if s.x[mid] > v {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	h := s.x[hi] - s.x[lo]
+	a := (s.x[hi] - v) / h
+	b := (v - s.x[lo]) / h
+	return a*s.y[lo] + b*s.y[hi] + ((a*a*a-a)*s.y2[lo]+(b*b*b-b)*s.y2[hi])*(h*h)/6
+}
+
+func degreesToRadians(in []float64) []float64 {
+	result := make([]float64, len(in))
+	for i, v := range in {
+		result[i] = v * math.Pi / 180.0
+	}
+	return result
+}
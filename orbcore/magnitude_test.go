@@ -0,0 +1,67 @@
+package orbcore
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMagnitudeHG(t *testing.T) {
+	if r := MagnitudeHG(15, 0.15, 1, 1, 0); math.Abs(r-15) > 1e-12 {
+		t.Errorf("expected H at zero phase and unit distances got %v", r)
+	}
+
+	r := MagnitudeHG(15, 0.15, 2, 1, 20*math.Pi/180)
+	if math.Abs(r-17.504775763308736) > 1e-9 {
+		t.Errorf("expected 17.504775763308736 got %v", r)
+	}
+}
+
+func TestMagnitudeHG1G2(t *testing.T) {
+	if r := MagnitudeHG1G2(15, 0.3, 0.4, 1, 1, 0); math.Abs(r-15) > 1e-12 {
+		t.Errorf("expected H at zero phase and unit distances got %v", r)
+	}
+
+	// The splines must pass through their nodes and join the linear part smoothly.
+	cases := []struct {
+		name     string
+		f        func(float64) float64
+		deg      float64
+		expected float64
+	}{
+		{"phi1", hg1g2Phi1, 30, 3.3486016e-1},
+		{"phi1", hg1g2Phi1, 7.5, 0.75},
+		{"phi2", hg1g2Phi2, 90, 1.2716367e-1},
+		{"phi2", hg1g2Phi2, 7.5, 0.925},
+		{"phi3", hg1g2Phi3, 4, 2.3174230e-1},
+		{"phi3", hg1g2Phi3, 45, 0},
+	}
+	for _, c := range cases {
+		r := c.f(c.deg * math.Pi / 180)
+		if math.Abs(r-c.expected) > 1e-7 {
+			t.Errorf("%v(%v): expected %v got %v", c.name, c.deg, c.expected, r)
+		}
+	}
+
+	below := hg1g2Phi1(7.4999 * math.Pi / 180)
+	above := hg1g2Phi1(7.5001 * math.Pi / 180)
+	if math.Abs(below-above) > 1e-5 {
+		t.Errorf("phi1 is not continuous at 7.5 degrees %v %v", below, above)
+	}
+}
+
+func TestMagnitudeComet(t *testing.T) {
+	r := MagnitudeComet(10, 10, 2, 0.5)
+	if math.Abs(r-11.505149978319906) > 1e-9 {
+		t.Errorf("expected 11.505149978319906 got %v", r)
+	}
+}
+
+func TestPhaseGeometry(t *testing.T) {
+	object := &Position{X: 2}
+	observer := &Position{X: 1, Y: 1}
+
+	r, delta, alpha := PhaseGeometry(object, observer)
+	if r != 2 || math.Abs(delta-math.Sqrt2) > 1e-12 || math.Abs(alpha-math.Pi/4) > 1e-12 {
+		t.Errorf("unexpected geometry r: %v delta: %v alpha: %v", r, delta, alpha)
+	}
+}
@@ -25,6 +25,36 @@ type Orbit struct {
 	OrbitalEccentricity         float64 // e ecc
 	MeanDailyMotion             float64
 	SemimajorAxis               float64 // a p
+	AbsoluteMagnitude           float64 // H
+	Slope                       float64 // G
+	Metadata                    *Metadata
+}
+
+/*
+Metadata holds the catalog information about an object that is not needed to propagate its orbit.
+
+The absolute magnitude and slope are kept on the Orbit itself as magnitude predictions need them. Propagating an orbit
+does not change its metadata so clones share the same Metadata value.
+*/
+type Metadata struct {
+	ReadableDesignation          string
+	PrincipalDesignation         string // provisional designation, kept after the object is numbered or named, if known
+	UncertaintyParameter         string
+	Reference                    string
+	NumberOfObservations         int64
+	NumberOfOppositions          int64
+	YearOfFirstObservation       int64
+	YearOfLastObservation        int64
+	ArcLength                    int64 // days, only for single opposition objects
+	RmsResidual                  float64
+	CoarseIndicatorOfPerturbers  string // perturbers used in the orbit fit, e.g. "M-v"
+	PreciseIndicatorOfPerturbers string // hex flags for the perturbers, e.g. "30k"
+	ComputerName                 string
+	Flags                        string
+	LastObservation              time.Time
+	OrbitType                    string // MPC orbit classification such as "MBA" or "Apollo", when the source provides it
+	NEO                          bool
+	PHA                          bool
 }
 
 /*
@@ -42,6 +72,9 @@ func (o *Orbit) Clone() *Orbit {
 		OrbitalEccentricity:         o.OrbitalEccentricity,
 		MeanDailyMotion:             o.MeanDailyMotion,
 		SemimajorAxis:               o.SemimajorAxis,
+		AbsoluteMagnitude:           o.AbsoluteMagnitude,
+		Slope:                       o.Slope,
+		Metadata:                    o.Metadata,
 	}
 }
 
@@ -87,14 +120,94 @@ func OrbitToVector(orbit *Orbit) (mat.Vector, mat.Vector) {
 	return r, v
 }
 
-// TODO: VectorToOrbit
+/*
+VectorToOrbit works out the orbit of an object from its position in km and velocity in km/s relative to the parent
+body, the reverse of OrbitToVector. The elements are relative to whatever plane the vectors are in, normally the
+ecliptic. For equatorial orbits the node is put on the x axis and for circular ones the perihelion is put at the node.
+*/
+func VectorToOrbit(r mat.Vector, v mat.Vector, parentGrav float64, epoch time.Time) *Orbit {
+	rv := mat.VecDenseCopyOf(r)
+	vv := mat.VecDenseCopyOf(v)
+
+	h := cross(rv, vv)
+	hNorm := mat.Norm(h, 2)
+	rNorm := mat.Norm(rv, 2)
+
+	// eccentricity vector, ((v^2 - mu/r) r - (r.v) v) / mu
+	e := mat.NewVecDense(3, nil)
+	e.AddScaledVec(e, mat.Dot(vv, vv)-parentGrav/rNorm, rv)
+	e.AddScaledVec(e, -mat.Dot(rv, vv), vv)
+	e.ScaleVec(1/parentGrav, e)
+	ecc := mat.Norm(e, 2)
+
+	inc := math.Acos(math.Max(-1, math.Min(1, h.AtVec(2)/hNorm)))
+
+	node := 0.0
+	var u float64 // argument of latitude, the angle from the node to the object
+	if math.Sin(inc) > circularLimit {
+		node = math.Atan2(h.AtVec(0), -h.AtVec(1))
+		u = math.Atan2(rv.AtVec(2)/math.Sin(inc), rv.AtVec(0)*math.Cos(node)+rv.AtVec(1)*math.Sin(node))
+	} else {
+		u = math.Atan2(rv.AtVec(1), rv.AtVec(0))
+		if h.AtVec(2) < 0 {
+			u = -u
+		}
+	}
+
+	nu := u
+	if ecc > circularLimit {
+		nu = math.Atan2(mat.Dot(h, cross(e, rv))/hNorm, mat.Dot(e, rv))
+	}
+
+	if math.Abs(ecc-1) < parabolicLimit {
+		ecc = 1 - parabolicLimit
+	}
+	p := hNorm * hNorm / parentGrav
+	a := p / (1 - ecc*ecc)
+
+	return &Orbit{
+		ParentGrav:                  parentGrav,
+		Epoch:                       epoch,
+		MeanAnomalyEpoch:            normaliseAngle(nu),
+		ArgumentOfPerihelion:        normaliseAngle(u - nu),
+		LongitudeOfTheAscendingNode: normaliseAngle(node),
+		InclinationToTheEcliptic:    inc,
+		OrbitalEccentricity:         ecc,
+		MeanDailyMotion:             math.Sqrt(parentGrav/math.Abs(a*a*a)) * secondsPerDay * 180 / math.Pi,
+		SemimajorAxis:               a,
+	}
+}
+
+// circularLimit is how close to zero the eccentricity or inclination must be before the orbit is treated as circular
+// or equatorial.
+const circularLimit = 1e-11
+
+// parabolicLimit keeps VectorToOrbit from giving an exactly parabolic orbit, which would have an infinite semimajor
+// axis.
+const parabolicLimit = 1e-10
+
+func cross(a, b mat.Vector) *mat.VecDense {
+	return mat.NewVecDense(3, []float64{
+		a.AtVec(1)*b.AtVec(2) - a.AtVec(2)*b.AtVec(1),
+		a.AtVec(2)*b.AtVec(0) - a.AtVec(0)*b.AtVec(2),
+		a.AtVec(0)*b.AtVec(1) - a.AtVec(1)*b.AtVec(0),
+	})
+}
+
+func normaliseAngle(a float64) float64 {
+	a = math.Mod(a, 2*math.Pi)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	return a
+}
 
 /*
 OrbitToVecPerifocal converts a MinorPlanet object into r and v vectors in the perifocal frame
 */
 func OrbitToVecPerifocal(orbit *Orbit) (*mat.VecDense, *mat.VecDense) {
 
-	a := orbit.SemimajorAxis * (1 - math.Pow(orbit.OrbitalEccentricity, 2))
+	a := semiLatusRectum(orbit)
 	cosNu := math.Cos(orbit.MeanAnomalyEpoch)
 	sinNu := math.Sin(orbit.MeanAnomalyEpoch)
 
@@ -118,3 +231,16 @@ func OrbitalPeriod(orbit *Orbit) time.Duration {
 	t := 2 * math.Pi * math.Sqrt(math.Pow(orbit.SemimajorAxis, 3)/orbit.ParentGrav)
 	return time.Duration(t) * time.Second
 }
+
+/*
+PerihelionDistance returns the closest approach of the orbit to its parent body.
+*/
+func PerihelionDistance(orbit *Orbit) float64 {
+	return orbit.SemimajorAxis * (1 - orbit.OrbitalEccentricity)
+}
+
+// semiLatusRectum is worked out as a(1-e)(1+e) rather than a(1-e^2) to keep precision for near parabolic orbits
+// where a is very large and 1-e^2 is very small.
+func semiLatusRectum(orbit *Orbit) float64 {
+	return PerihelionDistance(orbit) * (1 + orbit.OrbitalEccentricity)
+}
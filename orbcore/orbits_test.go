@@ -1,8 +1,11 @@
 package orbcore
 
 import (
+	"math"
 	"testing"
 	"time"
+
+	"gonum.org/v1/gonum/mat"
 )
 
 func TestOrbitalPeriod(t *testing.T) {
@@ -47,4 +50,56 @@ func BenchmarkOrbitToVector(b *testing.B){
 			b.Fatal("Got an invalid result")
 		}
 	}
-}
\ No newline at end of file
+}
+func TestVectorToOrbit(t *testing.T) {
+	epoch := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	orbits := []*Orbit{
+		{ParentGrav: 1.32712440018e11, Epoch: epoch, MeanAnomalyEpoch: 1.2, ArgumentOfPerihelion: 1.27, LongitudeOfTheAscendingNode: 1.4, InclinationToTheEcliptic: 0.18, OrbitalEccentricity: 0.0755, SemimajorAxis: 4.139e8},
+		{ParentGrav: 1.32712440018e11, Epoch: epoch, MeanAnomalyEpoch: 5.9, ArgumentOfPerihelion: 0.3, LongitudeOfTheAscendingNode: 4.1, InclinationToTheEcliptic: 2.5, OrbitalEccentricity: 0.6, SemimajorAxis: 2e8},
+		{ParentGrav: 1.32712440018e11, Epoch: epoch, MeanAnomalyEpoch: 0.4, ArgumentOfPerihelion: 2.2, LongitudeOfTheAscendingNode: 0.7, InclinationToTheEcliptic: 1.1, OrbitalEccentricity: 1.3, SemimajorAxis: -5e8},
+	}
+
+	for _, orb := range orbits {
+		r, v := OrbitToVector(orb)
+		result := VectorToOrbit(r, v, orb.ParentGrav, epoch)
+
+		if !result.Epoch.Equal(epoch) || result.ParentGrav != orb.ParentGrav {
+			t.Errorf("unexpected epoch or gravity %v", result)
+		}
+		if math.Abs(result.SemimajorAxis/orb.SemimajorAxis-1) > 1e-9 || math.Abs(result.OrbitalEccentricity-orb.OrbitalEccentricity) > 1e-9 {
+			t.Errorf("expected %v got %v", orb, result)
+		}
+		angles := [][2]float64{
+			{orb.InclinationToTheEcliptic, result.InclinationToTheEcliptic},
+			{orb.LongitudeOfTheAscendingNode, result.LongitudeOfTheAscendingNode},
+			{orb.ArgumentOfPerihelion, result.ArgumentOfPerihelion},
+			{orb.MeanAnomalyEpoch, result.MeanAnomalyEpoch},
+		}
+		for _, a := range angles {
+			if math.Abs(a[0]-a[1]) > 1e-9 {
+				t.Errorf("expected %v got %v", orb, result)
+			}
+		}
+
+		r2, v2 := OrbitToVector(result)
+		for i := 0; i < 3; i++ {
+			if math.Abs(r.AtVec(i)-r2.AtVec(i)) > 1e-3 || math.Abs(v.AtVec(i)-v2.AtVec(i)) > 1e-9 {
+				t.Errorf("vectors do not round trip: %v %v", r2, v2)
+			}
+		}
+	}
+}
+
+func TestVectorToOrbitCircularEquatorial(t *testing.T) {
+	r := mat.NewVecDense(3, []float64{0, 1e8, 0})
+	speed := math.Sqrt(1.32712440018e11 / 1e8)
+	v := mat.NewVecDense(3, []float64{-speed, 0, 0})
+
+	orb := VectorToOrbit(r, v, 1.32712440018e11, time.Time{})
+	if orb.OrbitalEccentricity > 1e-9 || orb.InclinationToTheEcliptic > 1e-9 || math.Abs(orb.SemimajorAxis-1e8) > 1e-3 {
+		t.Errorf("expected a circular equatorial orbit got %v", orb)
+	}
+	if math.Abs(orb.ArgumentOfPerihelion+orb.LongitudeOfTheAscendingNode+orb.MeanAnomalyEpoch-math.Pi/2) > 1e-9 {
+		t.Errorf("expected the object at 90 degrees got %v", orb)
+	}
+}
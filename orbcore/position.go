@@ -4,81 +4,189 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 )
 
 /*
-Position contains information about the location in space of an object
+Position contains information about the location in space of an object, in km, and optionally its velocity in km/s.
+
+HasVelocity is false when only the position is known. Frame and Center are optional, an empty Frame means
+EclipticJ2000 and an empty Center means the parent body of the orbit the position came from, normally the Sun.
 */
 type Position struct {
-	ID    string
-	Epoch time.Time
-	X     float64
-	Y     float64
-	Z     float64
+	ID          string
+	Epoch       time.Time
+	X           float64
+	Y           float64
+	Z           float64
+	VX          float64
+	VY          float64
+	VZ          float64
+	HasVelocity bool
+	Frame       string
+	Center      string
 }
 
+/*
+The reference frames a Position can be in.
+*/
+const (
+	EclipticJ2000 = "ECLIPJ2000" // the J2000 ecliptic and equinox, what orbits and positions use unless they say otherwise
+	ICRF          = "ICRF"       // the J2000 equator and equinox, near enough the same as EME2000
+)
+
+/*
+String gives the position as a five column csv line, the velocity, frame and center are left out. An ID with a comma
+in it is quoted. Use a PositionWriter to write them as well.
+*/
 func (p *Position) String() string {
-	return fmt.Sprintf("%v,%v,%v,%v,%v", p.ID, p.Epoch.Format(time.RFC3339), p.X, p.Y, p.Z)
+	return fmt.Sprintf("%v,%v,%v,%v,%v", quotePositionField(p.ID), p.Epoch.Format(time.RFC3339), p.X, p.Y, p.Z)
 }
 
 /*
-OrbitToPosition converts an object object to its position on day 0
+OrbitToPosition converts an object object to its position and velocity on day 0
 */
 func OrbitToPosition(orb *Orbit) *Position {
-	r, _ := OrbitToVector(orb)
+	r, v := OrbitToVector(orb)
 	return &Position{
-		ID:    orb.ID,
-		Epoch: orb.Epoch,
-		X:     r.AtVec(0),
-		Y:     r.AtVec(1),
-		Z:     r.AtVec(2),
+		ID:          orb.ID,
+		Epoch:       orb.Epoch,
+		X:           r.AtVec(0),
+		Y:           r.AtVec(1),
+		Z:           r.AtVec(2),
+		VX:          v.AtVec(0),
+		VY:          v.AtVec(1),
+		VZ:          v.AtVec(2),
+		HasVelocity: true,
+	}
+}
+
+/*
+PositionWriter writes positions as csv lines. By default it writes the same five columns as Position.String,
+id,epoch,x,y,z. Velocity adds vx,vy,vz columns, left empty for positions without a velocity, and Frame adds the
+frame and center columns after those. Header writes a row naming the columns before the first position.
+*/
+type PositionWriter struct {
+	Velocity bool
+	Frame    bool
+	Header   bool
+
+	w       *bufio.Writer
+	started bool
+}
+
+/*
+NewPositionWriter creates a writer that writes csv lines to out. Flush must be called once all the positions have
+been written.
+*/
+func NewPositionWriter(out io.Writer) *PositionWriter {
+	return &PositionWriter{
+		w: bufio.NewWriterSize(out, 64*1024),
+	}
+}
+
+/*
+WriteEntry writes a single position as one line.
+*/
+func (pw *PositionWriter) WriteEntry(p *Position) error {
+	if !pw.started && pw.Header {
+		if _, err := pw.w.WriteString(strings.Join(pw.columns(), ",") + "\n"); err != nil {
+			return err
+		}
+	}
+	pw.started = true
+
+	if _, err := pw.w.WriteString(p.String()); err != nil {
+		return err
+	}
+	if pw.Velocity || pw.Frame {
+		if p.HasVelocity {
+			fmt.Fprintf(pw.w, ",%v,%v,%v", p.VX, p.VY, p.VZ)
+		} else {
+			pw.w.WriteString(",,,")
+		}
+	}
+	if pw.Frame {
+		fmt.Fprintf(pw.w, ",%v,%v", quotePositionField(p.Frame), quotePositionField(p.Center))
+	}
+	return pw.w.WriteByte('\n')
+}
+
+/*
+Flush writes any buffered data to the underlying writer.
+*/
+func (pw *PositionWriter) Flush() error {
+	return pw.w.Flush()
+}
+
+func (pw *PositionWriter) columns() []string {
+	result := []string{"id", "epoch", "x", "y", "z"}
+	if pw.Velocity || pw.Frame {
+		result = append(result, "vx", "vy", "vz")
+	}
+	if pw.Frame {
+		result = append(result, "frame", "center")
 	}
+	return result
 }
 
 /*
-ReadPositionFile opens a path an loads in a CSV formatted list of Positions
+ReadPositionFile opens a path an loads in a CSV formatted list of Positions, or one in the binary position format.
+Gzip and bzip2 compressed files are decompressed automatically.
 */
 func ReadPositionFile(path string) (pos []*Position, err error) {
-	file, err := os.Open(path)
+	file, err := OpenDecompressed(path)
 	if err != nil {
 		return nil, err
 	}
-	defer func(c *os.File) {
-		err = c.Close()
+	defer func(c io.Closer) {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
 	}(file)
 
 	return ReadPositions(file)
 }
 
 /*
-ReadPositions takes a reader and parses a CSV formatted list of positions.
+ReadPositions takes a reader and parses a CSV formatted list of positions. Blank lines, comments and a header row are
+skipped as a PositionReader does, the first bad line gives a *PositionError saying where it was. Input in the binary
+position format is spotted from its first few bytes and read with a BinaryPositionReader instead.
 */
 func ReadPositions(input io.Reader) ([]*Position, error) {
-	scanner := bufio.NewScanner(input)
+	buffered := bufio.NewReader(input)
+	start, err := buffered.Peek(len(binaryPositionMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
 
-	count := 0
-	result := make([]*Position, 366)
-	for scanner.Scan() {
-		r, err := ParsePositionLine(scanner.Text())
-		if err != nil {
+	var reader interface {
+		ReadEntry() (*Position, error)
+	}
+	if isBinaryPositions(start) {
+		if reader, err = NewBinaryPositionReaderFromReader(buffered); err != nil {
 			return nil, err
 		}
-		if r != nil {
-			if count >= len(result) {
-				result = append(result, r)
-				count++
-			} else {
-				result[count] = r
-				count++
-			}
-		}
+	} else {
+		reader = NewPositionReaderFromReader(buffered)
 	}
 
-	if err := scanner.Err(); err != nil {
+	count := 0
+	result := make([]*Position, 366)
+	r, err := reader.ReadEntry()
+	for err == nil {
+		if count >= len(result) {
+			result = append(result, r)
+			count++
+		} else {
+			result[count] = r
+			count++
+		}
+		r, err = reader.ReadEntry()
+	}
+	if err != io.EOF {
 		return nil, err
 	}
 
@@ -87,38 +195,59 @@ func ReadPositions(input io.Reader) ([]*Position, error) {
 
 /*
 ParsePositionLine takes a CSV formatted line representing a possition and returns the object or an error
+
+The line has five columns, id,epoch,x,y,z, eight with the velocity, vx,vy,vz, added or ten with the frame and center
+after those. Empty velocity columns give a position without a velocity. The ID can be in double quotes if it has a
+comma in it. Errors are a *PositionError saying which column was wrong.
 */
 func ParsePositionLine(line string) (*Position, error) {
 	if line == "" {
 		return nil, nil
 	}
 
-	parts := strings.Split(line, ",")
-
-	i, err := parseTime(parts[1])
+	parts, err := splitPositionLine(line)
 	if err != nil {
 		return nil, err
 	}
+	return parsePositionFields(parts)
+}
 
-	x, err := strconv.ParseFloat(parts[2], 64)
-	if err != nil {
-		return nil, err
+func parsePositionFields(parts []string) (*Position, error) {
+	if len(parts) != 5 && len(parts) != 8 && len(parts) != 10 {
+		return nil, &PositionError{Err: fmt.Errorf("expected 5, 8 or 10 columns got %d", len(parts))}
 	}
-	y, err := strconv.ParseFloat(parts[3], 64)
+
+	i, err := parseTime(parts[1])
 	if err != nil {
-		return nil, err
+		return nil, &PositionError{Column: 2, Err: fmt.Errorf("could not parse %q as a time", parts[1])}
 	}
-	z, err := strconv.ParseFloat(parts[4], 64)
-	if err != nil {
-		return nil, err
+
+	var values [6]float64
+	count := 3
+	if len(parts) > 5 && (parts[5] != "" || parts[6] != "" || parts[7] != "") {
+		count = 6
+	}
+	for j := 0; j < count; j++ {
+		values[j], err = strconv.ParseFloat(parts[j+2], 64)
+		if err != nil {
+			return nil, &PositionError{Column: j + 3, Err: fmt.Errorf("could not parse %q as a number", parts[j+2])}
+		}
 	}
 
 	result := Position{
-		ID:    parts[0],
-		Epoch: *i,
-		X:     x,
-		Y:     y,
-		Z:     z,
+		ID:          parts[0],
+		Epoch:       *i,
+		X:           values[0],
+		Y:           values[1],
+		Z:           values[2],
+		VX:          values[3],
+		VY:          values[4],
+		VZ:          values[5],
+		HasVelocity: count == 6,
+	}
+	if len(parts) == 10 {
+		result.Frame = parts[8]
+		result.Center = parts[9]
 	}
 	return &result, nil
 }
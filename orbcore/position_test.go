@@ -0,0 +1,121 @@
+package orbcore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testPositionTime = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParsePositionLine(t *testing.T) {
+	cases := map[string]Position{
+		"Ceres,2019-01-01T00:00:00Z,1,2,3": {
+			ID: "Ceres", Epoch: testPositionTime, X: 1, Y: 2, Z: 3,
+		},
+		"Ceres,2019-01-01T00:00:00Z,1,2,3,4,5,6": {
+			ID: "Ceres", Epoch: testPositionTime, X: 1, Y: 2, Z: 3, VX: 4, VY: 5, VZ: 6, HasVelocity: true,
+		},
+		"Ceres,2019-01-01T00:00:00Z,1,2,3,,,": {
+			ID: "Ceres", Epoch: testPositionTime, X: 1, Y: 2, Z: 3,
+		},
+		"Ceres,2019-01-01T00:00:00Z,1,2,3,4,5,6,ICRF,Sun": {
+			ID: "Ceres", Epoch: testPositionTime, X: 1, Y: 2, Z: 3, VX: 4, VY: 5, VZ: 6, HasVelocity: true,
+			Frame: ICRF, Center: "Sun",
+		},
+	}
+	for line, expected := range cases {
+		p, err := ParsePositionLine(line)
+		if err != nil {
+			t.Fatalf("%v: %v", line, err)
+		}
+		if *p != expected {
+			t.Errorf("%v: expected %+v got %+v", line, expected, p)
+		}
+	}
+
+	for _, line := range []string{
+		"Ceres,2019-01-01T00:00:00Z,1,2",
+		"Ceres,2019-01-01T00:00:00Z,1,2,3,4",
+		"Ceres,2019-01-01T00:00:00Z,1,2,3,4,5,x",
+		"Ceres,yesterday,1,2,3",
+	} {
+		if _, err := ParsePositionLine(line); err == nil {
+			t.Errorf("%v: expected an error", line)
+		}
+	}
+}
+
+func TestPositionWriter(t *testing.T) {
+	positions := []*Position{
+		{ID: "Ceres", Epoch: testPositionTime, X: 1, Y: 2, Z: 3, VX: 4, VY: 5, VZ: 6, HasVelocity: true, Frame: ICRF, Center: "Sun"},
+		{ID: "Vesta", Epoch: testPositionTime, X: 7, Y: 8, Z: 9},
+	}
+
+	cases := []struct {
+		writer   PositionWriter
+		expected string
+	}{
+		{PositionWriter{}, "Ceres,2019-01-01T00:00:00Z,1,2,3\nVesta,2019-01-01T00:00:00Z,7,8,9\n"},
+		{PositionWriter{Velocity: true, Header: true}, "id,epoch,x,y,z,vx,vy,vz\n" +
+			"Ceres,2019-01-01T00:00:00Z,1,2,3,4,5,6\nVesta,2019-01-01T00:00:00Z,7,8,9,,,\n"},
+		{PositionWriter{Frame: true}, "Ceres,2019-01-01T00:00:00Z,1,2,3,4,5,6,ICRF,Sun\n" +
+			"Vesta,2019-01-01T00:00:00Z,7,8,9,,,,,\n"},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		w := NewPositionWriter(&buf)
+		w.Velocity, w.Frame, w.Header = c.writer.Velocity, c.writer.Frame, c.writer.Header
+		for _, p := range positions {
+			if err := w.WriteEntry(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != c.expected {
+			t.Errorf("expected\n%v\ngot\n%v", c.expected, buf.String())
+		}
+
+		read, err := ReadPositions(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(read) != 2 || read[1].ID != "Vesta" || read[1].HasVelocity {
+			t.Errorf("unexpected positions read back %v", read)
+		}
+		if c.writer.Velocity && *read[0] != (Position{ID: "Ceres", Epoch: testPositionTime, X: 1, Y: 2, Z: 3, VX: 4, VY: 5, VZ: 6, HasVelocity: true}) {
+			t.Errorf("unexpected velocity read back %+v", read[0])
+		}
+		if c.writer.Frame && *read[0] != *positions[0] {
+			t.Errorf("expected %+v got %+v", positions[0], read[0])
+		}
+	}
+}
+
+func TestReadPositionsOldFormat(t *testing.T) {
+	in := "Ceres,2019-01-01T00:00:00Z,1,2,3\n\nCeres,2019-01-02T00:00:00.000,4,5,6\n"
+	read, err := ReadPositions(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 2 || read[1].X != 4 || !read[1].Epoch.Equal(testPositionTime.AddDate(0, 0, 1)) {
+		t.Errorf("unexpected positions %v", read)
+	}
+}
+
+func TestOrbitToPositionVelocity(t *testing.T) {
+	orb := Orbit{
+		ID:                  "circle",
+		ParentGrav:          1.32712440018e11,
+		Epoch:               testPositionTime,
+		OrbitalEccentricity: 0,
+		SemimajorAxis:       1.495978707e8,
+	}
+	p := OrbitToPosition(&orb)
+	if !p.HasVelocity || p.X < 1.4e8 || p.VY < 29 || p.VY > 30.5 {
+		t.Errorf("expected about 29.8 km/s along y got %+v", p)
+	}
+}
@@ -0,0 +1,654 @@
+package orbcore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+/*
+The binary position format stores positions in chunks, each column of a chunk stored one after another so they
+compress and read back quickly. All numbers are little endian.
+
+The file starts with a header, the magic bytes ORBCPOS and a zero, then a uint16 version and a uint16 of flags saying
+whether coordinates are float32 and if the velocity, frame and center columns are present.
+
+Each chunk starts with the tag CHNK, the uint32 number of positions in it and the int64 unix time in seconds the
+epochs are offset from. Next comes the dictionary for the chunk, a uint32 count then each string as a uvarint length
+and its bytes. Then the columns, uint32 dictionary indexes for the IDs, int64 epoch offsets in nanoseconds, x, y and z,
+vx, vy and vz if there is velocity, NaN when a position has none, and dictionary indexes for the frame and center.
+
+After the last chunk the tag INDX starts the index, a uint32 count of chunks then a record for each one. The file ends
+with the uint64 offset of the index and the magic bytes ORBCIDX and a zero, so the index can be found from the end of
+the file.
+*/
+const (
+	binaryPositionMagic   = "ORBCPOS\x00"
+	binaryIndexMagic      = "ORBCIDX\x00"
+	binaryPositionVersion = 1
+
+	binaryChunkTag = "CHNK"
+	binaryIndexTag = "INDX"
+
+	// DefaultPositionChunkSize is the number of positions in each chunk when a BinaryPositionWriter has no ChunkSize.
+	DefaultPositionChunkSize = 65536
+	// MaxPositionChunkSize stops corrupt files asking for huge amounts of memory.
+	MaxPositionChunkSize = 1 << 24
+
+	// maxChunkSpan is the widest range of epochs in a chunk in seconds, keeping the nanosecond offsets inside an int64.
+	maxChunkSpan = 9e9
+	// maxDictionaryString limits the length of IDs, frames and centers.
+	maxDictionaryString = 1 << 16
+)
+
+const (
+	binaryFloat32 uint16 = 1 << iota
+	binaryVelocity
+	binaryFrame
+)
+
+/*
+BinaryPositionWriter writes positions in the binary position format, far smaller and quicker to read than csv for
+large outputs.
+
+Float32 stores coordinates as float32 rather than float64, halving their size but only keeping about seven
+significant figures, some tens of km for a main belt asteroid. Velocity and Frame add those columns as they do on a
+PositionWriter. These and ChunkSize must be set before the first position is written.
+*/
+type BinaryPositionWriter struct {
+	Float32   bool
+	Velocity  bool
+	Frame     bool
+	ChunkSize int
+
+	out     *countingWriter
+	w       *bufio.Writer
+	started bool
+	closed  bool
+	index   []binaryIndexEntry
+	chunk   binaryChunk
+}
+
+/*
+NewBinaryPositionWriter creates a writer that writes to out. Close must be called once all the positions have been
+written to add the index.
+*/
+func NewBinaryPositionWriter(out io.Writer) *BinaryPositionWriter {
+	counter := &countingWriter{w: out}
+	return &BinaryPositionWriter{
+		out: counter,
+		w:   bufio.NewWriterSize(counter, 64*1024),
+	}
+}
+
+/*
+WriteEntry adds a position to the current chunk, writing the chunk out when it is full.
+*/
+func (bw *BinaryPositionWriter) WriteEntry(p *Position) error {
+	if bw.closed {
+		return fmt.Errorf("position writer is closed")
+	}
+	if err := bw.start(); err != nil {
+		return err
+	}
+
+	c := &bw.chunk
+	if c.count > 0 && math.Abs(float64(p.Epoch.Unix()-c.base)) > maxChunkSpan {
+		if err := bw.writeChunk(); err != nil {
+			return err
+		}
+	}
+	if c.count == 0 {
+		c.base = p.Epoch.Unix()
+	}
+
+	c.count++
+	c.ids = append(c.ids, c.lookup(p.ID))
+	c.epochs = append(c.epochs, int64(p.Epoch.Sub(time.Unix(c.base, 0))))
+	c.values[0] = append(c.values[0], p.X)
+	c.values[1] = append(c.values[1], p.Y)
+	c.values[2] = append(c.values[2], p.Z)
+	if bw.Velocity {
+		vx, vy, vz := math.NaN(), math.NaN(), math.NaN()
+		if p.HasVelocity {
+			vx, vy, vz = p.VX, p.VY, p.VZ
+		}
+		c.values[3] = append(c.values[3], vx)
+		c.values[4] = append(c.values[4], vy)
+		c.values[5] = append(c.values[5], vz)
+	}
+	if bw.Frame {
+		c.frames = append(c.frames, c.lookup(p.Frame))
+		c.centers = append(c.centers, c.lookup(p.Center))
+	}
+
+	if c.count >= bw.ChunkSize {
+		return bw.writeChunk()
+	}
+	return nil
+}
+
+/*
+Close writes the last chunk and the index then flushes the output. It does not close the underlying writer.
+*/
+func (bw *BinaryPositionWriter) Close() error {
+	if bw.closed {
+		return nil
+	}
+	if err := bw.start(); err != nil {
+		return err
+	}
+	if bw.chunk.count > 0 {
+		if err := bw.writeChunk(); err != nil {
+			return err
+		}
+	}
+	bw.closed = true
+
+	offset := bw.offset()
+	bw.w.WriteString(binaryIndexTag)
+	binary.Write(bw.w, binary.LittleEndian, uint32(len(bw.index)))
+	binary.Write(bw.w, binary.LittleEndian, bw.index)
+	binary.Write(bw.w, binary.LittleEndian, uint64(offset))
+	bw.w.WriteString(binaryIndexMagic)
+	return bw.w.Flush()
+}
+
+func (bw *BinaryPositionWriter) start() error {
+	if bw.started {
+		return nil
+	}
+	bw.started = true
+
+	if bw.ChunkSize <= 0 {
+		bw.ChunkSize = DefaultPositionChunkSize
+	}
+	if bw.ChunkSize > MaxPositionChunkSize {
+		return fmt.Errorf("chunk size %d is more than the largest allowed, %d", bw.ChunkSize, MaxPositionChunkSize)
+	}
+
+	var flags uint16
+	if bw.Float32 {
+		flags |= binaryFloat32
+	}
+	if bw.Velocity {
+		flags |= binaryVelocity
+	}
+	if bw.Frame {
+		flags |= binaryFrame
+	}
+	bw.w.WriteString(binaryPositionMagic)
+	binary.Write(bw.w, binary.LittleEndian, uint16(binaryPositionVersion))
+	return binary.Write(bw.w, binary.LittleEndian, flags)
+}
+
+func (bw *BinaryPositionWriter) offset() int64 {
+	return bw.out.n + int64(bw.w.Buffered())
+}
+
+func (bw *BinaryPositionWriter) writeChunk() error {
+	c := &bw.chunk
+	entry := binaryIndexEntry{Offset: uint64(bw.offset()), Count: uint32(c.count), Base: c.base}
+	entry.Start, entry.End = c.epochs[0], c.epochs[0]
+	for _, e := range c.epochs {
+		if e < entry.Start {
+			entry.Start = e
+		}
+		if e > entry.End {
+			entry.End = e
+		}
+	}
+	bw.index = append(bw.index, entry)
+
+	w := bw.w
+	w.WriteString(binaryChunkTag)
+	binary.Write(w, binary.LittleEndian, uint32(c.count))
+	binary.Write(w, binary.LittleEndian, c.base)
+	binary.Write(w, binary.LittleEndian, uint32(len(c.dictionary)))
+	var buf [binary.MaxVarintLen64]byte
+	for _, s := range c.dictionary {
+		w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))])
+		w.WriteString(s)
+	}
+	binary.Write(w, binary.LittleEndian, c.ids)
+	binary.Write(w, binary.LittleEndian, c.epochs)
+
+	columns := 3
+	if bw.Velocity {
+		columns = 6
+	}
+	for i := 0; i < columns; i++ {
+		var err error
+		if bw.Float32 {
+			err = binary.Write(w, binary.LittleEndian, toFloat32(c.values[i]))
+		} else {
+			err = binary.Write(w, binary.LittleEndian, c.values[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if bw.Frame {
+		binary.Write(w, binary.LittleEndian, c.frames)
+		binary.Write(w, binary.LittleEndian, c.centers)
+	}
+
+	c.reset()
+	// bufio.Writer keeps the first error it sees and returns it from every later call, so this catches any of the
+	// writes above failing.
+	_, err := w.Write(nil)
+	return err
+}
+
+/*
+BinaryPositionReader streams positions out of a file in the binary position format, a chunk at a time.
+*/
+type BinaryPositionReader struct {
+	file   io.Closer
+	r      *bufio.Reader
+	flags  uint16
+	chunk  []Position
+	next   int
+	offset int64
+	done   bool
+}
+
+/*
+NewBinaryPositionReader opens a binary position file. Gzip and bzip2 compressed files are decompressed on the fly.
+*/
+func NewBinaryPositionReader(path string) (*BinaryPositionReader, error) {
+	f, err := OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := NewBinaryPositionReaderFromReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	result.file = f
+	return result, nil
+}
+
+/*
+NewBinaryPositionReaderFromReader creates a BinaryPositionReader that reads from an already open reader, checking the
+header as it does.
+*/
+func NewBinaryPositionReaderFromReader(in io.Reader) (*BinaryPositionReader, error) {
+	r := bufio.NewReaderSize(in, 64*1024)
+	flags, err := readBinaryHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryPositionReader{r: r, flags: flags}, nil
+}
+
+/*
+ReadEntry returns the next position. At the end of the input io.EOF is returned.
+*/
+func (br *BinaryPositionReader) ReadEntry() (*Position, error) {
+	for br.next >= len(br.chunk) {
+		if br.done {
+			return nil, io.EOF
+		}
+		tag := make([]byte, len(binaryChunkTag))
+		if _, err := io.ReadFull(br.r, tag); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("no index at the end of the file, was the writer closed?")
+			}
+			return nil, err
+		}
+		switch string(tag) {
+		case binaryIndexTag:
+			br.done = true
+			return nil, io.EOF
+		case binaryChunkTag:
+		default:
+			return nil, fmt.Errorf("expected a chunk got %q", tag)
+		}
+
+		chunk, err := readBinaryChunk(br.r, br.flags)
+		if err != nil {
+			return nil, err
+		}
+		br.chunk, br.next = chunk, 0
+	}
+
+	br.next++
+	return &br.chunk[br.next-1], nil
+}
+
+/*
+Close closes the underlying file if this reader opened it.
+*/
+func (br *BinaryPositionReader) Close() error {
+	if br.file != nil {
+		return br.file.Close()
+	}
+	return nil
+}
+
+/*
+BinaryPositionChunk describes one chunk of a binary position file, where it starts, how many positions are in it and
+the range of their epochs.
+*/
+type BinaryPositionChunk struct {
+	Offset int64
+	Count  int
+	Start  time.Time
+	End    time.Time
+}
+
+/*
+BinaryPositionIndex reads chunks from a binary position file in any order using the index at the end of the file.
+The file must not be compressed.
+*/
+type BinaryPositionIndex struct {
+	Chunks []BinaryPositionChunk
+
+	in    io.ReaderAt
+	flags uint16
+}
+
+/*
+ReadBinaryPositionIndex reads the header and index of a binary position file of size bytes.
+*/
+func ReadBinaryPositionIndex(in io.ReaderAt, size int64) (*BinaryPositionIndex, error) {
+	flags, err := readBinaryHeader(io.NewSectionReader(in, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	trailer := make([]byte, 8+len(binaryIndexMagic))
+	if size < int64(len(binaryPositionMagic)+4+len(trailer)) {
+		return nil, fmt.Errorf("file is too short to have an index")
+	}
+	if _, err := in.ReadAt(trailer, size-int64(len(trailer))); err != nil {
+		return nil, err
+	}
+	if string(trailer[8:]) != binaryIndexMagic {
+		return nil, fmt.Errorf("no index at the end of the file, was the writer closed?")
+	}
+	offset := int64(binary.LittleEndian.Uint64(trailer))
+	if offset < 0 || offset > size-int64(len(trailer)) {
+		return nil, fmt.Errorf("index offset %d is outside the file", offset)
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(in, offset, size-offset))
+	tag := make([]byte, len(binaryIndexTag))
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return nil, err
+	}
+	if string(tag) != binaryIndexTag {
+		return nil, fmt.Errorf("expected the index at %d got %q", offset, tag)
+	}
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	if int64(count)*int64(binary.Size(binaryIndexEntry{})) > size-offset {
+		return nil, fmt.Errorf("index has %d chunks, more than fit in the file", count)
+	}
+	entries := make([]binaryIndexEntry, count)
+	if err := binary.Read(r, binary.LittleEndian, entries); err != nil {
+		return nil, err
+	}
+
+	result := BinaryPositionIndex{in: in, flags: flags}
+	for _, e := range entries {
+		base := time.Unix(e.Base, 0).UTC()
+		result.Chunks = append(result.Chunks, BinaryPositionChunk{
+			Offset: int64(e.Offset),
+			Count:  int(e.Count),
+			Start:  base.Add(time.Duration(e.Start)),
+			End:    base.Add(time.Duration(e.End)),
+		})
+	}
+	return &result, nil
+}
+
+/*
+ReadChunk reads all the positions in chunk i.
+*/
+func (bi *BinaryPositionIndex) ReadChunk(i int) ([]Position, error) {
+	if i < 0 || i >= len(bi.Chunks) {
+		return nil, fmt.Errorf("chunk %d out of range, there are %d", i, len(bi.Chunks))
+	}
+	r := bufio.NewReader(io.NewSectionReader(bi.in, bi.Chunks[i].Offset, math.MaxInt64-bi.Chunks[i].Offset))
+	tag := make([]byte, len(binaryChunkTag))
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return nil, err
+	}
+	if string(tag) != binaryChunkTag {
+		return nil, fmt.Errorf("expected chunk %d at %d got %q", i, bi.Chunks[i].Offset, tag)
+	}
+	return readBinaryChunk(r, bi.flags)
+}
+
+/*
+Between returns the chunks that may have positions with epochs from start to end.
+*/
+func (bi *BinaryPositionIndex) Between(start, end time.Time) []int {
+	var result []int
+	for i, c := range bi.Chunks {
+		if !c.End.Before(start) && !c.Start.After(end) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// binaryIndexEntry is how a chunk is recorded in the index, Start and End are nanoseconds from Base like the epochs.
+type binaryIndexEntry struct {
+	Offset uint64
+	Count  uint32
+	Base   int64
+	Start  int64
+	End    int64
+}
+
+// binaryChunk collects the columns of the chunk being written.
+type binaryChunk struct {
+	count      int
+	base       int64
+	dictionary []string
+	lookups    map[string]uint32
+	ids        []uint32
+	epochs     []int64
+	values     [6][]float64
+	frames     []uint32
+	centers    []uint32
+}
+
+func (c *binaryChunk) lookup(s string) uint32 {
+	if c.lookups == nil {
+		c.lookups = make(map[string]uint32)
+	}
+	i, ok := c.lookups[s]
+	if !ok {
+		i = uint32(len(c.dictionary))
+		c.dictionary = append(c.dictionary, s)
+		c.lookups[s] = i
+	}
+	return i
+}
+
+func (c *binaryChunk) reset() {
+	c.count = 0
+	c.dictionary = c.dictionary[:0]
+	c.lookups = nil
+	c.ids = c.ids[:0]
+	c.epochs = c.epochs[:0]
+	for i := range c.values {
+		c.values[i] = c.values[i][:0]
+	}
+	c.frames = c.frames[:0]
+	c.centers = c.centers[:0]
+}
+
+func readBinaryHeader(r io.Reader) (uint16, error) {
+	header := make([]byte, len(binaryPositionMagic)+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, fmt.Errorf("could not read header: %v", err)
+	}
+	if string(header[:len(binaryPositionMagic)]) != binaryPositionMagic {
+		return 0, fmt.Errorf("not a binary position file")
+	}
+	version := binary.LittleEndian.Uint16(header[len(binaryPositionMagic):])
+	if version != binaryPositionVersion {
+		return 0, fmt.Errorf("unsupported binary position version %d", version)
+	}
+	return binary.LittleEndian.Uint16(header[len(binaryPositionMagic)+2:]), nil
+}
+
+// readBinaryChunk reads a chunk after its tag.
+func readBinaryChunk(r *bufio.Reader, flags uint16) ([]Position, error) {
+	var head struct {
+		Count      uint32
+		Base       int64
+		Dictionary uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &head); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if head.Count > MaxPositionChunkSize || head.Dictionary > 3*head.Count {
+		return nil, fmt.Errorf("chunk with %d positions and %d strings is too large", head.Count, head.Dictionary)
+	}
+
+	dictionary := make([]string, head.Dictionary)
+	for i := range dictionary {
+		length, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if length > maxDictionaryString {
+			return nil, fmt.Errorf("string of %d bytes is too long", length)
+		}
+		b := make([]byte, length)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		dictionary[i] = string(b)
+	}
+	lookup := func(indexes []uint32) ([]string, error) {
+		result := make([]string, len(indexes))
+		for i, d := range indexes {
+			if int(d) >= len(dictionary) {
+				return nil, fmt.Errorf("string %d is not in the dictionary of %d", d, len(dictionary))
+			}
+			result[i] = dictionary[d]
+		}
+		return result, nil
+	}
+
+	n := int(head.Count)
+	indexes := make([]uint32, n)
+	if err := binary.Read(r, binary.LittleEndian, indexes); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	ids, err := lookup(indexes)
+	if err != nil {
+		return nil, err
+	}
+	epochs := make([]int64, n)
+	if err := binary.Read(r, binary.LittleEndian, epochs); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	columns := 3
+	if flags&binaryVelocity != 0 {
+		columns = 6
+	}
+	var values [6][]float64
+	for i := 0; i < columns; i++ {
+		values[i], err = readFloats(r, n, flags&binaryFloat32 != 0)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+
+	var frames, centers []string
+	if flags&binaryFrame != 0 {
+		if err := binary.Read(r, binary.LittleEndian, indexes); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if frames, err = lookup(indexes); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, indexes); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if centers, err = lookup(indexes); err != nil {
+			return nil, err
+		}
+	}
+
+	base := time.Unix(head.Base, 0).UTC()
+	result := make([]Position, n)
+	for i := range result {
+		p := &result[i]
+		p.ID = ids[i]
+		p.Epoch = base.Add(time.Duration(epochs[i]))
+		p.X, p.Y, p.Z = values[0][i], values[1][i], values[2][i]
+		if columns == 6 && !math.IsNaN(values[3][i]) {
+			p.VX, p.VY, p.VZ = values[3][i], values[4][i], values[5][i]
+			p.HasVelocity = true
+		}
+		if frames != nil {
+			p.Frame, p.Center = frames[i], centers[i]
+		}
+	}
+	return result, nil
+}
+
+func readFloats(r io.Reader, n int, float32s bool) ([]float64, error) {
+	result := make([]float64, n)
+	if !float32s {
+		return result, binary.Read(r, binary.LittleEndian, result)
+	}
+	small := make([]float32, n)
+	if err := binary.Read(r, binary.LittleEndian, small); err != nil {
+		return nil, err
+	}
+	for i, v := range small {
+		result[i] = float64(v)
+	}
+	return result, nil
+}
+
+func toFloat32(values []float64) []float32 {
+	result := make([]float32, len(values))
+	for i, v := range values {
+		result[i] = float32(v)
+	}
+	return result
+}
+
+// unexpectedEOF turns an io.EOF part way through a chunk into io.ErrUnexpectedEOF so it is not taken as the end.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// isBinaryPositions checks if the start of a file is the binary position magic.
+func isBinaryPositions(start []byte) bool {
+	return bytes.HasPrefix(start, []byte(binaryPositionMagic))
+}
+
+// countingWriter counts the bytes written through it so the writer knows where each chunk starts.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
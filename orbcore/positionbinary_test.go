@@ -0,0 +1,175 @@
+package orbcore
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+func testBinaryPositions() []*Position {
+	var result []*Position
+	for i := 0; i < 25; i++ {
+		p := &Position{
+			ID:    []string{"Ceres", "(2) Pallas, the second", "Vesta"}[i%3],
+			Epoch: testPositionTime.Add(time.Duration(i) * 36 * time.Hour).Add(123456789),
+			X:     1.5e8 + float64(i),
+			Y:     -2.25e8 * float64(i),
+			Z:     1e6 / float64(i+1),
+		}
+		if i%2 == 0 {
+			p.VX, p.VY, p.VZ, p.HasVelocity = 10, -20.5, 0.125*float64(i), true
+		}
+		if i%5 == 0 {
+			p.Frame, p.Center = ICRF, "Sun"
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func writeBinaryPositions(t *testing.T, bw *BinaryPositionWriter, positions []*Position) {
+	for _, p := range positions {
+		if err := bw.WriteEntry(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBinaryPositionRoundTrip(t *testing.T) {
+	positions := testBinaryPositions()
+	var buf bytes.Buffer
+	bw := NewBinaryPositionWriter(&buf)
+	bw.Velocity = true
+	bw.Frame = true
+	bw.ChunkSize = 10
+	writeBinaryPositions(t, bw, positions)
+
+	r, err := NewBinaryPositionReaderFromReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range positions {
+		p, err := r.ReadEntry()
+		if err != nil {
+			t.Fatalf("%v: %v", i, err)
+		}
+		if *p != *expected {
+			t.Errorf("%v: expected %+v got %+v", i, expected, p)
+		}
+	}
+	if _, err := r.ReadEntry(); err != io.EOF {
+		t.Errorf("expected io.EOF got %v", err)
+	}
+
+	// ReadPositions spots the format for itself.
+	read, err := ReadPositions(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(positions) || *read[24] != *positions[24] {
+		t.Errorf("expected %v positions got %v", len(positions), len(read))
+	}
+}
+
+func TestBinaryPositionColumns(t *testing.T) {
+	positions := testBinaryPositions()
+	var buf bytes.Buffer
+	bw := NewBinaryPositionWriter(&buf)
+	bw.Float32 = true
+	writeBinaryPositions(t, bw, positions)
+
+	read, err := ReadPositions(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range read {
+		expected := positions[i]
+		if p.ID != expected.ID || !p.Epoch.Equal(expected.Epoch) || p.HasVelocity || p.Frame != "" {
+			t.Errorf("%v: expected %+v got %+v", i, expected, p)
+		}
+		if math.Abs(p.X/expected.X-1) > 1e-7 || p.Z != float64(float32(expected.Z)) {
+			t.Errorf("%v: expected float32 precision got %v %v", i, p.X, p.Z)
+		}
+	}
+}
+
+func TestBinaryPositionIndex(t *testing.T) {
+	positions := testBinaryPositions()
+	// A position far from the rest starts a new chunk so the epoch offsets fit.
+	positions = append(positions, &Position{ID: "Halley", Epoch: time.Date(1066, 3, 1, 0, 0, 0, 0, time.UTC)})
+
+	var buf bytes.Buffer
+	bw := NewBinaryPositionWriter(&buf)
+	bw.ChunkSize = 10
+	writeBinaryPositions(t, bw, positions)
+
+	index, err := ReadBinaryPositionIndex(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Chunks) != 4 {
+		t.Fatalf("expected 4 chunks got %+v", index.Chunks)
+	}
+	first := index.Chunks[0]
+	if first.Count != 10 || !first.Start.Equal(positions[0].Epoch) || !first.End.Equal(positions[9].Epoch) {
+		t.Errorf("unexpected first chunk %+v", first)
+	}
+	if c := index.Chunks[3]; c.Count != 1 || c.Start.Year() != 1066 {
+		t.Errorf("unexpected last chunk %+v", c)
+	}
+
+	chunks := index.Between(positions[12].Epoch, positions[22].Epoch)
+	if len(chunks) != 2 || chunks[0] != 1 || chunks[1] != 2 {
+		t.Fatalf("expected chunks 1 and 2 got %v", chunks)
+	}
+	read, err := index.ReadChunk(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 5 || read[0].ID != positions[20].ID || read[4].Y != positions[24].Y {
+		t.Errorf("unexpected chunk %+v", read)
+	}
+	if _, err := index.ReadChunk(4); err == nil {
+		t.Error("expected an error for a chunk past the end")
+	}
+}
+
+func TestBinaryPositionErrors(t *testing.T) {
+	var buf bytes.Buffer
+	bw := NewBinaryPositionWriter(&buf)
+	bw.ChunkSize = 10
+	writeBinaryPositions(t, bw, testBinaryPositions())
+	data := buf.Bytes()
+
+	if _, err := NewBinaryPositionReaderFromReader(bytes.NewReader([]byte("id,epoch,x,y,z\n"))); err == nil {
+		t.Error("expected an error for a csv file")
+	}
+
+	// Stop part way through the second chunk and just before the index.
+	for _, size := range []int{200, bytes.LastIndex(data, []byte(binaryIndexTag))} {
+		r, err := NewBinaryPositionReaderFromReader(bytes.NewReader(data[:size]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for err == nil {
+			_, err = r.ReadEntry()
+		}
+		if err == io.EOF {
+			t.Errorf("%v: expected an error for a truncated file", size)
+		}
+		if _, err := ReadBinaryPositionIndex(bytes.NewReader(data[:size]), int64(size)); err == nil {
+			t.Errorf("%v: expected an error reading the index of a truncated file", size)
+		}
+	}
+
+	bw = NewBinaryPositionWriter(&buf)
+	bw.Close()
+	if err := bw.WriteEntry(&Position{}); err == nil {
+		t.Error("expected an error writing to a closed writer")
+	}
+}
@@ -0,0 +1,203 @@
+package orbcore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*
+PositionError says where in a position csv file a problem was. Line and Column count from one, Line is zero when the
+error came from parsing a single line on its own and Column is zero when the problem is with the whole line.
+*/
+type PositionError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *PositionError) Error() string {
+	var parts []string
+	if e.Line > 0 {
+		parts = append(parts, fmt.Sprintf("line %d", e.Line))
+	}
+	if e.Column > 0 {
+		parts = append(parts, fmt.Sprintf("column %d (%v)", e.Column, columnName(e.Column)))
+	}
+	if len(parts) == 0 {
+		return e.Err.Error()
+	}
+	return strings.Join(parts, " ") + ": " + e.Err.Error()
+}
+
+var positionColumns = []string{"id", "epoch", "x", "y", "z", "vx", "vy", "vz", "frame", "center"}
+
+func columnName(column int) string {
+	if column > len(positionColumns) {
+		return "extra"
+	}
+	return positionColumns[column-1]
+}
+
+/*
+PositionReader streams positions out of a csv file in any of the layouts ParsePositionLine understands.
+
+Blank lines and lines starting with # are skipped, as is a header row naming the columns if it is the first thing in the
+file. A strict reader only takes a row whose first column is "id" as the header, a Lenient one also takes any first row
+where none of the position columns are numbers. IDs may be quoted with double quotes so they can contain commas, a
+double quote inside a quoted ID is written twice.
+
+By default a reader is strict, the first bad line stops it with a *PositionError. A Lenient reader ignores spaces
+around values and skips lines it cannot parse, keeping their errors in Skipped.
+*/
+type PositionReader struct {
+	Lenient bool
+	Skipped []*PositionError
+
+	file    io.Closer
+	scanner *bufio.Scanner
+	line    int
+	started bool
+}
+
+/*
+NewPositionReader opens a position csv file. Gzip and bzip2 compressed files are decompressed on the fly.
+*/
+func NewPositionReader(path string) (*PositionReader, error) {
+	f, err := OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	result := NewPositionReaderFromReader(f)
+	result.file = f
+	return result, nil
+}
+
+/*
+NewPositionReaderFromReader creates a PositionReader that reads from an already open reader.
+*/
+func NewPositionReaderFromReader(in io.Reader) *PositionReader {
+	return &PositionReader{
+		scanner: bufio.NewScanner(in),
+	}
+}
+
+/*
+ReadEntry returns the next position. At the end of the input io.EOF is returned.
+*/
+func (r *PositionReader) ReadEntry() (*Position, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := r.scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+
+		fields, err := splitPositionLine(text)
+		if err == nil && r.Lenient {
+			for i := range fields {
+				fields[i] = strings.TrimSpace(fields[i])
+			}
+		}
+		if err == nil && !r.started && isPositionHeader(fields, r.Lenient) {
+			r.started = true
+			continue
+		}
+		r.started = true
+
+		var pos *Position
+		if err == nil {
+			pos, err = parsePositionFields(fields)
+		}
+		if err != nil {
+			perr := &PositionError{Line: r.line, Err: err}
+			if e, ok := err.(*PositionError); ok {
+				perr.Column, perr.Err = e.Column, e.Err
+			}
+			if r.Lenient {
+				r.Skipped = append(r.Skipped, perr)
+				continue
+			}
+			return nil, perr
+		}
+		return pos, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+/*
+Close closes the underlying file if this reader opened it.
+*/
+func (r *PositionReader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+// splitPositionLine splits a csv line into fields, removing the quotes from quoted fields along with any spaces
+// outside them.
+func splitPositionLine(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	quoted, wasQuoted := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quoted && c == '"' && i+1 < len(line) && line[i+1] == '"':
+			field.WriteByte('"')
+			i++
+		case quoted && c == '"':
+			quoted = false
+		case quoted:
+			field.WriteByte(c)
+		case c == '"' && strings.TrimSpace(field.String()) == "" && !wasQuoted:
+			field.Reset()
+			quoted, wasQuoted = true, true
+		case c == ',':
+			fields = append(fields, field.String())
+			field.Reset()
+			wasQuoted = false
+		case wasQuoted && c == ' ':
+		case wasQuoted:
+			return nil, &PositionError{Column: len(fields) + 1, Err: fmt.Errorf("unexpected %q after a closing quote", c)}
+		default:
+			field.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, &PositionError{Column: len(fields) + 1, Err: fmt.Errorf("quote is not closed")}
+	}
+	return append(fields, field.String()), nil
+}
+
+// quotePositionField quotes a field if it would not survive splitPositionLine as it is.
+func quotePositionField(field string) string {
+	if !strings.ContainsAny(field, ",\"") {
+		return field
+	}
+	return `"` + strings.Replace(field, `"`, `""`, -1) + `"`
+}
+
+// isPositionHeader spots a header row starting with an id column like a PositionWriter writes. Leniently it also
+// takes one where none of the position columns are numbers, so a data row could be lost that way.
+func isPositionHeader(fields []string, lenient bool) bool {
+	if strings.EqualFold(strings.TrimSpace(fields[0]), "id") {
+		return true
+	}
+	if !lenient || len(fields) < 5 {
+		return false
+	}
+	for _, f := range fields[2:5] {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(f), 64); err == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package orbcore
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitPositionLine(t *testing.T) {
+	cases := map[string][]string{
+		`Ceres,2019-01-01T00:00:00Z,1,2,3`: {"Ceres", "2019-01-01T00:00:00Z", "1", "2", "3"},
+		`"(1) Ceres, dwarf",b,c`:           {"(1) Ceres, dwarf", "b", "c"},
+		`"say ""hi""",b`:                   {`say "hi"`, "b"},
+		` "spaced" ,b`:                     {"spaced", "b"},
+		`a,,`:                              {"a", "", ""},
+		`2004 MN4 "Apophis",2019-01-01T00:00:00Z`: {`2004 MN4 "Apophis"`, "2019-01-01T00:00:00Z"},
+	}
+	for line, expected := range cases {
+		fields, err := splitPositionLine(line)
+		if err != nil {
+			t.Fatalf("%v: %v", line, err)
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("%v: expected %q got %q", line, expected, fields)
+		}
+	}
+
+	for _, line := range []string{`"open,b`, `"closed"x,b`} {
+		if _, err := splitPositionLine(line); err == nil {
+			t.Errorf("%v: expected an error", line)
+		}
+	}
+}
+
+func TestParsePositionLineErrors(t *testing.T) {
+	cases := map[string]int{
+		"Ceres":                                 0,
+		"Ceres,2019-01-01T00:00:00Z,1,2":        0,
+		"Ceres,yesterday,1,2,3":                 2,
+		"Ceres,2019-01-01T00:00:00Z,1,2,z":      5,
+		"Ceres,2019-01-01T00:00:00Z,1,2,3,4,,6": 7,
+		`"Ceres,2019-01-01T00:00:00Z,1,2,3`:     1,
+	}
+	for line, column := range cases {
+		_, err := ParsePositionLine(line)
+		perr, ok := err.(*PositionError)
+		if !ok {
+			t.Errorf("%v: expected a PositionError got %v", line, err)
+			continue
+		}
+		if perr.Column != column || perr.Line != 0 {
+			t.Errorf("%v: expected column %v got %v", line, column, perr)
+		}
+	}
+}
+
+const messyPositions = `# positions from somewhere else
+ID,Epoch,X,Y,Z
+
+"(1) Ceres, the first",2019-01-01T00:00:00Z,1,2,3
+Vesta,2019-01-01T00:00:00Z,4,5,oops
+ Pallas , 2019-01-01T00:00:00Z , 7 , 8 , 9
+Juno,2019-01-01T00:00:00Z,10,11
+`
+
+func TestPositionReaderStrict(t *testing.T) {
+	r := NewPositionReaderFromReader(strings.NewReader(messyPositions))
+	p, err := r.ReadEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "(1) Ceres, the first" || p.X != 1 {
+		t.Errorf("unexpected position %+v", p)
+	}
+
+	_, err = r.ReadEntry()
+	perr, ok := err.(*PositionError)
+	if !ok || perr.Line != 5 || perr.Column != 5 {
+		t.Fatalf("expected an error at line 5 column 5 got %v", err)
+	}
+	if !strings.HasPrefix(perr.Error(), "line 5 column 5 (z): ") {
+		t.Errorf("unexpected message %q", perr.Error())
+	}
+
+	if _, err := ReadPositions(strings.NewReader(messyPositions)); err == nil || !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("expected ReadPositions to fail on line 5 got %v", err)
+	}
+}
+
+func TestPositionReaderLenient(t *testing.T) {
+	r := NewPositionReaderFromReader(strings.NewReader(messyPositions))
+	r.Lenient = true
+
+	var ids []string
+	p, err := r.ReadEntry()
+	for err == nil {
+		ids = append(ids, p.ID)
+		p, err = r.ReadEntry()
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []string{"(1) Ceres, the first", "Pallas"}) {
+		t.Errorf("unexpected ids %q", ids)
+	}
+	if len(r.Skipped) != 2 || r.Skipped[0].Line != 5 || r.Skipped[1].Line != 7 || r.Skipped[1].Column != 0 {
+		t.Errorf("unexpected skipped lines %v", r.Skipped)
+	}
+}
+
+func TestPositionReaderHeader(t *testing.T) {
+	input := "Ceres,2019-01-01T00:00:00Z,x,y,z\nVesta,2019-01-01T00:00:00Z,4,5,6\n"
+
+	r := NewPositionReaderFromReader(strings.NewReader(input))
+	if _, err := r.ReadEntry(); err == nil {
+		t.Error("expected a strict reader to fail on a first row that is not numbers")
+	}
+
+	r = NewPositionReaderFromReader(strings.NewReader(input))
+	r.Lenient = true
+	p, err := r.ReadEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "Vesta" || len(r.Skipped) != 0 {
+		t.Errorf("expected a lenient reader to take the first row as a header got %v %v", p.ID, r.Skipped)
+	}
+
+	r = NewPositionReaderFromReader(strings.NewReader("ID,epoch,x,y,z\nVesta,2019-01-01T00:00:00Z,4,5,6\n"))
+	if p, err := r.ReadEntry(); err != nil || p.ID != "Vesta" {
+		t.Errorf("expected an id header to be skipped got %v %v", p, err)
+	}
+}
+
+func TestPositionQuotedRoundTrip(t *testing.T) {
+	p := Position{ID: `"Oumuamua", 1I`, Epoch: testPositionTime, X: 1, Y: 2, Z: 3}
+	read, err := ParsePositionLine(p.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *read != p {
+		t.Errorf("expected %+v got %+v", p, read)
+	}
+}
@@ -1,7 +1,6 @@
 package orbcore
 
 import (
-	"fmt"
 	"log"
 	"math"
 	"time"
@@ -46,7 +45,7 @@ func MeanMotionFullOrbit(orbit *Orbit, count int64) []*Orbit {
 MeanMotionToDate calculates the mean motion value for a defined date.
 */
 func MeanMotionToDate(orbit *Orbit, d time.Time) *Orbit {
-	duration := orbit.Epoch.Sub(d)
+	duration := d.Sub(orbit.Epoch)
 	return MeanMotion(orbit, duration)
 }
 
@@ -54,7 +53,7 @@ func MeanMotionToDate(orbit *Orbit, d time.Time) *Orbit {
 MeanMotion uses the mean motion method to propagate [orbit] through [t] seconds .
 */
 func MeanMotion(orbit *Orbit, t time.Duration) *Orbit {
-	p := orbit.SemimajorAxis * (1 - math.Pow(orbit.OrbitalEccentricity, 2))
+	p := semiLatusRectum(orbit)
 	m0 := createM0(orbit)
 	var newMeanAnomalyEpoch float64
 	if math.Abs(orbit.OrbitalEccentricity-1) > delta {
@@ -74,6 +73,14 @@ func MeanMotion(orbit *Orbit, t time.Duration) *Orbit {
 	return r
 }
 
+/*
+MeanToTrueAnomaly converts a mean anomaly in radians into the true anomaly that MeanAnomalyEpoch holds, using the
+eccentricity of orbit.
+*/
+func MeanToTrueAnomaly(m float64, orbit *Orbit) float64 {
+	return mtoMeanAnomaly(m, orbit)
+}
+
 const delta = 1e-3
 const tolerance = 1e-16
 
@@ -101,7 +108,7 @@ func mtoMeanAnomaly(m float64, orbit *Orbit) float64 {
 		return 2 * math.Atan(math.Sqrt((1+orbit.OrbitalEccentricity)/(1-orbit.OrbitalEccentricity))*math.Tan(e/2))
 	} else {
 		b := 3.0 * m / 2.0
-		a := (b + (1 + math.Pow(math.Pow(math.Pow(b, 2), 0.5), (2.0/3.0))))
+		a := math.Pow(b+math.Sqrt(1+math.Pow(b, 2)), 2.0/3.0)
 
 		guess := 2 * a * b / (1 + a + math.Pow(a, 2))
 		d := newtonKeplerParabolic(guess, m, orbit.OrbitalEccentricity)
@@ -117,7 +124,6 @@ func keplerParabolic(orbitalEccentricity float64, d float64) float64 {
 	done := false
 	s := 0.0
 	k := 0.0
-	fmt.Println(orbitalEccentricity, ",", d)
 	for !done {
 		term := (orbitalEccentricity - 1.0/(2.0*k+3.0)) * math.Pow(x, k)
 		done = math.Abs(term) < tolerance
@@ -142,7 +148,7 @@ func keplerParabolicPrime(orbitalEccentricity float64, d float64) float64 {
 		k++
 	}
 
-	return math.Sqrt(2.0/(1.0+orbitalEccentricity))*d + math.Sqrt(2.0/math.Pow((1.0+orbitalEccentricity), 3))*math.Pow(d, 2)*s
+	return math.Sqrt(2.0/(1.0+orbitalEccentricity)) + math.Sqrt(2.0/math.Pow((1.0+orbitalEccentricity), 3))*math.Pow(d, 2)*s
 }
 
 func keplerHyper(orbitalEccentricity float64, d float64) float64 {
@@ -1,7 +1,9 @@
 package orbcore
 
 import (
+	"math"
 	"testing"
+	"time"
 )
 
 func TestLoopingProblem(t *testing.T) {
@@ -21,3 +23,57 @@ func TestLoopingProblem(t *testing.T) {
 
 	_ = MeanMotion(&orb, 1*24*60*60)
 }
+
+func TestParabolicMeanMotion(t *testing.T) {
+	// Distances from the sun worked out with Barker's equation for a parabolic orbit with q = 0.253 AU
+	const au = 149598000
+	e := 1 - 1e-10
+	q := 0.253 * au
+	orb := Orbit{
+		ID:                  "parabolic",
+		ParentGrav:          132712442099.00002,
+		OrbitalEccentricity: e,
+		SemimajorAxis:       q / (1 - e),
+	}
+
+	cases := []struct {
+		days     float64
+		distance float64
+	}{
+		{-30, 0.878351378719961},
+		{1, 0.25529755232249557},
+		{30, 0.878351378719961},
+		{300, 4.69071341471529},
+	}
+
+	for _, c := range cases {
+		p := OrbitToPosition(MeanMotion(&orb, time.Duration(c.days*24*float64(time.Hour))))
+		d := math.Sqrt(p.X*p.X+p.Y*p.Y+p.Z*p.Z) / au
+		if math.Abs(d-c.distance) > 1e-8 {
+			t.Errorf("%v days: expected %v AU got %v", c.days, c.distance, d)
+		}
+	}
+}
+
+func TestMeanMotionToDate(t *testing.T) {
+	orb := Orbit{
+		ID:                  "circular",
+		ParentGrav:          132712442099.00002,
+		Epoch:               time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		OrbitalEccentricity: 0.1,
+		SemimajorAxis:       2 * 149598000,
+	}
+	target := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	r := MeanMotionToDate(&orb, target)
+	if !r.Epoch.Equal(target) {
+		t.Errorf("expected epoch %v got %v", target, r.Epoch)
+	}
+	if r.MeanAnomalyEpoch <= 0 {
+		t.Errorf("expected the object to have moved forward got %v", r.MeanAnomalyEpoch)
+	}
+	// it used to move the orbit back by the same amount
+	if forward := MeanMotion(&orb, target.Sub(orb.Epoch)); r.MeanAnomalyEpoch != forward.MeanAnomalyEpoch {
+		t.Errorf("expected mean anomaly %v got %v", forward.MeanAnomalyEpoch, r.MeanAnomalyEpoch)
+	}
+}
@@ -0,0 +1,112 @@
+package orbcore
+
+import (
+	"math"
+	"time"
+)
+
+// julianUnixEpoch is the julian date of the unix epoch 1970-01-01T00:00:00Z
+const julianUnixEpoch = 2440587.5
+
+const secondsPerDay = 24 * 60 * 60
+
+/*
+JulianDate converts a time into a julian date. No leap second or time scale corrections are applied.
+*/
+func JulianDate(t time.Time) float64 {
+	return julianUnixEpoch + (float64(t.Unix())+float64(t.Nanosecond())/1e9)/secondsPerDay
+}
+
+/*
+TimeFromJulianDate converts a julian date back into a UTC time.
+*/
+func TimeFromJulianDate(jd float64) time.Time {
+	days := jd - julianUnixEpoch
+	seconds := days * secondsPerDay
+	whole := int64(seconds)
+	if float64(whole) > seconds {
+		whole--
+	}
+	nano := int64((seconds - float64(whole)) * 1e9)
+	return time.Unix(whole, nano).UTC().Round(time.Microsecond)
+}
+
+// leapSeconds lists when the difference between TAI and UTC changed and what it changed to.
+var leapSeconds = []struct {
+	from   time.Time
+	offset float64
+}{
+	{time.Date(1972, 1, 1, 0, 0, 0, 0, time.UTC), 10},
+	{time.Date(1972, 7, 1, 0, 0, 0, 0, time.UTC), 11},
+	{time.Date(1973, 1, 1, 0, 0, 0, 0, time.UTC), 12},
+	{time.Date(1974, 1, 1, 0, 0, 0, 0, time.UTC), 13},
+	{time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC), 14},
+	{time.Date(1976, 1, 1, 0, 0, 0, 0, time.UTC), 15},
+	{time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC), 16},
+	{time.Date(1978, 1, 1, 0, 0, 0, 0, time.UTC), 17},
+	{time.Date(1979, 1, 1, 0, 0, 0, 0, time.UTC), 18},
+	{time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), 19},
+	{time.Date(1981, 7, 1, 0, 0, 0, 0, time.UTC), 20},
+	{time.Date(1982, 7, 1, 0, 0, 0, 0, time.UTC), 21},
+	{time.Date(1983, 7, 1, 0, 0, 0, 0, time.UTC), 22},
+	{time.Date(1985, 7, 1, 0, 0, 0, 0, time.UTC), 23},
+	{time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC), 24},
+	{time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), 25},
+	{time.Date(1991, 1, 1, 0, 0, 0, 0, time.UTC), 26},
+	{time.Date(1992, 7, 1, 0, 0, 0, 0, time.UTC), 27},
+	{time.Date(1993, 7, 1, 0, 0, 0, 0, time.UTC), 28},
+	{time.Date(1994, 7, 1, 0, 0, 0, 0, time.UTC), 29},
+	{time.Date(1996, 1, 1, 0, 0, 0, 0, time.UTC), 30},
+	{time.Date(1997, 7, 1, 0, 0, 0, 0, time.UTC), 31},
+	{time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC), 32},
+	{time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC), 33},
+	{time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC), 34},
+	{time.Date(2012, 7, 1, 0, 0, 0, 0, time.UTC), 35},
+	{time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC), 36},
+	{time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), 37},
+}
+
+// taiMinusUTC returns the number of seconds TAI was ahead of UTC at time t. Times before 1972 use the 1972 value.
+func taiMinusUTC(t time.Time) float64 {
+	offset := leapSeconds[0].offset
+	for _, l := range leapSeconds {
+		if t.Before(l.from) {
+			break
+		}
+		offset = l.offset
+	}
+	return offset
+}
+
+// j2000Unix is the unix time of 2000-01-01T12:00:00 without any time scale corrections.
+const j2000Unix = 946728000
+
+/*
+EphemerisTime converts a UTC time into seconds past J2000 in barycentric dynamical time (TDB), the time scale JPL
+ephemeris files use. Leap seconds are applied from 1972 onwards and the main periodic term of TDB-TT is included, so
+the result is good to a few tens of microseconds.
+*/
+func EphemerisTime(t time.Time) float64 {
+	utc := float64(t.Unix()-j2000Unix) + float64(t.Nanosecond())/1e9
+	tt := utc + taiMinusUTC(t) + 32.184
+	return tt + tdbMinusTT(tt)
+}
+
+/*
+TimeFromEphemerisTime converts seconds past J2000 TDB back into a UTC time.
+*/
+func TimeFromEphemerisTime(et float64) time.Time {
+	tt := et - tdbMinusTT(et)
+	utc := tt - 32.184 - taiMinusUTC(fromJ2000Seconds(tt))
+	utc = tt - 32.184 - taiMinusUTC(fromJ2000Seconds(utc))
+	return fromJ2000Seconds(utc)
+}
+
+func fromJ2000Seconds(s float64) time.Time {
+	return TimeFromJulianDate(2451545.0 + s/secondsPerDay)
+}
+
+func tdbMinusTT(tt float64) float64 {
+	g := (357.53 + 0.98560028*tt/secondsPerDay) * math.Pi / 180
+	return 0.001657*math.Sin(g) + 0.000014*math.Sin(2*g)
+}
@@ -0,0 +1,48 @@
+package orbcore
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestJulianDate(t *testing.T) {
+	cases := []struct {
+		t  time.Time
+		jd float64
+	}{
+		{time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC), 2451545.0},
+		{time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), 2440587.5},
+		{time.Date(2019, 1, 1, 6, 0, 0, 0, time.UTC), 2458484.75},
+		{time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC), 2305447.5},
+	}
+
+	for _, c := range cases {
+		r := JulianDate(c.t)
+		if math.Abs(r-c.jd) > 1e-9 {
+			t.Errorf("%v: expected %v got %v", c.t, c.jd, r)
+		}
+
+		back := TimeFromJulianDate(c.jd)
+		if !back.Equal(c.t) {
+			t.Errorf("%v: expected %v got %v", c.jd, c.t, back)
+		}
+	}
+}
+
+func TestEphemerisTime(t *testing.T) {
+	if et := EphemerisTime(time.Date(2000, 1, 1, 11, 58, 55, 816000000, time.UTC)); math.Abs(et) > 1e-4 {
+		t.Errorf("expected J2000 to be 0 seconds got %v", et)
+	}
+
+	// 2017-01-01 is the first day with 37 leap seconds, 69.184 seconds between UTC and TT.
+	when := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := float64(when.Unix()-946728000) + 69.184
+	if et := EphemerisTime(when); math.Abs(et-expected) > 0.002 {
+		t.Errorf("expected %v got %v", expected, et)
+	}
+
+	if back := TimeFromEphemerisTime(EphemerisTime(when)); back.Sub(when) > time.Microsecond || when.Sub(back) > time.Microsecond {
+		t.Errorf("expected %v got %v", when, back)
+	}
+}
@@ -0,0 +1,529 @@
+package orbdata
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"gonum.org/v1/gonum/mat"
+)
+
+const deg = math.Pi / 180.0
+const day = 86400.0
+
+/*
+Body describes a major body in the solar system along with where it sits in the hierarchy of bodies.
+
+The pole is given as the right ascension and declination of the north pole in the J2000 equatorial frame, zero when
+it is not known. A negative RotationRate means the body spins retrograde. Orbit is relative to the parent body and is
+nil for the sun and for bodies whose orbit is not known here. It is referenced to the ecliptic unless Equatorial is
+set, in which case it is referenced to the equator of the parent. Elements, when set, replace Orbit with elements
+that change over time. Children is filled in from the registry.
+*/
+type Body struct {
+	Name               string
+	NAIF               int     // NAIF integer ID used by JPL ephemeris files
+	GM                 float64 // km^3 s^-2
+	Radius             float64 // mean radius in km
+	PoleRightAscension float64 // rad
+	PoleDeclination    float64 // rad
+	RotationRate       float64 // rad/s
+	J2                 float64
+	Parent             *Body
+	Orbit              *orbcore.Orbit
+	Equatorial         bool
+	Elements           *PlanetElements
+	Children           []*Body
+}
+
+/*
+Sun is the root of the body tree.
+*/
+var Sun = &Body{
+	Name:               "Sun",
+	NAIF:               10,
+	GM:                 SunGrav,
+	Radius:             695700,
+	PoleRightAscension: 286.13 * deg,
+	PoleDeclination:    63.87 * deg,
+	RotationRate:       2 * math.Pi / (25.38 * day),
+	J2:                 2.2e-7,
+}
+
+// Mercury is the body for the planet Mercury
+var Mercury = &Body{
+	Name:               "Mercury",
+	NAIF:               199,
+	GM:                 MercuryGrav,
+	Radius:             2439.4,
+	PoleRightAscension: 281.0103 * deg,
+	PoleDeclination:    61.4155 * deg,
+	RotationRate:       2 * math.Pi / (58.6462 * day),
+	J2:                 5.03e-5,
+	Parent:             Sun,
+	Orbit:              &MercuryOrbit,
+	Elements:           &MercuryElements,
+}
+
+// Venus is the body for the planet Venus
+var Venus = &Body{
+	Name:               "Venus",
+	NAIF:               299,
+	GM:                 VenusGrav,
+	Radius:             6051.8,
+	PoleRightAscension: 272.76 * deg,
+	PoleDeclination:    67.16 * deg,
+	RotationRate:       -2 * math.Pi / (243.025 * day),
+	J2:                 4.458e-6,
+	Parent:             Sun,
+	Orbit:              &VenusOrbit,
+	Elements:           &VenusElements,
+}
+
+// Earth is the body for the planet Earth
+var Earth = &Body{
+	Name:               "Earth",
+	NAIF:               399,
+	GM:                 EarthGrav,
+	Radius:             6371.0084,
+	PoleRightAscension: 0,
+	PoleDeclination:    90 * deg,
+	RotationRate:       7.292115e-5,
+	J2:                 1.08262668e-3,
+	Parent:             Sun,
+	Orbit:              &EarthOrbit,
+	Elements:           &EarthElements,
+}
+
+// Moon is the body for the Earth's moon
+var Moon = &Body{
+	Name:               "Moon",
+	NAIF:               301,
+	GM:                 MoonGrav,
+	Radius:             1737.4,
+	PoleRightAscension: 269.9949 * deg,
+	PoleDeclination:    66.5392 * deg,
+	RotationRate:       2 * math.Pi / (27.321661 * day),
+	J2:                 2.034e-4,
+	Parent:             Earth,
+	Orbit:              &MoonOrbit,
+}
+
+// Mars is the body for the planet Mars
+var Mars = &Body{
+	Name:               "Mars",
+	NAIF:               499,
+	GM:                 MarsGrav,
+	Radius:             3389.5,
+	PoleRightAscension: 317.269202 * deg,
+	PoleDeclination:    54.432516 * deg,
+	RotationRate:       2 * math.Pi / (1.02595676 * day),
+	J2:                 1.96045e-3,
+	Parent:             Sun,
+	Orbit:              &MarsOrbit,
+	Elements:           &MarsElements,
+}
+
+// Jupiter is the body for the planet Jupiter
+var Jupiter = &Body{
+	Name:               "Jupiter",
+	NAIF:               599,
+	GM:                 JupiterGrav,
+	Radius:             69911,
+	PoleRightAscension: 268.056595 * deg,
+	PoleDeclination:    64.495303 * deg,
+	RotationRate:       2 * math.Pi / (0.41354 * day),
+	J2:                 1.46965e-2,
+	Parent:             Sun,
+	Orbit:              &JupiterOrbit,
+	Elements:           &JupiterElements,
+}
+
+// Saturn is the body for the planet Saturn
+var Saturn = &Body{
+	Name:               "Saturn",
+	NAIF:               699,
+	GM:                 SaturnGrav,
+	Radius:             58232,
+	PoleRightAscension: 40.589 * deg,
+	PoleDeclination:    83.537 * deg,
+	RotationRate:       2 * math.Pi / (0.44401 * day),
+	J2:                 1.62907e-2,
+	Parent:             Sun,
+	Orbit:              &SaturnOrbit,
+	Elements:           &SaturnElements,
+}
+
+// Uranus is the body for the planet Uranus
+var Uranus = &Body{
+	Name:               "Uranus",
+	NAIF:               799,
+	GM:                 UranusGrav,
+	Radius:             25362,
+	PoleRightAscension: 257.311 * deg,
+	PoleDeclination:    -15.175 * deg,
+	RotationRate:       -2 * math.Pi / (0.71833 * day),
+	J2:                 3.51068e-3,
+	Parent:             Sun,
+	Orbit:              &UranusOrbit,
+	Elements:           &UranusElements,
+}
+
+// Neptune is the body for the planet Neptune
+var Neptune = &Body{
+	Name:               "Neptune",
+	NAIF:               899,
+	GM:                 NeptuneGrav,
+	Radius:             24622,
+	PoleRightAscension: 299.36 * deg,
+	PoleDeclination:    43.46 * deg,
+	RotationRate:       2 * math.Pi / (0.6713 * day),
+	J2:                 3.40843e-3,
+	Parent:             Sun,
+	Orbit:              &NeptuneOrbit,
+	Elements:           &NeptuneElements,
+}
+
+// Phobos is the body for the inner moon of Mars
+var Phobos = &Body{
+	Name:               "Phobos",
+	NAIF:               401,
+	GM:                 PhobosGrav,
+	Radius:             11.08,
+	PoleRightAscension: 317.68 * deg,
+	PoleDeclination:    52.90 * deg,
+	RotationRate:       2 * math.Pi / (0.31891023 * day),
+	Parent:             Mars,
+	Orbit:              &PhobosOrbit,
+	Equatorial:         true,
+}
+
+// Deimos is the body for the outer moon of Mars
+var Deimos = &Body{
+	Name:               "Deimos",
+	NAIF:               402,
+	GM:                 DeimosGrav,
+	Radius:             6.2,
+	PoleRightAscension: 316.65 * deg,
+	PoleDeclination:    53.52 * deg,
+	RotationRate:       2 * math.Pi / (1.263 * day),
+	Parent:             Mars,
+	Orbit:              &DeimosOrbit,
+	Equatorial:         true,
+}
+
+// Io is the body for the innermost Galilean moon of Jupiter
+var Io = &Body{
+	Name:               "Io",
+	NAIF:               501,
+	GM:                 IoGrav,
+	Radius:             1821.6,
+	PoleRightAscension: 268.05 * deg,
+	PoleDeclination:    64.50 * deg,
+	RotationRate:       2 * math.Pi / (1.769138 * day),
+	Parent:             Jupiter,
+	Orbit:              &IoOrbit,
+	Equatorial:         true,
+}
+
+// Europa is the body for the Galilean moon Europa
+var Europa = &Body{
+	Name:               "Europa",
+	NAIF:               502,
+	GM:                 EuropaGrav,
+	Radius:             1560.8,
+	PoleRightAscension: 268.08 * deg,
+	PoleDeclination:    64.51 * deg,
+	RotationRate:       2 * math.Pi / (3.551181 * day),
+	Parent:             Jupiter,
+	Orbit:              &EuropaOrbit,
+	Equatorial:         true,
+}
+
+// Ganymede is the body for the Galilean moon Ganymede
+var Ganymede = &Body{
+	Name:               "Ganymede",
+	NAIF:               503,
+	GM:                 GanymedeGrav,
+	Radius:             2631.2,
+	PoleRightAscension: 268.20 * deg,
+	PoleDeclination:    64.57 * deg,
+	RotationRate:       2 * math.Pi / (7.154553 * day),
+	Parent:             Jupiter,
+	Orbit:              &GanymedeOrbit,
+	Equatorial:         true,
+}
+
+// Callisto is the body for the outermost Galilean moon of Jupiter
+var Callisto = &Body{
+	Name:               "Callisto",
+	NAIF:               504,
+	GM:                 CallistoGrav,
+	Radius:             2410.3,
+	PoleRightAscension: 268.72 * deg,
+	PoleDeclination:    64.83 * deg,
+	RotationRate:       2 * math.Pi / (16.689018 * day),
+	Parent:             Jupiter,
+	Orbit:              &CallistoOrbit,
+	Equatorial:         true,
+}
+
+// Titan is the body for the largest moon of Saturn
+var Titan = &Body{
+	Name:               "Titan",
+	NAIF:               606,
+	GM:                 TitanGrav,
+	Radius:             2574.7,
+	PoleRightAscension: 39.4827 * deg,
+	PoleDeclination:    83.4279 * deg,
+	RotationRate:       2 * math.Pi / (15.945421 * day),
+	Parent:             Saturn,
+	Orbit:              &TitanOrbit,
+	Equatorial:         true,
+}
+
+// Triton is the body for the largest moon of Neptune
+var Triton = &Body{
+	Name:               "Triton",
+	NAIF:               801,
+	GM:                 TritonGrav,
+	Radius:             1353.4,
+	PoleRightAscension: 299.36 * deg,
+	PoleDeclination:    41.17 * deg,
+	RotationRate:       -2 * math.Pi / (5.876854 * day),
+	Parent:             Neptune,
+	Orbit:              &TritonOrbit,
+	Equatorial:         true,
+}
+
+// Ceres is the body for the dwarf planet Ceres
+var Ceres = &Body{
+	Name:               "Ceres",
+	NAIF:               2000001,
+	GM:                 CeresGrav,
+	Radius:             469.7,
+	PoleRightAscension: 291.418 * deg,
+	PoleDeclination:    66.764 * deg,
+	RotationRate:       2 * math.Pi / (9.074170 * 3600),
+	J2:                 2.675e-2,
+	Parent:             Sun,
+	Orbit:              &CeresOrbit,
+}
+
+// Vesta is the body for the asteroid Vesta
+var Vesta = &Body{
+	Name:               "Vesta",
+	NAIF:               2000004,
+	GM:                 VestaGrav,
+	Radius:             262.7,
+	PoleRightAscension: 309.031 * deg,
+	PoleDeclination:    42.235 * deg,
+	RotationRate:       2 * math.Pi / (5.342128 * 3600),
+	J2:                 3.17e-2,
+	Parent:             Sun,
+	Orbit:              &VestaOrbit,
+}
+
+// Pluto is the body for the dwarf planet Pluto
+var Pluto = &Body{
+	Name:               "Pluto",
+	NAIF:               999,
+	GM:                 PlutoGrav,
+	Radius:             1188.3,
+	PoleRightAscension: 132.993 * deg,
+	PoleDeclination:    -6.163 * deg,
+	RotationRate:       -2 * math.Pi / (6.387230 * day),
+	Parent:             Sun,
+	Orbit:              &PlutoOrbit,
+	Elements:           &PlutoElements,
+}
+
+// Haumea is the body for the dwarf planet Haumea
+var Haumea = &Body{
+	Name:         "Haumea",
+	NAIF:         2136108,
+	GM:           HaumeaGrav,
+	Radius:       798,
+	RotationRate: 2 * math.Pi / (3.9155 * 3600),
+	Parent:       Sun,
+	Orbit:        &HaumeaOrbit,
+}
+
+// Makemake is the body for the dwarf planet Makemake
+var Makemake = &Body{
+	Name:         "Makemake",
+	NAIF:         2136472,
+	GM:           MakemakeGrav,
+	Radius:       715,
+	RotationRate: 2 * math.Pi / (22.83 * 3600),
+	Parent:       Sun,
+	Orbit:        &MakemakeOrbit,
+}
+
+// Eris is the body for the dwarf planet Eris
+var Eris = &Body{
+	Name:         "Eris",
+	NAIF:         2136199,
+	GM:           ErisGrav,
+	Radius:       1163,
+	RotationRate: 2 * math.Pi / (15.786 * day),
+	Parent:       Sun,
+	Orbit:        &ErisOrbit,
+}
+
+// bodies lists everything in the registry. Parents must come before their children.
+var bodies = []*Body{
+	Sun,
+	Mercury,
+	Venus,
+	Earth,
+	Moon,
+	Mars,
+	Phobos,
+	Deimos,
+	Jupiter,
+	Io,
+	Europa,
+	Ganymede,
+	Callisto,
+	Saturn,
+	Titan,
+	Uranus,
+	Neptune,
+	Triton,
+	Ceres,
+	Vesta,
+	Pluto,
+	Haumea,
+	Makemake,
+	Eris,
+}
+
+var bodyIndex map[string]*Body
+
+func init() {
+	bodyIndex = make(map[string]*Body, len(bodies))
+	for _, b := range bodies {
+		bodyIndex[strings.ToLower(b.Name)] = b
+		if b.Parent != nil {
+			b.Parent.Children = append(b.Parent.Children, b)
+		}
+	}
+}
+
+/*
+LookupBody finds a body by name. Names are not case sensitive.
+*/
+func LookupBody(name string) (*Body, bool) {
+	b, ok := bodyIndex[strings.ToLower(strings.TrimSpace(name))]
+	return b, ok
+}
+
+/*
+LookupBodyByGM finds the body whose gravitational parameter is closest to gm, if it is within 2%. The margin lets
+the combined GM of a planet and a moon, as used by the moon orbits, or a GM from another set of constants find the
+body.
+*/
+func LookupBodyByGM(gm float64) (*Body, bool) {
+	var result *Body
+	best := 0.02
+	for _, b := range bodies {
+		if b.GM <= 0 {
+			continue
+		}
+		if d := math.Abs(gm/b.GM - 1); d <= best {
+			result, best = b, d
+		}
+	}
+	return result, result != nil
+}
+
+/*
+Bodies returns every body in the registry, parents before their children.
+*/
+func Bodies() []*Body {
+	result := make([]*Body, len(bodies))
+	copy(result, bodies)
+	return result
+}
+
+/*
+SphereOfInfluence returns the Laplace sphere of influence radius of the body in km, a(m/M)^(2/5). It is zero for the
+sun and for bodies without an orbit.
+*/
+func (b *Body) SphereOfInfluence() float64 {
+	if b.Parent == nil || b.Orbit == nil {
+		return 0
+	}
+	return math.Abs(b.Orbit.SemimajorAxis) * math.Pow(b.GM/b.Parent.GM, 2.0/5.0)
+}
+
+/*
+HillSphere returns the radius of the Hill sphere of the body in km, a(1-e)(m/3M)^(1/3). It is zero for the sun and
+for bodies without an orbit.
+*/
+func (b *Body) HillSphere() float64 {
+	if b.Parent == nil || b.Orbit == nil {
+		return 0
+	}
+	return math.Abs(b.Orbit.SemimajorAxis) * (1 - b.Orbit.OrbitalEccentricity) * math.Cbrt(b.GM/(3*b.Parent.GM))
+}
+
+/*
+HeliocentricPosition returns the position of the body relative to the sun at time t in the J2000 ecliptic frame, in
+km. The orbits of the body and each of its parents at t are added together. The Earth uses the elements of the
+Earth-Moon barycenter so it is out by up to about 4700 km.
+*/
+func (b *Body) HeliocentricPosition(t time.Time) *orbcore.Position {
+	r := b.heliocentric(t)
+	return &orbcore.Position{
+		ID:    b.Name,
+		Epoch: t,
+		X:     r.AtVec(0),
+		Y:     r.AtVec(1),
+		Z:     r.AtVec(2),
+	}
+}
+
+/*
+OrbitAt returns the orbit of the body around its parent at time t. Bodies with Elements use them, which is good from
+3000 BC to 3000 AD, others have their fixed Orbit propagated to t. It is nil when the body has no orbit.
+*/
+func (b *Body) OrbitAt(t time.Time) *orbcore.Orbit {
+	if b.Elements != nil {
+		return b.Elements.OrbitAt(t)
+	}
+	if b.Orbit == nil {
+		return nil
+	}
+	return orbcore.MeanMotionToDate(b.Orbit, t)
+}
+
+func (b *Body) heliocentric(t time.Time) *mat.VecDense {
+	if b.Parent == nil || b.Orbit == nil {
+		return mat.NewVecDense(3, nil)
+	}
+
+	r, _ := orbcore.OrbitToVector(b.OrbitAt(t))
+	local := mat.VecDenseCopyOf(r)
+	if b.Equatorial {
+		local = b.Parent.equatorToEcliptic(local)
+	}
+
+	local.AddVec(local, b.Parent.heliocentric(t))
+	return local
+}
+
+// equatorToEcliptic rotates a vector from the equatorial frame of the body into the J2000 ecliptic. The equator
+// crosses the J2000 equator at a right ascension of 90 degrees past the pole, tilted by 90 degrees less the pole
+// declination.
+func (b *Body) equatorToEcliptic(r *mat.VecDense) *mat.VecDense {
+	orbcore.Rotate(r, math.Pi/2-b.PoleDeclination, orbcore.AxisX)
+	orbcore.Rotate(r, b.PoleRightAscension+math.Pi/2, orbcore.AxisZ)
+	return orbcore.Rotate(r, -Obliquity, orbcore.AxisX)
+}
+
+func (b *Body) String() string {
+	return b.Name
+}
@@ -0,0 +1,139 @@
+package orbdata
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLookupBody(t *testing.T) {
+	earth, ok := LookupBody("earth")
+	if !ok {
+		t.Fatal("could not find the earth")
+	}
+	if earth.GM != EarthGrav || earth.Orbit != &EarthOrbit || earth.Parent != Sun {
+		t.Errorf("unexpected earth %+v", earth)
+	}
+
+	if _, ok := LookupBody("Vulcan"); ok {
+		t.Errorf("did not expect to find Vulcan")
+	}
+}
+
+func TestLookupBodyByGM(t *testing.T) {
+	for _, b := range Bodies() {
+		if found, ok := LookupBodyByGM(b.GM); b.GM > 0 && found != b {
+			t.Errorf("%v: found %v %v", b.Name, found, ok)
+		}
+	}
+	if earth, ok := LookupBodyByGM(EarthGrav + MoonGrav); !ok || earth != Earth {
+		t.Errorf("expected the earth for the earth moon GM got %v", earth)
+	}
+	if b, ok := LookupBodyByGM(1); ok {
+		t.Errorf("did not expect a body for a GM of 1 got %v", b)
+	}
+}
+
+func TestBodyTree(t *testing.T) {
+	if Sun.Parent != nil {
+		t.Errorf("expected the sun to be the root")
+	}
+	if len(Sun.Children) != 14 {
+		t.Errorf("expected the planets, dwarf planets and Vesta got %v", Sun.Children)
+	}
+	if len(Earth.Children) != 1 || Earth.Children[0] != Moon {
+		t.Errorf("expected the moon to orbit the earth got %v", Earth.Children)
+	}
+
+	for _, b := range Bodies() {
+		if b.Parent == nil && b != Sun {
+			t.Errorf("%v has no parent", b)
+		}
+		// Moons use the combined GM of the pair.
+		if b.Orbit != nil && b.Orbit.ParentGrav != b.Parent.GM && math.Abs(b.Orbit.ParentGrav-b.Parent.GM-b.GM) > 1e-6 {
+			t.Errorf("%v orbit has the wrong parent gravity", b)
+		}
+	}
+}
+
+func TestSphereOfInfluence(t *testing.T) {
+	// Earth has a sphere of influence of about 925000 km and a hill sphere of about 1.5 million km.
+	if soi := Earth.SphereOfInfluence(); math.Abs(soi-925000)/925000 > 0.01 {
+		t.Errorf("unexpected earth sphere of influence %v", soi)
+	}
+	if hill := Earth.HillSphere(); math.Abs(hill-1.47e6)/1.47e6 > 0.02 {
+		t.Errorf("unexpected earth hill sphere %v", hill)
+	}
+	if Sun.SphereOfInfluence() != 0 || Sun.HillSphere() != 0 {
+		t.Errorf("expected the sun to have no sphere of influence")
+	}
+}
+
+func distance(a, b *orbcore.Position) float64 {
+	return math.Sqrt(math.Pow(a.X-b.X, 2) + math.Pow(a.Y-b.Y, 2) + math.Pow(a.Z-b.Z, 2))
+}
+
+func TestMoonHeliocentricPosition(t *testing.T) {
+	when := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+	earth := Earth.HeliocentricPosition(when)
+	moon := Moon.HeliocentricPosition(when)
+
+	if d := distance(earth, moon); d < 356000 || d > 407000 {
+		t.Errorf("expected the moon to be about 384000 km from the earth got %v", d)
+	}
+
+	// After one sidereal month the moon should be back in about the same place relative to the earth.
+	later := when.Add(time.Duration(27.321661 * 24 * float64(time.Hour)))
+	r1 := orbcore.OrbitToPosition(orbcore.MeanMotionToDate(&MoonOrbit, when))
+	r2 := orbcore.OrbitToPosition(orbcore.MeanMotionToDate(&MoonOrbit, later))
+	if d := distance(r1, r2); d > 5000 {
+		t.Errorf("expected the moon to complete an orbit in a sidereal month, %v km away", d)
+	}
+}
+
+func TestGalileanMoonsInJupitersEquator(t *testing.T) {
+	when := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+	jupiter := Jupiter.HeliocentricPosition(when)
+
+	// Jupiter's pole in the ecliptic frame.
+	pole := mat.NewVecDense(3, []float64{
+		math.Cos(Jupiter.PoleDeclination) * math.Cos(Jupiter.PoleRightAscension),
+		math.Cos(Jupiter.PoleDeclination) * math.Sin(Jupiter.PoleRightAscension),
+		math.Sin(Jupiter.PoleDeclination),
+	})
+	orbcore.Rotate(pole, -Obliquity, orbcore.AxisX)
+
+	for _, moon := range Jupiter.Children {
+		p := moon.HeliocentricPosition(when)
+		r := mat.NewVecDense(3, []float64{p.X - jupiter.X, p.Y - jupiter.Y, p.Z - jupiter.Z})
+		d := mat.Norm(r, 2)
+		if math.Abs(d-moon.Orbit.SemimajorAxis)/moon.Orbit.SemimajorAxis > 0.02 {
+			t.Errorf("%v is %v km from Jupiter", moon, d)
+		}
+		if lat := math.Asin(mat.Dot(r, pole)/d) / deg; math.Abs(lat) > 0.5 {
+			t.Errorf("expected %v to be close to the equator got %v degrees", moon, lat)
+		}
+	}
+}
+
+func TestDwarfPlanetDistances(t *testing.T) {
+	cases := []struct {
+		body     *Body
+		min, max float64
+	}{
+		{Pluto, 29.6, 30.8},
+		{Ceres, 2.55, 2.98},
+		{Vesta, 2.15, 2.58},
+		{Eris, 37.8, 97.6},
+	}
+	for _, c := range cases {
+		p := c.body.HeliocentricPosition(J2000Noon)
+		d := math.Sqrt(p.X*p.X+p.Y*p.Y+p.Z*p.Z) / AU
+		if d < c.min || d > c.max {
+			t.Errorf("expected %v to be between %v and %v AU in 2000 got %v", c.body, c.min, c.max, d)
+		}
+	}
+}
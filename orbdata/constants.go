@@ -6,6 +6,7 @@ In here you will find Gravitational constants of the major planets
 package orbdata
 
 import (
+	"math"
 	"time"
 )
 
@@ -41,6 +42,48 @@ const UranusGrav = 5793939.300000001
 // NeptuneGrav gravitational constant for Neptune centric orbits (km^3)/(s^-2)
 const NeptuneGrav = 6836527.100580397
 
+// PlutoGrav gravitational constant for Pluto centric orbits (km^3)/(s^-2)
+const PlutoGrav = 869.6
+
+// CeresGrav gravitational constant for Ceres centric orbits (km^3)/(s^-2)
+const CeresGrav = 62.6284
+
+// VestaGrav gravitational constant for Vesta centric orbits (km^3)/(s^-2)
+const VestaGrav = 17.288
+
+// ErisGrav gravitational constant for Eris centric orbits (km^3)/(s^-2)
+const ErisGrav = 1108
+
+// HaumeaGrav gravitational constant for Haumea centric orbits (km^3)/(s^-2)
+const HaumeaGrav = 267.4
+
+// MakemakeGrav gravitational constant for Makemake centric orbits (km^3)/(s^-2), estimated from its moon.
+const MakemakeGrav = 207
+
+// PhobosGrav gravitational constant for Phobos centric orbits (km^3)/(s^-2)
+const PhobosGrav = 7.087e-4
+
+// DeimosGrav gravitational constant for Deimos centric orbits (km^3)/(s^-2)
+const DeimosGrav = 9.62e-5
+
+// IoGrav gravitational constant for Io centric orbits (km^3)/(s^-2)
+const IoGrav = 5959.916
+
+// EuropaGrav gravitational constant for Europa centric orbits (km^3)/(s^-2)
+const EuropaGrav = 3202.739
+
+// GanymedeGrav gravitational constant for Ganymede centric orbits (km^3)/(s^-2)
+const GanymedeGrav = 9887.834
+
+// CallistoGrav gravitational constant for Callisto centric orbits (km^3)/(s^-2)
+const CallistoGrav = 7179.289
+
+// TitanGrav gravitational constant for Titan centric orbits (km^3)/(s^-2)
+const TitanGrav = 8978.14
+
+// TritonGrav gravitational constant for Triton centric orbits (km^3)/(s^-2)
+const TritonGrav = 1427.6
+
 /*
 AU represents the length of an Astronomical Unit in KiloMeters
 */
@@ -50,3 +93,19 @@ const AU = 149598000
 J2000 is the base time epoch of a lot of astronomical times.
 */
 var J2000 = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+/*
+EarthRadius is the equatorial radius of the earth in KiloMeters (WGS84)
+*/
+const EarthRadius = 6378.137
+
+/*
+Obliquity is the angle between the equator and the ecliptic at J2000 in radians (IAU 1976)
+*/
+const Obliquity = 23.4392911 * math.Pi / 180.0
+
+/*
+J2000Noon is the J2000.0 epoch itself, noon TT on the first of January 2000, given in UTC. Element tables that are
+quoted "at J2000" mean this instant rather than midnight.
+*/
+var J2000Noon = time.Date(2000, 1, 1, 11, 58, 55, 816000000, time.UTC)
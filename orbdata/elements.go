@@ -0,0 +1,294 @@
+package orbdata
+
+import (
+	"math"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+PlanetElements are mean orbital elements that change linearly with time, from the JPL "Approximate Positions of the
+Planets" tables (E M Standish). Values are relative to the J2000 ecliptic and equinox, distances are in AU, angles in
+degrees and rates are per Julian century from J2000.
+
+The outer planets also have the extra terms B, C, S and F which are added to the mean anomaly as
+B T^2 + C cos(F T) + S sin(F T) where T is in centuries. They are zero for the other planets.
+
+These are the long span elements that are good from 3000 BC to 3000 AD. Errors are up to a few hundred arc seconds
+for the inner planets and a few thousand for Saturn, fine for plotting and finding things, not for precise work.
+*/
+type PlanetElements struct {
+	Name                            string
+	SemimajorAxis                   float64
+	Eccentricity                    float64
+	Inclination                     float64
+	MeanLongitude                   float64
+	LongitudeOfPerihelion           float64
+	LongitudeOfTheAscendingNode     float64
+	SemimajorAxisRate               float64
+	EccentricityRate                float64
+	InclinationRate                 float64
+	MeanLongitudeRate               float64
+	LongitudeOfPerihelionRate       float64
+	LongitudeOfTheAscendingNodeRate float64
+	B, C, S, F                      float64
+}
+
+/*
+MercuryElements are the approximate elements of Mercury
+*/
+var MercuryElements = PlanetElements{
+	Name:                            "Mercury",
+	SemimajorAxis:                   0.38709843,
+	Eccentricity:                    0.20563661,
+	Inclination:                     7.00559432,
+	MeanLongitude:                   252.25166724,
+	LongitudeOfPerihelion:           77.45771895,
+	LongitudeOfTheAscendingNode:     48.33961819,
+	SemimajorAxisRate:               0.00000000,
+	EccentricityRate:                0.00002123,
+	InclinationRate:                 -0.00590158,
+	MeanLongitudeRate:               149472.67486623,
+	LongitudeOfPerihelionRate:       0.15940013,
+	LongitudeOfTheAscendingNodeRate: -0.12214182,
+}
+
+/*
+VenusElements are the approximate elements of Venus
+*/
+var VenusElements = PlanetElements{
+	Name:                            "Venus",
+	SemimajorAxis:                   0.72332102,
+	Eccentricity:                    0.00676399,
+	Inclination:                     3.39777545,
+	MeanLongitude:                   181.97970850,
+	LongitudeOfPerihelion:           131.76755713,
+	LongitudeOfTheAscendingNode:     76.67261496,
+	SemimajorAxisRate:               -0.00000026,
+	EccentricityRate:                -0.00005107,
+	InclinationRate:                 0.00043494,
+	MeanLongitudeRate:               58517.81560260,
+	LongitudeOfPerihelionRate:       0.05679648,
+	LongitudeOfTheAscendingNodeRate: -0.27274174,
+}
+
+/*
+EarthElements are the approximate elements of the Earth-Moon barycenter
+*/
+var EarthElements = PlanetElements{
+	Name:                            "Earth",
+	SemimajorAxis:                   1.00000018,
+	Eccentricity:                    0.01673163,
+	Inclination:                     -0.00054346,
+	MeanLongitude:                   100.46691572,
+	LongitudeOfPerihelion:           102.93005885,
+	LongitudeOfTheAscendingNode:     -5.11260389,
+	SemimajorAxisRate:               -0.00000003,
+	EccentricityRate:                -0.00003661,
+	InclinationRate:                 -0.01337178,
+	MeanLongitudeRate:               35999.37306329,
+	LongitudeOfPerihelionRate:       0.31795260,
+	LongitudeOfTheAscendingNodeRate: -0.24123856,
+}
+
+/*
+MarsElements are the approximate elements of Mars
+*/
+var MarsElements = PlanetElements{
+	Name:                            "Mars",
+	SemimajorAxis:                   1.52371243,
+	Eccentricity:                    0.09336511,
+	Inclination:                     1.85181869,
+	MeanLongitude:                   -4.56813164,
+	LongitudeOfPerihelion:           -23.91744784,
+	LongitudeOfTheAscendingNode:     49.71320984,
+	SemimajorAxisRate:               0.00000097,
+	EccentricityRate:                0.00009149,
+	InclinationRate:                 -0.00724757,
+	MeanLongitudeRate:               19140.29934243,
+	LongitudeOfPerihelionRate:       0.45223625,
+	LongitudeOfTheAscendingNodeRate: -0.26852431,
+}
+
+/*
+JupiterElements are the approximate elements of Jupiter
+*/
+var JupiterElements = PlanetElements{
+	Name:                            "Jupiter",
+	SemimajorAxis:                   5.20248019,
+	Eccentricity:                    0.04853590,
+	Inclination:                     1.29861416,
+	MeanLongitude:                   34.33479152,
+	LongitudeOfPerihelion:           14.27495244,
+	LongitudeOfTheAscendingNode:     100.29282654,
+	SemimajorAxisRate:               -0.00002864,
+	EccentricityRate:                0.00018026,
+	InclinationRate:                 -0.00322699,
+	MeanLongitudeRate:               3034.90371757,
+	LongitudeOfPerihelionRate:       0.18199196,
+	LongitudeOfTheAscendingNodeRate: 0.13024619,
+	B:                               -0.00012452,
+	C:                               0.06064060,
+	S:                               -0.35635438,
+	F:                               38.35125000,
+}
+
+/*
+SaturnElements are the approximate elements of Saturn
+*/
+var SaturnElements = PlanetElements{
+	Name:                            "Saturn",
+	SemimajorAxis:                   9.54149883,
+	Eccentricity:                    0.05550825,
+	Inclination:                     2.49424102,
+	MeanLongitude:                   50.07571329,
+	LongitudeOfPerihelion:           92.86136063,
+	LongitudeOfTheAscendingNode:     113.63998702,
+	SemimajorAxisRate:               -0.00003065,
+	EccentricityRate:                -0.00032044,
+	InclinationRate:                 0.00451969,
+	MeanLongitudeRate:               1222.11494724,
+	LongitudeOfPerihelionRate:       0.54179478,
+	LongitudeOfTheAscendingNodeRate: -0.25015002,
+	B:                               0.00025899,
+	C:                               -0.13434469,
+	S:                               0.87320147,
+	F:                               38.35125000,
+}
+
+/*
+UranusElements are the approximate elements of Uranus
+*/
+var UranusElements = PlanetElements{
+	Name:                            "Uranus",
+	SemimajorAxis:                   19.18797948,
+	Eccentricity:                    0.04685740,
+	Inclination:                     0.77298127,
+	MeanLongitude:                   314.20276625,
+	LongitudeOfPerihelion:           172.43404441,
+	LongitudeOfTheAscendingNode:     73.96250215,
+	SemimajorAxisRate:               -0.00020455,
+	EccentricityRate:                -0.00001550,
+	InclinationRate:                 -0.00180155,
+	MeanLongitudeRate:               428.49512595,
+	LongitudeOfPerihelionRate:       0.09266985,
+	LongitudeOfTheAscendingNodeRate: 0.05739699,
+	B:                               0.00058331,
+	C:                               -0.97731848,
+	S:                               0.17689245,
+	F:                               7.67025000,
+}
+
+/*
+NeptuneElements are the approximate elements of Neptune
+*/
+var NeptuneElements = PlanetElements{
+	Name:                            "Neptune",
+	SemimajorAxis:                   30.06952752,
+	Eccentricity:                    0.00895439,
+	Inclination:                     1.77005520,
+	MeanLongitude:                   304.22289287,
+	LongitudeOfPerihelion:           46.68158724,
+	LongitudeOfTheAscendingNode:     131.78635853,
+	SemimajorAxisRate:               0.00006447,
+	EccentricityRate:                0.00000818,
+	InclinationRate:                 0.00022400,
+	MeanLongitudeRate:               218.46515314,
+	LongitudeOfPerihelionRate:       0.01009938,
+	LongitudeOfTheAscendingNodeRate: -0.00606302,
+	B:                               -0.00041348,
+	C:                               0.68346318,
+	S:                               -0.10162547,
+	F:                               7.67025000,
+}
+
+/*
+PlutoElements are the approximate elements of Pluto
+*/
+var PlutoElements = PlanetElements{
+	Name:                            "Pluto",
+	SemimajorAxis:                   39.48686035,
+	Eccentricity:                    0.24885238,
+	Inclination:                     17.14104260,
+	MeanLongitude:                   238.96535011,
+	LongitudeOfPerihelion:           224.09702598,
+	LongitudeOfTheAscendingNode:     110.30167986,
+	SemimajorAxisRate:               0.00449751,
+	EccentricityRate:                0.00006016,
+	InclinationRate:                 0.00000501,
+	MeanLongitudeRate:               145.18042903,
+	LongitudeOfPerihelionRate:       -0.00968827,
+	LongitudeOfTheAscendingNodeRate: -0.00809981,
+	B:                               -0.01262724,
+}
+
+/*
+PlanetaryElements lists the approximate elements of the planets and Pluto in order from the sun
+*/
+var PlanetaryElements = []*PlanetElements{
+	&MercuryElements,
+	&VenusElements,
+	&EarthElements,
+	&MarsElements,
+	&JupiterElements,
+	&SaturnElements,
+	&UranusElements,
+	&NeptuneElements,
+	&PlutoElements,
+}
+
+/*
+OrbitAt returns the osculating orbit of the planet at time t with its epoch set to t. UTC is used in place of TT,
+which is well within the accuracy of the tables.
+*/
+func (p *PlanetElements) OrbitAt(t time.Time) *orbcore.Orbit {
+	c := JulianCenturies(t)
+
+	a := p.SemimajorAxis + p.SemimajorAxisRate*c
+	e := p.Eccentricity + p.EccentricityRate*c
+	i := p.Inclination + p.InclinationRate*c
+	l := p.MeanLongitude + p.MeanLongitudeRate*c
+	peri := p.LongitudeOfPerihelion + p.LongitudeOfPerihelionRate*c
+	node := p.LongitudeOfTheAscendingNode + p.LongitudeOfTheAscendingNodeRate*c
+
+	// a few of the tables have a small negative inclination, which is the same plane with the node on the other side
+	if i < 0 {
+		i = -i
+		node += 180
+	}
+
+	m := l - peri + p.B*c*c
+	if p.F != 0 {
+		m += p.C*math.Cos(p.F*c*deg) + p.S*math.Sin(p.F*c*deg)
+	}
+
+	orbit := orbcore.Orbit{
+		ID:                          p.Name,
+		ParentGrav:                  SunGrav,
+		Epoch:                       t,
+		ArgumentOfPerihelion:        normaliseRadians((peri - node) * deg),
+		LongitudeOfTheAscendingNode: normaliseRadians(node * deg),
+		InclinationToTheEcliptic:    i * deg,
+		OrbitalEccentricity:         e,
+		SemimajorAxis:               a * AU,
+		MeanDailyMotion:             p.MeanLongitudeRate / 36525, // deg/day
+	}
+	orbit.MeanAnomalyEpoch = orbcore.MeanToTrueAnomaly(normaliseRadians(m*deg), &orbit)
+	return &orbit
+}
+
+/*
+JulianCenturies returns the number of Julian centuries of 36525 days between J2000Noon and t.
+*/
+func JulianCenturies(t time.Time) float64 {
+	return (orbcore.JulianDate(t) - 2451545.0) / 36525.0
+}
+
+func normaliseRadians(r float64) float64 {
+	r = math.Mod(r, 2*math.Pi)
+	if r < 0 {
+		r += 2 * math.Pi
+	}
+	return r
+}
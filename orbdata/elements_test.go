@@ -0,0 +1,113 @@
+package orbdata
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+func longitude(b *Body, t time.Time) float64 {
+	pos := b.HeliocentricPosition(t)
+	l := math.Atan2(pos.Y, pos.X) / deg
+	if l < 0 {
+		l += 360
+	}
+	return l
+}
+
+func fromSun(p *orbcore.Position) float64 {
+	return math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+}
+
+func TestPlanetElementsAtJ2000(t *testing.T) {
+	orbit := EarthElements.OrbitAt(J2000Noon)
+	if orbit.Epoch != J2000Noon || orbit.ID != "Earth" {
+		t.Errorf("unexpected orbit %+v", orbit)
+	}
+	if math.Abs(orbit.SemimajorAxis-1.00000018*AU) > 1e-3 {
+		t.Errorf("expected semimajor axis of %v got %v", 1.00000018*AU, orbit.SemimajorAxis)
+	}
+	if orbit.InclinationToTheEcliptic < 0 {
+		t.Errorf("expected a positive inclination got %v", orbit.InclinationToTheEcliptic)
+	}
+
+	// The sun was at a geometric longitude of 280.38 degrees so the earth was opposite it.
+	if l := longitude(Earth, J2000Noon); math.Abs(l-100.38) > 0.05 {
+		t.Errorf("expected earth at a longitude of 100.38 got %v", l)
+	}
+}
+
+func TestSolarSystemAt(t *testing.T) {
+	date := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+	orbits := SolarSystemAt(date)
+	if len(orbits) != 8 || orbits[0].ID != "Mercury" || orbits[7].ID != "Neptune" {
+		t.Fatalf("unexpected planets %v", orbits)
+	}
+	for _, o := range orbits {
+		if !o.Epoch.Equal(date) {
+			t.Errorf("%v: expected epoch %v got %v", o.ID, date, o.Epoch)
+		}
+	}
+
+	// the fixed orbits are the same elements at midnight on the first of January 2000, half a day before the earth
+	// reaches the longitude of 100.38 in TestPlanetElementsAtJ2000
+	p := orbcore.OrbitToPosition(&EarthOrbit)
+	if l := math.Atan2(p.Y, p.X) / deg; math.Abs(l-99.87) > 0.05 {
+		t.Errorf("expected the fixed earth orbit at a longitude of 99.87 got %v", l)
+	}
+}
+
+func TestEarthDistanceChanges(t *testing.T) {
+	perihelion := Earth.HeliocentricPosition(time.Date(2019, 1, 3, 5, 0, 0, 0, time.UTC))
+	aphelion := Earth.HeliocentricPosition(time.Date(2019, 7, 4, 22, 0, 0, 0, time.UTC))
+
+	if d := fromSun(perihelion) / AU; math.Abs(d-0.98330) > 1e-4 {
+		t.Errorf("expected perihelion distance of 0.98330 AU got %v", d)
+	}
+	if d := fromSun(aphelion) / AU; math.Abs(d-1.01675) > 1e-4 {
+		t.Errorf("expected aphelion distance of 1.01675 AU got %v", d)
+	}
+}
+
+func TestOppositions(t *testing.T) {
+	oppositions := []struct {
+		body *Body
+		when time.Time
+	}{
+		{Mars, time.Date(2020, 10, 13, 23, 0, 0, 0, time.UTC)},
+		{Jupiter, time.Date(2022, 9, 26, 20, 0, 0, 0, time.UTC)},
+		{Saturn, time.Date(2022, 8, 14, 17, 0, 0, 0, time.UTC)},
+		{Neptune, time.Date(2022, 9, 16, 22, 0, 0, 0, time.UTC)},
+	}
+
+	for _, o := range oppositions {
+		diff := math.Abs(longitude(o.body, o.when) - longitude(Earth, o.when))
+		diff = math.Min(diff, 360-diff)
+		if diff > 1 {
+			t.Errorf("expected %v to be in line with the earth on %v but was %v degrees off", o.body, o.when, diff)
+		}
+	}
+}
+
+func TestPlanetElementsOverTheirSpan(t *testing.T) {
+	times := []time.Time{
+		time.Date(-2999, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(1066, 10, 14, 0, 0, 0, 0, time.UTC),
+		time.Date(2999, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	for _, p := range PlanetaryElements {
+		body, _ := LookupBody(p.Name)
+		for _, when := range times {
+			orbit := body.OrbitAt(when)
+			d := fromSun(body.HeliocentricPosition(when))
+			min := orbit.SemimajorAxis * (1 - orbit.OrbitalEccentricity)
+			max := orbit.SemimajorAxis * (1 + orbit.OrbitalEccentricity)
+			if math.IsNaN(d) || d < min*0.999 || d > max*1.001 {
+				t.Errorf("%v on %v was %v km from the sun, expected between %v and %v", p.Name, when, d, min, max)
+			}
+		}
+	}
+}
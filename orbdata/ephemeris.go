@@ -0,0 +1,29 @@
+package orbdata
+
+import (
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+Ephemeris gives the position of a body at a time. Positions are relative to the sun in the J2000 ecliptic frame, in
+km. An error is returned when the ephemeris does not cover the body or the time.
+
+The orbits in this package are available as Approximate. orbspk reads JPL ephemeris files for more accurate positions.
+*/
+type Ephemeris interface {
+	HeliocentricPosition(body *Body, t time.Time) (*orbcore.Position, error)
+}
+
+/*
+Approximate is the Ephemeris made from the orbits and elements of the bodies in this package. It covers every body
+with an orbit and never fails.
+*/
+var Approximate Ephemeris = approximate{}
+
+type approximate struct{}
+
+func (approximate) HeliocentricPosition(body *Body, t time.Time) (*orbcore.Position, error) {
+	return body.HeliocentricPosition(t), nil
+}
@@ -0,0 +1,172 @@
+package orbdata
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"gonum.org/v1/gonum/mat"
+)
+
+// The bundled observatory list is only a few common sites. It can be replaced by the full list from a copy of the MPC
+// ObsCodes.html with:
+//   OBSCODES=/path/to/ObsCodes.html go generate ./orbdata
+//go:generate go run ../tools/obscodes -in $OBSCODES -out observatory_codes.go
+
+/*
+Observatory is an entry from the MPC list of observatory codes.
+
+Longitude is in radians east of Greenwich. RhoCosPhi and RhoSinPhi are the parallax constants in earth radii.
+Space based observatories have no fixed site and Fixed will be false.
+*/
+type Observatory struct {
+	Code      string
+	Longitude float64 // rad
+	RhoCosPhi float64
+	RhoSinPhi float64
+	Name      string
+	Fixed     bool
+}
+
+/*
+GeocentricPosition returns the position of the observatory relative to the center of the earth at time t in the
+ecliptic frame, in km.
+*/
+func (o *Observatory) GeocentricPosition(t time.Time) *mat.VecDense {
+	theta := GreenwichMeanSiderealTime(t) + o.Longitude
+
+	r := mat.NewVecDense(3, []float64{
+		EarthRadius * o.RhoCosPhi * math.Cos(theta),
+		EarthRadius * o.RhoCosPhi * math.Sin(theta),
+		EarthRadius * o.RhoSinPhi,
+	})
+
+	// The parallax constants are relative to the equator so rotate into the ecliptic.
+	return orbcore.Rotate(r, -Obliquity, orbcore.AxisX)
+}
+
+/*
+HeliocentricPosition returns the position of the observatory relative to the sun at time t. The earth is placed
+using the approximate elements of the Earth-Moon barycenter.
+*/
+func (o *Observatory) HeliocentricPosition(t time.Time) *orbcore.Position {
+	earth := Earth.HeliocentricPosition(t)
+	site := o.GeocentricPosition(t)
+
+	return &orbcore.Position{
+		ID:    o.Code,
+		Epoch: t,
+		X:     earth.X + site.AtVec(0),
+		Y:     earth.Y + site.AtVec(1),
+		Z:     earth.Z + site.AtVec(2),
+	}
+}
+
+/*
+GreenwichMeanSiderealTime returns the rotation angle of the earth at time t in radians. UTC is used in place of UT1.
+*/
+func GreenwichMeanSiderealTime(t time.Time) float64 {
+	d := orbcore.JulianDate(t) - 2451545.0
+	c := d / 36525.0
+	gmst := 280.46061837 + 360.98564736629*d + 0.000387933*c*c - c*c*c/38710000.0
+	gmst = math.Mod(gmst, 360)
+	if gmst < 0 {
+		gmst += 360
+	}
+	return gmst * math.Pi / 180.0
+}
+
+/*
+ParseObservatoryCodes reads a list of observatories in the MPC ObsCodes format.
+
+Lines that are not observatory entries, such as the column header or html tags, are skipped.
+*/
+func ParseObservatoryCodes(input io.Reader) ([]*Observatory, error) {
+	scanner := bufio.NewScanner(input)
+
+	var result []*Observatory
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if len(line) < 4 || line[3] != ' ' || strings.HasPrefix(line, "Code") || strings.HasPrefix(line, "<") {
+			continue
+		}
+
+		o, err := parseObservatoryLine(line)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, o)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func parseObservatoryLine(line string) (*Observatory, error) {
+	field := func(start, end int) string {
+		if start >= len(line) {
+			return ""
+		}
+		if end > len(line) {
+			end = len(line)
+		}
+		return strings.TrimSpace(line[start:end])
+	}
+
+	result := Observatory{
+		Code: line[0:3],
+		Name: field(30, len(line)),
+	}
+
+	long, cos, sin := field(4, 13), field(13, 21), field(21, 30)
+	if long == "" && cos == "" && sin == "" {
+		return &result, nil
+	}
+
+	values := make([]float64, 3)
+	for i, s := range []string{long, cos, sin} {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse observatory %v: %v", result.Code, err)
+		}
+		values[i] = v
+	}
+
+	result.Longitude = values[0] * math.Pi / 180.0
+	result.RhoCosPhi = values[1]
+	result.RhoSinPhi = values[2]
+	result.Fixed = true
+	return &result, nil
+}
+
+var observatoriesOnce sync.Once
+var observatories map[string]*Observatory
+
+/*
+LookupObservatory finds an observatory by its MPC code in the bundled list of observatory codes. The list bundled in
+the repository only has a few common sites, see tools/obscodes to bundle the full MPC list.
+*/
+func LookupObservatory(code string) (*Observatory, bool) {
+	observatoriesOnce.Do(func() {
+		list, err := ParseObservatoryCodes(strings.NewReader(observatoryCodes))
+		if err != nil {
+			panic(fmt.Sprintf("bundled observatory codes are invalid: %v", err))
+		}
+		observatories = make(map[string]*Observatory, len(list))
+		for _, o := range list {
+			observatories[o.Code] = o
+		}
+	})
+
+	o, ok := observatories[code]
+	return o, ok
+}
@@ -0,0 +1,78 @@
+package orbdata
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestParseObservatoryCodes(t *testing.T) {
+	input := `<pre>
+Code  Long.   cos      sin    Name
+000   0.0000 0.62411 +0.77873 Greenwich
+568 204.527800.94171 +0.33725 Maunakea
+C51                           WISE
+</pre>`
+
+	result, err := ParseObservatoryCodes(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 3 {
+		t.Fatalf("expected 3 observatories got %v", len(result))
+	}
+
+	mk := result[1]
+	if mk.Code != "568" || mk.Name != "Maunakea" || !mk.Fixed {
+		t.Errorf("unexpected observatory %+v", mk)
+	}
+	if math.Abs(mk.Longitude-204.5278*math.Pi/180) > 1e-12 || mk.RhoCosPhi != 0.94171 || mk.RhoSinPhi != 0.33725 {
+		t.Errorf("unexpected observatory location %+v", mk)
+	}
+
+	if result[2].Fixed || result[2].Name != "WISE" {
+		t.Errorf("expected WISE to have no fixed site got %+v", result[2])
+	}
+}
+
+func TestLookupObservatory(t *testing.T) {
+	o, ok := LookupObservatory("G96")
+	if !ok {
+		t.Fatal("could not find G96")
+	}
+	if o.Name != "Mt. Lemmon Survey" {
+		t.Errorf("expected Mt. Lemmon Survey got %v", o.Name)
+	}
+
+	if _, ok := LookupObservatory("XXX"); ok {
+		t.Error("found an observatory that does not exist")
+	}
+}
+
+func TestGreenwichMeanSiderealTime(t *testing.T) {
+	r := GreenwichMeanSiderealTime(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))
+	expected := 280.46061837 * math.Pi / 180
+	if math.Abs(r-expected) > 1e-9 {
+		t.Errorf("expected %v got %v", expected, r)
+	}
+}
+
+func TestObservatoryHeliocentricPosition(t *testing.T) {
+	o, _ := LookupObservatory("568")
+	when := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	site := o.GeocentricPosition(when)
+	expected := EarthRadius * math.Hypot(o.RhoCosPhi, o.RhoSinPhi)
+	if math.Abs(mat.Norm(site, 2)-expected) > 1e-6 {
+		t.Errorf("expected site to be %v km from the earth center got %v", expected, mat.Norm(site, 2))
+	}
+
+	pos := o.HeliocentricPosition(when)
+	distance := math.Sqrt(pos.X*pos.X+pos.Y*pos.Y+pos.Z*pos.Z) / AU
+	if distance < 0.983 || distance > 0.984 {
+		t.Errorf("expected observatory to be 0.983-0.984 AU from the sun in early January got %v", distance)
+	}
+}
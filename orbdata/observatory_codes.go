@@ -0,0 +1,19 @@
+package orbdata
+
+// observatoryCodes is a hand picked subset of the MPC list of observatory codes, the survey sites and space telescopes
+// most observations come from, parsed by ParseObservatoryCodes. It is not the full list, tools/obscodes replaces this
+// file with every site from a copy of ObsCodes.html.
+const observatoryCodes = `000    0.00000.62411 +0.77873 Greenwich
+245                           Spitzer Space Telescope
+250                           Hubble Space Telescope
+500    0.00000.00000 +0.00000 Geocentric
+568 204.527800.94171 +0.33725 Maunakea
+675 243.137460.836325+0.546877Palomar Mountain
+691 248.399660.845067+0.533717Steward Observatory, Kitt Peak-Spacewatch
+703 249.267360.845225+0.533420Catalina Sky Survey
+C51                           WISE
+F51 203.744090.936241+0.351543Pan-STARRS 1, Haleakala
+G96 249.211280.845111+0.533614Mt. Lemmon Survey
+I41 243.140220.836325+0.546877Palomar Mountain--ZTF
+T05  203.74240.93624 +0.35154 ATLAS-HKO, Haleakala
+`
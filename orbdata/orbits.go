@@ -1,139 +1,271 @@
 package orbdata
 
 import (
+	"time"
+
 	"github.com/emilyselwood/orbcalc/orbcore"
 )
 
 // This file will contain orbital information for standard objects. Major planets, moons and so on.
+//
+// The planet orbits here are worked out from the PlanetElements in elements.go at J2000. The planets drift over the
+// years, use OrbitAt on the elements or the Body, or SolarSystemAt, for other dates.
 
-// MercuryOrbit defines the standard mercury orbit
-var MercuryOrbit = orbcore.Orbit{
-	ID:                          "Mercury",
-	ParentGrav:                  SunGrav,
-	Epoch:                       J2000,
-	MeanAnomalyEpoch:            0.7363828677023899,  // rad
-	ArgumentOfPerihelion:        1.290398137330985,   // rad
-	LongitudeOfTheAscendingNode: 0.19016162418731905, // rad
-	InclinationToTheEcliptic:    0.122258,            // rad
-	OrbitalEccentricity:         0.2161872518335417,
-	SemimajorAxis:               5.7909176e7, // km
+// MercuryOrbit defines the standard mercury orbit at J2000
+var MercuryOrbit = *MercuryElements.OrbitAt(J2000)
+
+// VenusOrbit defines the standard venus orbit at J2000
+var VenusOrbit = *VenusElements.OrbitAt(J2000)
+
+// EarthOrbit defines the standard earth orbit at J2000.
+var EarthOrbit = *EarthElements.OrbitAt(J2000)
+
+// MarsOrbit defines the standard mars orbit at J2000.
+var MarsOrbit = *MarsElements.OrbitAt(J2000)
+
+// JupiterOrbit defines the standard Jupiter orbit at J2000.
+var JupiterOrbit = *JupiterElements.OrbitAt(J2000)
+
+// SaturnOrbit defines the standard Saturn orbit at J2000.
+var SaturnOrbit = *SaturnElements.OrbitAt(J2000)
+
+// UranusOrbit defines the standard Uranus orbit at J2000.
+var UranusOrbit = *UranusElements.OrbitAt(J2000)
+
+// NeptuneOrbit defines the standard Neptune orbit at J2000.
+var NeptuneOrbit = *NeptuneElements.OrbitAt(J2000)
+
+// SolarSystem is a collection of major bodies in the solar system at J2000
+var SolarSystem = SolarSystemAt(J2000)
+
+// InnerSolarSystem is a collection of major bodies in the inner solar system at J2000
+var InnerSolarSystem = InnerSolarSystemAt(J2000)
+
+// OuterSolarSystem is a collection of major bodies in the outer solar system at J2000
+var OuterSolarSystem = OuterSolarSystemAt(J2000)
+
+// SolarSystemAt returns the orbits of the major planets at time t
+func SolarSystemAt(t time.Time) []orbcore.Orbit {
+	return append(InnerSolarSystemAt(t), OuterSolarSystemAt(t)...)
 }
 
-// VenusOrbit defines the standard mercury orbit
-var VenusOrbit = orbcore.Orbit{
-	ID:                          "Venus",
-	ParentGrav:                  SunGrav,
-	Epoch:                       J2000,
-	MeanAnomalyEpoch:            6.024347789858294,   // rad
-	ArgumentOfPerihelion:        1.8790979389622697,  // rad
-	LongitudeOfTheAscendingNode: 0.13963804205942293, // rad
-	InclinationToTheEcliptic:    0.0592489,           // rad
-	OrbitalEccentricity:         0.017361719534212148,
-	SemimajorAxis:               1.0820893e8, // km
+// InnerSolarSystemAt returns the orbits of the planets of the inner solar system at time t
+func InnerSolarSystemAt(t time.Time) []orbcore.Orbit {
+	return orbitsAt(t, &MercuryElements, &VenusElements, &EarthElements, &MarsElements)
 }
 
-// EarthOrbit defines the standard earth orbit.
-var EarthOrbit = orbcore.Orbit{
-	ID:                          "Earth",
-	ParentGrav:                  SunGrav,
-	Epoch:                       J2000,
-	MeanAnomalyEpoch:            6.039693392708146,     // rad
-	ArgumentOfPerihelion:        1.4877567222443007,    // rad
-	LongitudeOfTheAscendingNode: 8.219803446009808e-05, // rad
-	InclinationToTheEcliptic:    0.9e-07,               // rad
-	OrbitalEccentricity:         0.023506053256160484,
-	SemimajorAxis:               1.49597887e8, // km
+// OuterSolarSystemAt returns the orbits of the planets of the outer solar system at time t
+func OuterSolarSystemAt(t time.Time) []orbcore.Orbit {
+	return orbitsAt(t, &JupiterElements, &SaturnElements, &UranusElements, &NeptuneElements)
 }
 
-// MarsOrbit defines the standard mars orbit.
-var MarsOrbit = orbcore.Orbit{
-	ID:                          "Mars",
-	ParentGrav:                  SunGrav,
-	Epoch:                       J2000,
-	MeanAnomalyEpoch:            0.9016227920497925,   // rad
-	ArgumentOfPerihelion:        5.804221558977953,    // rad
-	LongitudeOfTheAscendingNode: 0.059136325715984754, // rad
-	InclinationToTheEcliptic:    0.0322992,            // rad
-	OrbitalEccentricity:         0.09853112210172534,
-	SemimajorAxis:               2.27936637e8, // km
+func orbitsAt(t time.Time, elements ...*PlanetElements) []orbcore.Orbit {
+	result := make([]orbcore.Orbit, len(elements))
+	for i, e := range elements {
+		result[i] = *e.OrbitAt(t)
+	}
+	return result
 }
 
-// JupiterOrbit defines the standard Jupiter orbit.
-var JupiterOrbit = orbcore.Orbit{
-	ID:                          "Jupiter",
-	ParentGrav:                  SunGrav,
-	Epoch:                       J2000,
-	MeanAnomalyEpoch:            3.986624571747394,    // rad
-	ArgumentOfPerihelion:        0.22894709895829354,  // rad
-	LongitudeOfTheAscendingNode: 0.056682739190454204, // rad
-	InclinationToTheEcliptic:    0.0227818,            // rad
-	OrbitalEccentricity:         0.05041232826440195,
-	SemimajorAxis:               7.78412027e8, // km
+// withMeanAnomaly sets the position of an orbit from a mean anomaly in degrees. Published elements give the mean
+// anomaly but MeanAnomalyEpoch holds the true anomaly.
+func withMeanAnomaly(orbit orbcore.Orbit, m float64) orbcore.Orbit {
+	orbit.MeanAnomalyEpoch = orbcore.MeanToTrueAnomaly(m*deg, &orbit)
+	return orbit
 }
 
-// SaturnOrbit defines the standard Saturn orbit.
-var SaturnOrbit = orbcore.Orbit{
-	ID:                          "Saturn",
+// Dwarf planets and large asteroids. Heliocentric elements relative to the J2000 ecliptic.
+
+// PlutoOrbit defines the standard Pluto orbit, from the JPL approximate planetary elements.
+var PlutoOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Pluto",
 	ParentGrav:                  SunGrav,
-	Epoch:                       J2000,
-	MeanAnomalyEpoch:            3.2720797523951766,  // rad
-	ArgumentOfPerihelion:        1.5276434137035415,  // rad
-	LongitudeOfTheAscendingNode: 0.10399170848152173, // rad
-	InclinationToTheEcliptic:    0.043362,            // rad
-	OrbitalEccentricity:         0.05853326249640754,
-	SemimajorAxis:               1.42672541e9, // km
-}
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        113.76497945 * deg,
+	LongitudeOfTheAscendingNode: 110.30393684 * deg,
+	InclinationToTheEcliptic:    17.14001206 * deg,
+	OrbitalEccentricity:         0.24882730,
+	SemimajorAxis:               39.48211675 * AU,
+}, 14.86012204)
 
-// UranusOrbit defines the standard Uranus orbit.
-var UranusOrbit = orbcore.Orbit{
-	ID:                          "Uranus",
+// CeresOrbit defines the standard Ceres orbit.
+var CeresOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Ceres",
 	ParentGrav:                  SunGrav,
-	Epoch:                       J2000,
-	MeanAnomalyEpoch:            3.8644829632802806,  // rad
-	ArgumentOfPerihelion:        2.926548412800625,   // rad
-	LongitudeOfTheAscendingNode: 0.03235322856941487, // rad
-	InclinationToTheEcliptic:    0.013437,            // rad
-	OrbitalEccentricity:         0.044645557888114,
-	SemimajorAxis:               2.87097222e9, // km
-}
+	Epoch:                       time.Date(2018, 3, 23, 0, 0, 0, 0, time.UTC),
+	ArgumentOfPerihelion:        73.11528 * deg,
+	LongitudeOfTheAscendingNode: 80.30992 * deg,
+	InclinationToTheEcliptic:    10.59351 * deg,
+	OrbitalEccentricity:         0.0755347,
+	SemimajorAxis:               2.7670463 * AU,
+}, 352.23052)
 
-// NeptuneOrbit defines the standard Neptune orbit.
-var NeptuneOrbit = orbcore.Orbit{
-	ID:                          "Neptune",
+// VestaOrbit defines the standard Vesta orbit.
+var VestaOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Vesta",
 	ParentGrav:                  SunGrav,
-	Epoch:                       J2000,
-	MeanAnomalyEpoch:            5.100969108525634,    // rad
-	ArgumentOfPerihelion:        0.8712884041923264,   // rad
-	LongitudeOfTheAscendingNode: 0.060720496894987035, // rad
-	InclinationToTheEcliptic:    0.0308778,            // rad
-	OrbitalEccentricity:         0.011600603763700122,
-	SemimajorAxis:               4.49825291e9, // km
-}
+	Epoch:                       time.Date(2020, 5, 31, 0, 0, 0, 0, time.UTC),
+	ArgumentOfPerihelion:        151.66 * deg,
+	LongitudeOfTheAscendingNode: 103.71 * deg,
+	InclinationToTheEcliptic:    7.1422 * deg,
+	OrbitalEccentricity:         0.08874,
+	SemimajorAxis:               2.36151 * AU,
+}, 169.4)
 
-// SolarSystem is a collection of major bodies in the solar system
-var SolarSystem = []orbcore.Orbit{
-	MercuryOrbit,
-	VenusOrbit,
-	EarthOrbit,
-	MarsOrbit,
-	JupiterOrbit,
-	SaturnOrbit,
-	UranusOrbit,
-	NeptuneOrbit,
-}
+// ErisOrbit defines the standard Eris orbit.
+var ErisOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Eris",
+	ParentGrav:                  SunGrav,
+	Epoch:                       time.Date(2020, 5, 31, 0, 0, 0, 0, time.UTC),
+	ArgumentOfPerihelion:        151.639 * deg,
+	LongitudeOfTheAscendingNode: 35.951 * deg,
+	InclinationToTheEcliptic:    44.040 * deg,
+	OrbitalEccentricity:         0.43607,
+	SemimajorAxis:               67.864 * AU,
+}, 205.989)
 
-// InnerSolarSystem is a collection of major bodies in the inner solar system
-var InnerSolarSystem = []orbcore.Orbit{
-	MercuryOrbit,
-	VenusOrbit,
-	EarthOrbit,
-	MarsOrbit,
-}
+// HaumeaOrbit defines the standard Haumea orbit.
+var HaumeaOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Haumea",
+	ParentGrav:                  SunGrav,
+	Epoch:                       time.Date(2020, 5, 31, 0, 0, 0, 0, time.UTC),
+	ArgumentOfPerihelion:        239.041 * deg,
+	LongitudeOfTheAscendingNode: 122.167 * deg,
+	InclinationToTheEcliptic:    28.2137 * deg,
+	OrbitalEccentricity:         0.19642,
+	SemimajorAxis:               43.116 * AU,
+}, 218.205)
+
+// MakemakeOrbit defines the standard Makemake orbit.
+var MakemakeOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Makemake",
+	ParentGrav:                  SunGrav,
+	Epoch:                       time.Date(2020, 5, 31, 0, 0, 0, 0, time.UTC),
+	ArgumentOfPerihelion:        294.834 * deg,
+	LongitudeOfTheAscendingNode: 79.620 * deg,
+	InclinationToTheEcliptic:    28.9835 * deg,
+	OrbitalEccentricity:         0.16126,
+	SemimajorAxis:               45.430 * AU,
+}, 165.514)
+
+// Moons. These are mean elements at J2000 from the JPL planetary satellite tables. The Moon is relative to the
+// ecliptic, the rest are relative to the equator of their planet. ParentGrav is the combined GM of the planet and
+// moon as that is what sets the period of the orbit between them.
+
+// MoonOrbit defines the standard orbit of the Moon around the Earth.
+var MoonOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Moon",
+	ParentGrav:                  EarthGrav + MoonGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        318.15 * deg,
+	LongitudeOfTheAscendingNode: 125.08 * deg,
+	InclinationToTheEcliptic:    5.16 * deg,
+	OrbitalEccentricity:         0.0554,
+	SemimajorAxis:               384400,
+}, 135.27)
+
+// PhobosOrbit defines the standard orbit of Phobos around Mars.
+var PhobosOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Phobos",
+	ParentGrav:                  MarsGrav + PhobosGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        150.057 * deg,
+	LongitudeOfTheAscendingNode: 207.784 * deg,
+	InclinationToTheEcliptic:    1.075 * deg,
+	OrbitalEccentricity:         0.0151,
+	SemimajorAxis:               9376,
+}, 91.059)
+
+// DeimosOrbit defines the standard orbit of Deimos around Mars.
+var DeimosOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Deimos",
+	ParentGrav:                  MarsGrav + DeimosGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        260.729 * deg,
+	LongitudeOfTheAscendingNode: 24.525 * deg,
+	InclinationToTheEcliptic:    1.788 * deg,
+	OrbitalEccentricity:         0.0002,
+	SemimajorAxis:               23458,
+}, 325.329)
+
+// IoOrbit defines the standard orbit of Io around Jupiter.
+var IoOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Io",
+	ParentGrav:                  JupiterGrav + IoGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        49.1 * deg,
+	LongitudeOfTheAscendingNode: 0,
+	InclinationToTheEcliptic:    0.036 * deg,
+	OrbitalEccentricity:         0.0041,
+	SemimajorAxis:               421800,
+}, 330.9)
+
+// EuropaOrbit defines the standard orbit of Europa around Jupiter.
+var EuropaOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Europa",
+	ParentGrav:                  JupiterGrav + EuropaGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        45.0 * deg,
+	LongitudeOfTheAscendingNode: 184.0 * deg,
+	InclinationToTheEcliptic:    0.466 * deg,
+	OrbitalEccentricity:         0.0094,
+	SemimajorAxis:               671100,
+}, 345.4)
+
+// GanymedeOrbit defines the standard orbit of Ganymede around Jupiter.
+var GanymedeOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Ganymede",
+	ParentGrav:                  JupiterGrav + GanymedeGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        198.3 * deg,
+	LongitudeOfTheAscendingNode: 58.5 * deg,
+	InclinationToTheEcliptic:    0.177 * deg,
+	OrbitalEccentricity:         0.0013,
+	SemimajorAxis:               1070400,
+}, 324.8)
+
+// CallistoOrbit defines the standard orbit of Callisto around Jupiter.
+var CallistoOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Callisto",
+	ParentGrav:                  JupiterGrav + CallistoGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        43.8 * deg,
+	LongitudeOfTheAscendingNode: 309.1 * deg,
+	InclinationToTheEcliptic:    0.192 * deg,
+	OrbitalEccentricity:         0.0074,
+	SemimajorAxis:               1882700,
+}, 87.4)
+
+// TitanOrbit defines the standard orbit of Titan around Saturn.
+var TitanOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Titan",
+	ParentGrav:                  SaturnGrav + TitanGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        180.532 * deg,
+	LongitudeOfTheAscendingNode: 28.060 * deg,
+	InclinationToTheEcliptic:    0.306 * deg,
+	OrbitalEccentricity:         0.0288,
+	SemimajorAxis:               1221870,
+}, 163.310)
+
+// TritonOrbit defines the standard orbit of Triton around Neptune. It is retrograde.
+var TritonOrbit = withMeanAnomaly(orbcore.Orbit{
+	ID:                          "Triton",
+	ParentGrav:                  NeptuneGrav + TritonGrav,
+	Epoch:                       J2000Noon,
+	ArgumentOfPerihelion:        344.046 * deg,
+	LongitudeOfTheAscendingNode: 177.608 * deg,
+	InclinationToTheEcliptic:    156.865 * deg,
+	OrbitalEccentricity:         0.000016,
+	SemimajorAxis:               354759,
+}, 264.775)
 
-// OuterSolarSystem is a collection of major bodies in the outer solar system
-var OuterSolarSystem = []orbcore.Orbit{
-	JupiterOrbit,
-	SaturnOrbit,
-	UranusOrbit,
-	NeptuneOrbit,
+// DwarfPlanets is a collection of the dwarf planets and Vesta
+var DwarfPlanets = []orbcore.Orbit{
+	CeresOrbit,
+	VestaOrbit,
+	PlutoOrbit,
+	HaumeaOrbit,
+	MakemakeOrbit,
+	ErisOrbit,
 }
@@ -0,0 +1,310 @@
+package orbephem
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+magic starts every ephemeris file, the last byte is the format version. It is followed by one record per series, all
+little endian:
+
+	uint16   length of the ID
+	[]byte   ID
+	int64    start, unix seconds
+	uint32   start, nanoseconds
+	int64    end, unix seconds
+	uint32   end, nanoseconds
+	float64  tolerance in km
+	uint8    degree of the polynomials
+	uint32   number of pieces, n
+	float64  n+1 piece boundaries in unix seconds
+	float64  n * 3 * (degree+1) coefficients, x then y then z for each piece
+*/
+const magic = "ORBEPHM\x01"
+
+// maxPieces stops a damaged file from making the reader allocate everything it can.
+const maxPieces = 1 << 24
+
+/*
+Writer writes series to an ephemeris file.
+*/
+type Writer struct {
+	out  *bufio.Writer
+	file io.Closer
+}
+
+/*
+Create makes a new ephemeris file at path, gzip compressed if the path ends in .gz
+*/
+func Create(path string) (*Writer, error) {
+	f, err := orbcore.CreateCompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.file = f
+	return w, nil
+}
+
+/*
+NewWriter starts an ephemeris file on an existing writer. Close must still be called to flush it.
+*/
+func NewWriter(out io.Writer) (*Writer, error) {
+	w := Writer{out: bufio.NewWriter(out)}
+	if _, err := w.out.WriteString(magic); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+/*
+Write adds a series to the file.
+*/
+func (w *Writer) Write(s *Series) error {
+	if len(s.ID) > math.MaxUint16 {
+		return fmt.Errorf("id %.20q... is too long", s.ID)
+	}
+
+	values := []interface{}{
+		uint16(len(s.ID)),
+		[]byte(s.ID),
+		s.Start.Unix(),
+		uint32(s.Start.Nanosecond()),
+		s.End.Unix(),
+		uint32(s.End.Nanosecond()),
+		s.Tolerance,
+		uint8(Degree),
+		uint32(len(s.pieces)),
+	}
+	for _, v := range values {
+		if err := binary.Write(w.out, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	numbers := make([]float64, 0, len(s.pieces)+1+len(s.pieces)*3*(Degree+1))
+	for _, p := range s.pieces {
+		numbers = append(numbers, p.start)
+	}
+	if len(s.pieces) > 0 {
+		numbers = append(numbers, s.pieces[len(s.pieces)-1].end)
+	}
+	for _, p := range s.pieces {
+		numbers = append(numbers, p.x...)
+		numbers = append(numbers, p.y...)
+		numbers = append(numbers, p.z...)
+	}
+	return binary.Write(w.out, binary.LittleEndian, numbers)
+}
+
+/*
+Close flushes the file, and closes it if it was opened with Create.
+*/
+func (w *Writer) Close() error {
+	if err := w.out.Flush(); err != nil {
+		return err
+	}
+	if w.file != nil {
+		return w.file.Close()
+	}
+	return nil
+}
+
+/*
+Reader reads series back out of an ephemeris file.
+*/
+type Reader struct {
+	in    *bufio.Reader
+	file  io.Closer
+	entry int
+}
+
+/*
+NewReader opens an ephemeris file. Gzip and bzip2 compressed files are decompressed automatically.
+*/
+func NewReader(path string) (*Reader, error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReaderFromReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	r.file = f
+	return r, nil
+}
+
+/*
+NewReaderFromReader reads an ephemeris file from an existing reader.
+*/
+func NewReaderFromReader(in io.Reader) (*Reader, error) {
+	r := Reader{in: bufio.NewReader(in)}
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(r.in, header); err != nil {
+		return nil, fmt.Errorf("could not read header: %v", err)
+	}
+	if string(header) != magic {
+		return nil, fmt.Errorf("not an ephemeris file, header is %q", header)
+	}
+	return &r, nil
+}
+
+/*
+ReadEntry returns the next series in the file. io.EOF is returned when there are no more.
+*/
+func (r *Reader) ReadEntry() (*Series, error) {
+	var idLength uint16
+	if err := binary.Read(r.in, binary.LittleEndian, &idLength); err != nil {
+		return nil, err
+	}
+	r.entry++
+
+	s, err := r.readSeries(int(idLength))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("entry %d: %v", r.entry, err)
+	}
+	return s, nil
+}
+
+func (r *Reader) readSeries(idLength int) (*Series, error) {
+	id := make([]byte, idLength)
+	if _, err := io.ReadFull(r.in, id); err != nil {
+		return nil, err
+	}
+
+	var header struct {
+		StartSeconds int64
+		StartNanos   uint32
+		EndSeconds   int64
+		EndNanos     uint32
+		Tolerance    float64
+		Degree       uint8
+		Pieces       uint32
+	}
+	if err := binary.Read(r.in, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.Degree != Degree {
+		return nil, fmt.Errorf("%s: polynomials of degree %d are not supported", id, header.Degree)
+	}
+
+	n := int(header.Pieces)
+	if n > maxPieces {
+		return nil, fmt.Errorf("%s: %d pieces is more than the %d allowed", id, n, maxPieces)
+	}
+	size := Degree + 1
+	var boundaries []float64
+	if n > 0 {
+		boundaries = make([]float64, n+1)
+		if err := binary.Read(r.in, binary.LittleEndian, boundaries); err != nil {
+			return nil, err
+		}
+	}
+
+	s := Series{
+		ID:        string(id),
+		Start:     time.Unix(header.StartSeconds, int64(header.StartNanos)).UTC(),
+		End:       time.Unix(header.EndSeconds, int64(header.EndNanos)).UTC(),
+		Tolerance: header.Tolerance,
+		pieces:    make([]piece, n),
+	}
+	for i := range s.pieces {
+		coeffs := make([]float64, 3*size)
+		if err := binary.Read(r.in, binary.LittleEndian, coeffs); err != nil {
+			return nil, err
+		}
+		s.pieces[i] = piece{
+			start: boundaries[i],
+			end:   boundaries[i+1],
+			x:     coeffs[:size],
+			y:     coeffs[size : 2*size],
+			z:     coeffs[2*size:],
+		}
+	}
+	return &s, nil
+}
+
+/*
+Close closes the file if it was opened with NewReader.
+*/
+func (r *Reader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+/*
+Set is every series from an ephemeris file, looked up by ID.
+*/
+type Set struct {
+	series []*Series
+	ids    map[string]*Series
+}
+
+/*
+Load reads a whole ephemeris file into memory.
+*/
+func Load(path string) (*Set, error) {
+	r, err := NewReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	set := Set{ids: make(map[string]*Series)}
+	s, err := r.ReadEntry()
+	for err == nil {
+		set.series = append(set.series, s)
+		set.ids[s.ID] = s
+		s, err = r.ReadEntry()
+	}
+	if err != io.EOF {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return &set, nil
+}
+
+/*
+Lookup finds the series for an object ID.
+*/
+func (s *Set) Lookup(id string) (*Series, bool) {
+	result, ok := s.ids[id]
+	return result, ok
+}
+
+/*
+Series returns every series in the order they were in the file. The slice is shared and must not be modified.
+*/
+func (s *Set) Series() []*Series {
+	return s.series
+}
+
+/*
+Positions returns the position of every object in the set at time t, skipping any that do not cover t.
+*/
+func (s *Set) Positions(t time.Time) []*orbcore.Position {
+	result := make([]*orbcore.Position, 0, len(s.series))
+	for _, series := range s.series {
+		if pos, err := series.Position(t); err == nil {
+			result = append(result, pos)
+		}
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package orbephem
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+func fitAll(t *testing.T) []*Series {
+	var result []*Series
+	for _, orbit := range orbdata.InnerSolarSystem {
+		orbit := orbit
+		s, err := Fit(&orbit, start, end, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+func TestWriteRead(t *testing.T) {
+	series := fitAll(t)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range series {
+		if err := w.Write(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReaderFromReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range series {
+		s, err := r.ReadEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.ID != expected.ID || !s.Start.Equal(expected.Start) || !s.End.Equal(expected.End) || s.Tolerance != expected.Tolerance || s.Pieces() != expected.Pieces() {
+			t.Errorf("expected %+v got %+v", expected, s)
+		}
+		at := start.AddDate(0, 3, 7)
+		a, _ := expected.Position(at)
+		b, _ := s.Position(at)
+		if *a != *b {
+			t.Errorf("expected %v got %v", a, b)
+		}
+	}
+	if _, err := r.ReadEntry(); err != io.EOF {
+		t.Errorf("expected EOF got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orbephem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "inner.ephem.gz")
+
+	w, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range fitAll(t) {
+		if err := w.Write(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Series()) != 4 {
+		t.Errorf("expected 4 series got %v", len(set.Series()))
+	}
+	if _, ok := set.Lookup("Mars"); !ok {
+		t.Error("could not find Mars")
+	}
+	if positions := set.Positions(start.AddDate(1, 0, 0)); len(positions) != 4 {
+		t.Errorf("expected 4 positions got %v", len(positions))
+	}
+	if positions := set.Positions(end.AddDate(1, 0, 0)); len(positions) != 0 {
+		t.Errorf("expected no positions outside the range got %v", len(positions))
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	if _, err := NewReaderFromReader(strings.NewReader("not an ephemeris")); err == nil {
+		t.Error("expected an error for a bad header")
+	}
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+	w.Write(fitAll(t)[0])
+	w.Close()
+	truncated := buf.Bytes()[:buf.Len()-10]
+
+	r, err := NewReaderFromReader(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadEntry(); err == nil || err == io.EOF {
+		t.Errorf("expected an error for a truncated file got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Package orbephem fits piecewise Chebyshev polynomials to the paths of objects over a date range so their positions can
+be looked up at any time in the range without propagating the orbit again. Fits are written to and read from a
+compact binary file.
+*/
+package orbephem
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+// Degree is the degree of the Chebyshev polynomial fitted to each piece of a path.
+const Degree = 12
+
+// minPiece is the shortest piece a path will be split into, a piece this short that is still not within tolerance is an
+// error.
+const minPiece = 60.0
+
+/*
+PositionFunc gives the position of an object at a time in km.
+*/
+type PositionFunc func(t time.Time) (x, y, z float64)
+
+/*
+Series is the fitted path of one object. Positions anywhere between Start and End are within Tolerance km of the
+function the series was fitted to, checked at points between the ones the fit was made from.
+*/
+type Series struct {
+	ID        string
+	Start     time.Time
+	End       time.Time
+	Tolerance float64
+
+	pieces []piece
+}
+
+// piece is one Chebyshev fit covering start to end, in unix seconds.
+type piece struct {
+	start, end float64
+	x, y, z    []float64
+}
+
+/*
+Fit fits a series to the path of an orbit between start and end. The orbit is propagated with orbcore.MeanMotion so
+the series reproduces exactly what the rest of orbcalc would give. Pieces are never longer than a sixteenth of the
+orbital period.
+*/
+func Fit(orbit *orbcore.Orbit, start, end time.Time, tolerance float64) (*Series, error) {
+	maxPiece := end.Sub(start)
+	if orbit.OrbitalEccentricity < 1 && orbit.SemimajorAxis > 0 {
+		if period := orbcore.OrbitalPeriod(orbit) / 16; period < maxPiece {
+			maxPiece = period
+		}
+	}
+
+	return FitFunc(orbit.ID, start, end, maxPiece, tolerance, func(t time.Time) (float64, float64, float64) {
+		r, _ := orbcore.OrbitToVector(orbcore.MeanMotionToDate(orbit, t))
+		return r.AtVec(0), r.AtVec(1), r.AtVec(2)
+	})
+}
+
+/*
+FitFunc fits a series to any function of time. The range is first cut into pieces no longer than maxPiece and then
+each piece is split in half until it fits within tolerance km. An error is returned if a piece a minute long still does
+not fit, rather than giving a series that is further from the function than its Tolerance.
+*/
+func FitFunc(id string, start, end time.Time, maxPiece time.Duration, tolerance float64, fn PositionFunc) (*Series, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("%v: end %v is not after start %v", id, end, start)
+	}
+	if tolerance <= 0 {
+		return nil, fmt.Errorf("%v: tolerance must be positive", id)
+	}
+
+	s := Series{ID: id, Start: start, End: end, Tolerance: tolerance}
+	from, to := unixSeconds(start), unixSeconds(end)
+	count := 1
+	if maxPiece > 0 {
+		count = int(math.Ceil((to - from) / maxPiece.Seconds()))
+	}
+	for i := 0; i < count; i++ {
+		a := from + (to-from)*float64(i)/float64(count)
+		b := from + (to-from)*float64(i+1)/float64(count)
+		if err := s.fit(a, b, fn); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// fit adds pieces covering a to b, splitting the range until each piece is within tolerance.
+func (s *Series) fit(a, b float64, fn PositionFunc) error {
+	p := fitPiece(a, b, fn)
+	maxError := p.maxError(fn)
+	if maxError <= s.Tolerance {
+		s.pieces = append(s.pieces, p)
+		return nil
+	}
+	if b-a <= minPiece {
+		return fmt.Errorf("%v: could not fit within %v km at %v, the closest fit is %v km away", s.ID, s.Tolerance,
+			fromUnixSeconds(a), maxError)
+	}
+	mid := (a + b) / 2
+	if err := s.fit(a, mid, fn); err != nil {
+		return err
+	}
+	return s.fit(mid, b, fn)
+}
+
+// fitPiece interpolates fn at the Chebyshev nodes of a to b.
+func fitPiece(a, b float64, fn PositionFunc) piece {
+	n := Degree + 1
+	values := make([][3]float64, n)
+	for k := 0; k < n; k++ {
+		x, y, z := fn(fromUnixSeconds(scale(a, b, node(k, n))))
+		values[k] = [3]float64{x, y, z}
+	}
+
+	p := piece{start: a, end: b, x: make([]float64, n), y: make([]float64, n), z: make([]float64, n)}
+	for j := 0; j < n; j++ {
+		var sx, sy, sz float64
+		for k := 0; k < n; k++ {
+			c := math.Cos(math.Pi * float64(j) * (float64(k) + 0.5) / float64(n))
+			sx += values[k][0] * c
+			sy += values[k][1] * c
+			sz += values[k][2] * c
+		}
+		f := 2 / float64(n)
+		if j == 0 {
+			f = 1 / float64(n)
+		}
+		p.x[j], p.y[j], p.z[j] = sx*f, sy*f, sz*f
+	}
+	return p
+}
+
+// maxError compares the piece with fn half way between each pair of nodes and at both ends.
+func (p *piece) maxError(fn PositionFunc) float64 {
+	n := Degree + 1
+	check := []float64{-1, 1}
+	for k := 0; k < n-1; k++ {
+		check = append(check, (node(k, n)+node(k+1, n))/2)
+	}
+
+	worst := 0.0
+	for _, c := range check {
+		x, y, z := fn(fromUnixSeconds(scale(p.start, p.end, c)))
+		px, py, pz := p.at(scale(p.start, p.end, c))
+		worst = math.Max(worst, math.Sqrt((x-px)*(x-px)+(y-py)*(y-py)+(z-pz)*(z-pz)))
+	}
+	return worst
+}
+
+// at evaluates the piece at unix time t.
+func (p *piece) at(t float64) (float64, float64, float64) {
+	u := (2*t - p.start - p.end) / (p.end - p.start)
+	return clenshaw(p.x, u), clenshaw(p.y, u), clenshaw(p.z, u)
+}
+
+/*
+Position returns the position of the object at time t, or an error if t is outside of the series.
+*/
+func (s *Series) Position(t time.Time) (*orbcore.Position, error) {
+	x, y, z, err := s.At(t)
+	if err != nil {
+		return nil, err
+	}
+	return &orbcore.Position{ID: s.ID, Epoch: t, X: x, Y: y, Z: z}, nil
+}
+
+/*
+At returns the position of the object at time t as separate coordinates without allocating.
+*/
+func (s *Series) At(t time.Time) (float64, float64, float64, error) {
+	u := unixSeconds(t)
+	if len(s.pieces) == 0 || u < s.pieces[0].start || u > s.pieces[len(s.pieces)-1].end {
+		return 0, 0, 0, fmt.Errorf("%v: %v is outside of %v to %v", s.ID, t, s.Start, s.End)
+	}
+	i := sort.Search(len(s.pieces), func(i int) bool {
+		return s.pieces[i].end >= u
+	})
+	x, y, z := s.pieces[i].at(u)
+	return x, y, z, nil
+}
+
+/*
+Pieces returns the number of polynomials the series is made of.
+*/
+func (s *Series) Pieces() int {
+	return len(s.pieces)
+}
+
+// clenshaw sums a Chebyshev series at u between -1 and 1.
+func clenshaw(coeffs []float64, u float64) float64 {
+	var b1, b2 float64
+	for j := len(coeffs) - 1; j >= 1; j-- {
+		b1, b2 = 2*u*b1-b2+coeffs[j], b1
+	}
+	return u*b1 - b2 + coeffs[0]
+}
+
+// node is the kth of n Chebyshev nodes between -1 and 1.
+func node(k, n int) float64 {
+	return math.Cos(math.Pi * (float64(k) + 0.5) / float64(n))
+}
+
+// scale maps u between -1 and 1 onto a to b.
+func scale(a, b, u float64) float64 {
+	return (a+b)/2 + u*(b-a)/2
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/1e9
+}
+
+func fromUnixSeconds(s float64) time.Time {
+	whole := math.Floor(s)
+	return time.Unix(int64(whole), int64((s-whole)*1e9)).UTC()
+}
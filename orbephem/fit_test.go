@@ -0,0 +1,106 @@
+package orbephem
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+var start = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+var end = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func checkSeries(t *testing.T, orbit *orbcore.Orbit, s *Series) {
+	for at := start; !at.After(end); at = at.Add(7*time.Hour + 13*time.Minute) {
+		pos, err := s.Position(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := orbcore.OrbitToPosition(orbcore.MeanMotionToDate(orbit, at))
+		d := math.Sqrt(math.Pow(pos.X-expected.X, 2) + math.Pow(pos.Y-expected.Y, 2) + math.Pow(pos.Z-expected.Z, 2))
+		if d > s.Tolerance {
+			t.Fatalf("%v at %v: %v km away from the orbit, tolerance is %v", orbit.ID, at, d, s.Tolerance)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	orbits := []*orbcore.Orbit{&orbdata.CeresOrbit, &orbdata.MercuryOrbit, &orbdata.PlutoOrbit}
+	for _, orbit := range orbits {
+		s, err := Fit(orbit, start, end, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.ID != orbit.ID || !s.Start.Equal(start) || !s.End.Equal(end) {
+			t.Errorf("unexpected series %v %v %v", s.ID, s.Start, s.End)
+		}
+		checkSeries(t, orbit, s)
+	}
+}
+
+func TestFitTighterNeedsMorePieces(t *testing.T) {
+	mercury := func(t time.Time) (float64, float64, float64) {
+		pos := orbcore.OrbitToPosition(orbcore.MeanMotionToDate(&orbdata.MercuryOrbit, t))
+		return pos.X, pos.Y, pos.Z
+	}
+	loose, _ := FitFunc("Mercury", start, end, 0, 1000, mercury)
+	tight, _ := FitFunc("Mercury", start, end, 0, 0.01, mercury)
+	if tight.Pieces() <= loose.Pieces() {
+		t.Errorf("expected more pieces for a tighter fit, got %v and %v", tight.Pieces(), loose.Pieces())
+	}
+	checkSeries(t, &orbdata.MercuryOrbit, tight)
+}
+
+func TestOutsideRange(t *testing.T) {
+	s, _ := Fit(&orbdata.CeresOrbit, start, end, 1)
+	if _, err := s.Position(start.Add(-time.Second)); err == nil {
+		t.Error("expected an error before the start")
+	}
+	if _, err := s.Position(end.Add(time.Second)); err == nil {
+		t.Error("expected an error after the end")
+	}
+	if _, err := s.Position(end); err != nil {
+		t.Errorf("expected the end to be covered: %v", err)
+	}
+}
+
+func TestFitErrors(t *testing.T) {
+	if _, err := Fit(&orbdata.CeresOrbit, end, start, 1); err == nil {
+		t.Error("expected an error for a backwards range")
+	}
+	if _, err := Fit(&orbdata.CeresOrbit, start, end, 0); err == nil {
+		t.Error("expected an error for a zero tolerance")
+	}
+
+	jump := start.Add(100 * 24 * time.Hour)
+	step := func(t time.Time) (float64, float64, float64) {
+		if t.Before(jump) {
+			return 0, 0, 0
+		}
+		return 1000, 0, 0
+	}
+	if _, err := FitFunc("step", start, end, 0, 1, step); err == nil {
+		t.Error("expected an error when the tolerance can not be met")
+	}
+}
+
+func TestClenshaw(t *testing.T) {
+	coeffs := []float64{1, 2, 3, 4}
+	for _, u := range []float64{-1, -0.25, 0, 0.7, 1} {
+		expected := 1 + 2*u + 3*(2*u*u-1) + 4*(4*u*u*u-3*u)
+		if r := clenshaw(coeffs, u); math.Abs(r-expected) > 1e-12 {
+			t.Errorf("at %v expected %v got %v", u, expected, r)
+		}
+	}
+}
+
+func BenchmarkPosition(b *testing.B) {
+	s, _ := Fit(&orbdata.CeresOrbit, start, end, 1)
+	at := start.Add(400 * 24 * time.Hour)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.At(at)
+	}
+}
@@ -0,0 +1,469 @@
+/*
+Package orbhorizons reads the text output of the JPL Horizons system so saved ephemerides can be compared with what
+orbcalc works out.
+
+VECTORS tables are understood in both the default layout and with CSV_FORMAT=YES. OBSERVER tables need CSV_FORMAT=YES
+as the default layout depends on which quantities were requested.
+*/
+package orbhorizons
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+/*
+TableKind says which sort of Horizons table was read.
+*/
+type TableKind string
+
+/*
+The Horizons table types that can be read.
+*/
+const (
+	Vectors  TableKind = "VECTORS"
+	Observer TableKind = "OBSERVER"
+)
+
+/*
+Table is a parsed Horizons ephemeris. Header holds every "key : value" line from before the data, keyed on the trimmed
+key. Only one of Vectors and Observations is filled in depending on Kind.
+
+Each row of a VECTORS table is a Position in km and km/s relative to the center body in the J2000 ecliptic frame,
+whatever the units and frame of the table were, with the target as its ID. HasVelocity is false for position only
+tables.
+*/
+type Table struct {
+	Kind         TableKind
+	Target       string
+	Center       string
+	Header       map[string]string
+	Vectors      []*orbcore.Position
+	Observations []*Observation
+}
+
+/*
+Observation is one row of an OBSERVER table. Right ascension and declination are in radians. Delta is the distance
+from the observer in km and DeltaDot its rate of change in km/s. Values the table did not include are NaN.
+*/
+type Observation struct {
+	Epoch          time.Time
+	RightAscension float64
+	Declination    float64
+	Magnitude      float64
+	Delta          float64
+	DeltaDot       float64
+}
+
+/*
+ReadFile reads a Horizons table from a file. Gzip and bzip2 compressed files are decompressed automatically.
+*/
+func ReadFile(path string) (table *Table, err error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(c io.Closer) {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}(f)
+
+	table, err = Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return table, nil
+}
+
+/*
+Read parses a Horizons table. Everything before $$SOE is treated as header and everything after $$EOE is ignored.
+*/
+func Read(in io.Reader) (*Table, error) {
+	p := parser{table: Table{Header: make(map[string]string)}}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	state := inHeader
+	for scanner.Scan() && state != done {
+		p.line++
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+
+		switch {
+		case state == inHeader && trimmed == "$$SOE":
+			if err := p.start(); err != nil {
+				return nil, fmt.Errorf("line %d: %v", p.line, err)
+			}
+			state = inData
+		case state == inHeader:
+			p.header(text)
+		case trimmed == "$$EOE":
+			state = done
+		case trimmed != "":
+			if err := p.data(trimmed); err != nil {
+				return nil, fmt.Errorf("line %d: %v", p.line, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	switch state {
+	case inHeader:
+		return nil, fmt.Errorf("no $$SOE marker found")
+	case inData:
+		return nil, fmt.Errorf("no $$EOE marker found")
+	}
+	p.flush()
+	return &p.table, nil
+}
+
+/*
+Positions returns the positions from a VECTORS table with the target name as their ID. The slice is shared with
+Vectors.
+*/
+func (t *Table) Positions() []*orbcore.Position {
+	return t.Vectors
+}
+
+const (
+	inHeader = iota
+	inData
+	done
+)
+
+type parser struct {
+	table   Table
+	line    int
+	columns []string // from the line above $$SOE, only used for CSV tables
+	csv     bool
+
+	// scaling to km and km/s
+	distance, speed float64
+	equatorial      bool
+
+	pending *orbcore.Position // a default layout vector that is still being read
+}
+
+func (p *parser) header(text string) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || strings.HasPrefix(trimmed, "*") {
+		return
+	}
+	p.columns = splitCSV(trimmed)
+
+	colon := strings.Index(text, ":")
+	if colon <= 0 {
+		return
+	}
+	key := strings.TrimSpace(text[:colon])
+	if key == "" || strings.ContainsAny(key, ",_") {
+		return
+	}
+	value := strings.TrimSpace(text[colon+1:])
+	if _, ok := p.table.Header[key]; !ok {
+		p.table.Header[key] = value
+	}
+}
+
+// start works out what sort of table follows from the header once $$SOE is reached.
+func (p *parser) start() error {
+	p.table.Target = bodyName(p.table.Header["Target body name"])
+	p.table.Center = bodyName(p.table.Header["Center body name"])
+
+	header := strings.Join(p.columns, ",")
+	switch {
+	case strings.Contains(header, "R.A."):
+		p.table.Kind = Observer
+		if len(p.columns) < 2 {
+			return fmt.Errorf("observer tables must be written with CSV_FORMAT=YES")
+		}
+		p.csv = true
+	default:
+		p.table.Kind = Vectors
+		p.csv = len(p.columns) > 1 && contains(p.columns, "X")
+	}
+
+	p.distance, p.speed = 1, 1
+	switch units := strings.ToUpper(p.table.Header["Output units"]); {
+	case strings.HasPrefix(units, "AU-D"):
+		p.distance, p.speed = orbdata.AU, orbdata.AU/86400.0
+	case strings.HasPrefix(units, "KM-D"):
+		p.speed = 1.0 / 86400
+	case units == "" || strings.HasPrefix(units, "KM-S"):
+	default:
+		return fmt.Errorf("unknown output units %q", units)
+	}
+
+	for _, key := range []string{"Reference plane", "Coordinate systm", "Reference frame"} {
+		frame := strings.ToLower(p.table.Header[key])
+		if strings.Contains(frame, "ecliptic") {
+			break
+		}
+		if strings.Contains(frame, "equator") {
+			p.equatorial = true
+			break
+		}
+	}
+	return nil
+}
+
+func (p *parser) data(line string) error {
+	if p.table.Kind == Observer {
+		obs, err := p.observation(splitCSV(line))
+		if err != nil {
+			return err
+		}
+		p.table.Observations = append(p.table.Observations, obs)
+		return nil
+	}
+	if p.csv {
+		return p.vectorCSV(splitCSV(line))
+	}
+	return p.vectorText(line)
+}
+
+func (p *parser) vectorCSV(fields []string) error {
+	v := orbcore.Position{}
+	values := map[string]*float64{"X": &v.X, "Y": &v.Y, "Z": &v.Z, "VX": &v.VX, "VY": &v.VY, "VZ": &v.VZ}
+	found := false
+	for i, name := range p.columns {
+		if i >= len(fields) {
+			break
+		}
+		if name == "JDTDB" {
+			jd, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return fmt.Errorf("could not parse julian date %q: %v", fields[i], err)
+			}
+			v.Epoch = fromJDTDB(jd)
+			found = true
+			continue
+		}
+		if target, ok := values[name]; ok {
+			f, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return fmt.Errorf("could not parse %v %q: %v", name, fields[i], err)
+			}
+			*target = f
+			if strings.HasPrefix(name, "V") {
+				v.HasVelocity = true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("vector table has no JDTDB column")
+	}
+	p.addVector(&v)
+	return nil
+}
+
+var vectorValue = regexp.MustCompile(`([A-Z]{1,2})\s*=\s*([-+]?[0-9.]+(?:[Ee][-+]?[0-9]+)?)`)
+
+// vectorText reads the default vector layout where each row starts with "JD = A.D. date TDB" and the values follow
+// on the next few lines as "X = value" pairs.
+func (p *parser) vectorText(line string) error {
+	if jd, ok := leadingJulianDate(line); ok {
+		p.flush()
+		p.pending = &orbcore.Position{Epoch: fromJDTDB(jd)}
+		return nil
+	}
+	if p.pending == nil {
+		return fmt.Errorf("values before the first date: %q", line)
+	}
+
+	for _, m := range vectorValue.FindAllStringSubmatch(line, -1) {
+		f, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return fmt.Errorf("could not parse %v %q: %v", m[1], m[2], err)
+		}
+		switch m[1] {
+		case "X":
+			p.pending.X = f
+		case "Y":
+			p.pending.Y = f
+		case "Z":
+			p.pending.Z = f
+		case "VX":
+			p.pending.VX, p.pending.HasVelocity = f, true
+		case "VY":
+			p.pending.VY = f
+		case "VZ":
+			p.pending.VZ = f
+		}
+	}
+	return nil
+}
+
+func (p *parser) flush() {
+	if p.pending != nil {
+		p.addVector(p.pending)
+		p.pending = nil
+	}
+}
+
+// addVector converts a vector to km, km/s and the ecliptic frame and adds it to the table.
+func (p *parser) addVector(v *orbcore.Position) {
+	v.ID, v.Center = p.table.Target, p.table.Center
+	v.X, v.Y, v.Z = v.X*p.distance, v.Y*p.distance, v.Z*p.distance
+	v.VX, v.VY, v.VZ = v.VX*p.speed, v.VY*p.speed, v.VZ*p.speed
+	if p.equatorial {
+		v.X, v.Y, v.Z = toEcliptic(v.X, v.Y, v.Z)
+		v.VX, v.VY, v.VZ = toEcliptic(v.VX, v.VY, v.VZ)
+	}
+	p.table.Vectors = append(p.table.Vectors, v)
+}
+
+func (p *parser) observation(fields []string) (*Observation, error) {
+	obs := Observation{
+		RightAscension: math.NaN(),
+		Declination:    math.NaN(),
+		Magnitude:      math.NaN(),
+		Delta:          math.NaN(),
+		DeltaDot:       math.NaN(),
+	}
+
+	found := false
+	for i, name := range p.columns {
+		if i >= len(fields) || fields[i] == "" || fields[i] == "n.a." {
+			continue
+		}
+		field := fields[i]
+		var err error
+		switch {
+		case strings.HasPrefix(name, "Date") && strings.Contains(name, "JD"):
+			var jd float64
+			if jd, err = strconv.ParseFloat(field, 64); err == nil {
+				obs.Epoch = orbcore.TimeFromJulianDate(jd)
+				found = true
+			}
+		case strings.HasPrefix(name, "Date"):
+			if obs.Epoch, err = parseCalendarDate(field); err == nil {
+				found = true
+			}
+		case strings.HasPrefix(name, "R.A."):
+			obs.RightAscension, err = parseAngle(field, 15)
+		case strings.HasPrefix(name, "DEC"):
+			obs.Declination, err = parseAngle(field, 1)
+		case name == "APmag" || name == "T-mag":
+			obs.Magnitude, err = strconv.ParseFloat(field, 64)
+		case name == "delta":
+			obs.Delta, err = strconv.ParseFloat(field, 64)
+			obs.Delta *= orbdata.AU
+		case name == "deldot":
+			obs.DeltaDot, err = strconv.ParseFloat(field, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %v %q: %v", name, field, err)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("observer table has no date column")
+	}
+	return &obs, nil
+}
+
+var julianDate = regexp.MustCompile(`^([0-9]+\.[0-9]+)\s*=`)
+
+func leadingJulianDate(line string) (float64, bool) {
+	m := julianDate.FindStringSubmatch(line)
+	if m == nil {
+		return 0, false
+	}
+	jd, err := strconv.ParseFloat(m[1], 64)
+	return jd, err == nil
+}
+
+// fromJDTDB converts a barycentric dynamical time julian date into UTC.
+func fromJDTDB(jd float64) time.Time {
+	return orbcore.TimeFromEphemerisTime((jd - 2451545.0) * 86400)
+}
+
+var calendarLayouts = []string{
+	"2006-Jan-02 15:04:05.000",
+	"2006-Jan-02 15:04:05",
+	"2006-Jan-02 15:04",
+	"2006-Jan-02",
+}
+
+// parseCalendarDate reads Horizons calendar dates like "2019-Jan-01 00:00" which are UT in observer tables.
+func parseCalendarDate(in string) (time.Time, error) {
+	in = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(in), "A.D."))
+	for _, layout := range calendarLayouts {
+		if t, err := time.Parse(layout, in); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format")
+}
+
+// parseAngle reads either decimal degrees or sexagesimal "sDD MM SS.ss" where the first field is multiplied by scale
+// to get degrees, 15 for hours of right ascension.
+func parseAngle(in string, scale float64) (float64, error) {
+	parts := strings.Fields(in)
+	if len(parts) == 1 {
+		v, err := strconv.ParseFloat(parts[0], 64)
+		return v * math.Pi / 180, err
+	}
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("expected one or three fields got %d", len(parts))
+	}
+
+	negative := strings.HasPrefix(parts[0], "-")
+	result := 0.0
+	divisor := 1.0
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimLeft(part, "+-"), 64)
+		if err != nil {
+			return 0, err
+		}
+		result += v / divisor
+		divisor *= 60
+	}
+	if negative {
+		result = -result
+	}
+	return result * scale * math.Pi / 180, nil
+}
+
+// bodyName strips the source note from a header value, "1 Ceres (A801 AA)   {source: JPL#48}" gives
+// "1 Ceres (A801 AA)".
+func bodyName(in string) string {
+	if i := strings.Index(in, "{"); i >= 0 {
+		in = in[:i]
+	}
+	return strings.TrimSpace(in)
+}
+
+func splitCSV(line string) []string {
+	fields := strings.Split(line, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toEcliptic(x, y, z float64) (float64, float64, float64) {
+	c, s := math.Cos(orbdata.Obliquity), math.Sin(orbdata.Obliquity)
+	return x, c*y + s*z, -s*y + c*z
+}
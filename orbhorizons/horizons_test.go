@@ -0,0 +1,177 @@
+package orbhorizons
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+const vectorHeader = `*******************************************************************************
+Ephemeris / WWW_USER Sat Jun 15 10:21:52 2019 Pasadena, USA      / Horizons
+*******************************************************************************
+Target body name: 1 Ceres (A801 AA)               {source: JPL#47}
+Center body name: Sun (10)                        {source: DE431}
+Center-site name: BODY CENTER
+*******************************************************************************
+Start time      : A.D. 2019-Jan-01 00:00:00.0000 TDB
+Stop  time      : A.D. 2019-Jan-03 00:00:00.0000 TDB
+Step-size       : 1440 minutes
+*******************************************************************************
+Output units    : %UNITS%
+Output type     : GEOMETRIC cartesian states
+Output format   : 3 (position, velocity, LT, range, range-rate)
+Reference frame : ICRF/J2000.0
+Coordinate systm: %FRAME%
+*******************************************************************************
+`
+
+const vectorText = `JDTDB
+   X     Y     Z
+   VX    VY    VZ
+   LT    RG    RR
+*******************************************************************************
+$$SOE
+2458484.500000000 = A.D. 2019-Jan-01 00:00:00.0000 TDB
+ X = 1.234567890123456E+08 Y =-3.456789012345678E+08 Z =-2.345678901234567E+07
+ VX= 1.555555555555555E+01 VY= 5.111111111111111E+00 VZ=-2.777777777777777E+00
+ LT= 1.222222222222222E+03 RG= 3.670000000000000E+08 RR=-1.111111111111111E-01
+2458485.500000000 = A.D. 2019-Jan-02 00:00:00.0000 TDB
+ X = 1.248000000000000E+08 Y =-3.452000000000000E+08 Z =-2.370000000000000E+07
+ VX= 1.560000000000000E+01 VY= 5.200000000000000E+00 VZ=-2.780000000000000E+00
+ LT= 1.222000000000000E+03 RG= 3.671000000000000E+08 RR=-1.100000000000000E-01
+$$EOE
+*******************************************************************************
+Coordinate system description:
+`
+
+const vectorCSV = `            JDTDB,            Calendar Date (TDB),                      X,                      Y,                      Z,                     VX,                     VY,                     VZ,
+**************************************************************************************************************************************************************************************************
+$$SOE
+2458484.500000000, A.D. 2019-Jan-01 00:00:00.0000,  1.234567890123456E+08, -3.456789012345678E+08, -2.345678901234567E+07,  1.555555555555555E+01,  5.111111111111111E+00, -2.777777777777777E+00,
+2458485.500000000, A.D. 2019-Jan-02 00:00:00.0000,  1.248000000000000E+08, -3.452000000000000E+08, -2.370000000000000E+07,  1.560000000000000E+01,  5.200000000000000E+00, -2.780000000000000E+00,
+$$EOE
+`
+
+func vectors(units, frame, body string) string {
+	h := strings.Replace(vectorHeader, "%UNITS%", units, 1)
+	return strings.Replace(h, "%FRAME%", frame, 1) + body
+}
+
+func TestReadVectors(t *testing.T) {
+	for _, body := range []string{vectorText, vectorCSV} {
+		table, err := Read(strings.NewReader(vectors("KM-S", "Ecliptic of J2000.0", body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if table.Kind != Vectors || table.Target != "1 Ceres (A801 AA)" || table.Center != "Sun (10)" {
+			t.Errorf("unexpected table %v %v %v", table.Kind, table.Target, table.Center)
+		}
+		if table.Header["Step-size"] != "1440 minutes" {
+			t.Errorf("expected step size in the header got %q", table.Header["Step-size"])
+		}
+		if len(table.Vectors) != 2 {
+			t.Fatalf("expected 2 vectors got %v", len(table.Vectors))
+		}
+
+		v := table.Vectors[0]
+		if v.X != 1.234567890123456e8 || v.Y != -3.456789012345678e8 || v.Z != -2.345678901234567e7 {
+			t.Errorf("unexpected position %+v", v)
+		}
+		if !v.HasVelocity || v.VX != 1.555555555555555e1 || v.VZ != -2.777777777777777 {
+			t.Errorf("unexpected velocity %+v", v)
+		}
+
+		// 2019-01-01 00:00 TDB is 69.184 seconds before midnight UTC.
+		expected := time.Date(2018, 12, 31, 23, 58, 50, 816000000, time.UTC)
+		if d := v.Epoch.Sub(expected); d > time.Millisecond || d < -time.Millisecond {
+			t.Errorf("expected epoch %v got %v", expected, v.Epoch)
+		}
+
+		positions := table.Positions()
+		if len(positions) != 2 || positions[1].ID != "1 Ceres (A801 AA)" || positions[1].Center != "Sun (10)" || positions[1].X != 1.248e8 {
+			t.Errorf("unexpected positions %v", positions)
+		}
+	}
+}
+
+func TestReadVectorsAUAndEquatorial(t *testing.T) {
+	table, err := Read(strings.NewReader(vectors("AU-D", "Earth Mean Equator and Equinox of Reference Epoch", vectorCSV)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := table.Vectors[0]
+	x, y, z := 1.234567890123456e8*orbdata.AU, -3.456789012345678e8*orbdata.AU, -2.345678901234567e7*orbdata.AU
+	c, s := math.Cos(orbdata.Obliquity), math.Sin(orbdata.Obliquity)
+	if math.Abs(v.X/x-1) > 1e-12 || math.Abs(v.Y/(c*y+s*z)-1) > 1e-12 || math.Abs(v.Z/(-s*y+c*z)-1) > 1e-12 {
+		t.Errorf("unexpected position %+v", v)
+	}
+	if math.Abs(v.VX-1.555555555555555e1*orbdata.AU/86400.0) > 1e-6 {
+		t.Errorf("expected velocity in km/s got %v", v.VX)
+	}
+}
+
+const observerCSV = `*******************************************************************************
+Ephemeris / WWW_USER Sat Jun 15 10:30:11 2019 Pasadena, USA      / Horizons
+*******************************************************************************
+Target body name: 1 Ceres (A801 AA)               {source: JPL#47}
+Center body name: Earth (399)                     {source: DE431}
+Center-site name: GEOCENTRIC
+*******************************************************************************
+ Date__(UT)__HR:MN, , , R.A._(ICRF), DEC__(ICRF), APmag, S-brt,             delta,      deldot,
+***************************************************************************************************
+$$SOE
+ 2019-Jan-01 00:00, , , 15 40 32.13, -13 04 51.5,  8.91,  6.83,  3.29812345678901, -25.4838200,
+ 2019-Jan-02 00:00,*, , 15 43 09.25, -13 13 43.6,  8.91,  6.83,  3.28339816438712, -25.5011321,
+ 2019-Jan-03 00:00, , ,   235.8375,   -13.2226,  n.a.,  n.a.,  3.26866012345678, -25.5184555,
+$$EOE
+`
+
+func TestReadObserver(t *testing.T) {
+	table, err := Read(strings.NewReader(observerCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Kind != Observer || table.Center != "Earth (399)" || len(table.Observations) != 3 {
+		t.Fatalf("unexpected table %+v", table)
+	}
+
+	o := table.Observations[0]
+	if !o.Epoch.Equal(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected epoch %v", o.Epoch)
+	}
+	ra := (15 + 40.0/60 + 32.13/3600) * 15 * math.Pi / 180
+	dec := -(13 + 4.0/60 + 51.5/3600) * math.Pi / 180
+	if math.Abs(o.RightAscension-ra) > 1e-12 || math.Abs(o.Declination-dec) > 1e-12 {
+		t.Errorf("expected %v,%v got %v,%v", ra, dec, o.RightAscension, o.Declination)
+	}
+	if o.Magnitude != 8.91 || math.Abs(o.Delta-3.29812345678901*orbdata.AU) > 1e-3 || o.DeltaDot != -25.48382 {
+		t.Errorf("unexpected observation %+v", o)
+	}
+
+	o = table.Observations[2]
+	if math.Abs(o.RightAscension-235.8375*math.Pi/180) > 1e-12 || math.Abs(o.Declination+13.2226*math.Pi/180) > 1e-12 {
+		t.Errorf("unexpected decimal angles %+v", o)
+	}
+	if !math.IsNaN(o.Magnitude) {
+		t.Errorf("expected no magnitude got %v", o.Magnitude)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	cases := map[string]string{
+		"no start":    vectorHeader,
+		"no end":      strings.Replace(vectors("KM-S", "Ecliptic of J2000.0", vectorCSV), "$$EOE", "", 1),
+		"bad number":  strings.Replace(vectors("KM-S", "Ecliptic of J2000.0", vectorCSV), "1.248000000000000E+08", "1.24x", 1),
+		"bad units":   vectors("PARSECS", "Ecliptic of J2000.0", vectorCSV),
+		"text output": strings.Replace(observerCSV, ", , ,", "   ", -1),
+	}
+	for name, in := range cases {
+		if _, err := Read(strings.NewReader(in)); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Package orbobs reads astrometric observations of minor planets and comets.
+
+Currently this understands the Minor Planet Center 80 column optical observation format.
+*/
+package orbobs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+/*
+Observation holds a single astrometric observation of an object.
+
+Right ascension and declination are in radians. Magnitude will be NaN when the record does not include one.
+*/
+type Observation struct {
+	Number                 string // packed permanent number, blank if not numbered
+	ProvisionalDesignation string // packed provisional or temporary designation
+	Discovery              bool
+	Note1                  string
+	Note2                  string // observation type. C for CCD, S for satellite based and so on.
+	Epoch                  time.Time
+	RightAscension         float64 // rad
+	Declination            float64 // rad
+	Magnitude              float64
+	Band                   string
+	ObservatoryCode        string
+	ObserverPosition       []float64 // geocentric observer position in km, only for satellite observations
+}
+
+/*
+ID returns the identifier for the observed object. This is the packed number if there is one otherwise the packed
+provisional designation.
+*/
+func (o *Observation) ID() string {
+	if o.Number != "" {
+		return o.Number
+	}
+	return o.ProvisionalDesignation
+}
+
+func (o *Observation) String() string {
+	return fmt.Sprintf("id: \"%v\" epoch: %v ra: %v dec: %v mag: %v%v obs: %v",
+		o.ID(),
+		o.Epoch.Format(time.RFC3339Nano),
+		o.RightAscension,
+		o.Declination,
+		o.Magnitude,
+		o.Band,
+		o.ObservatoryCode,
+	)
+}
+
+const lineLength = 80
+
+/*
+ParseObservationLine parses a single 80 column optical observation record.
+
+Second lines of satellite or roving observer records should be passed to ParseSatelliteLine instead.
+*/
+func ParseObservationLine(line string) (*Observation, error) {
+	if len(line) < lineLength {
+		return nil, fmt.Errorf("observation line is %d characters long, expected %d", len(line), lineLength)
+	}
+
+	var result Observation
+	result.Number = strings.TrimSpace(line[0:5])
+	result.ProvisionalDesignation = strings.TrimSpace(line[5:12])
+	result.Discovery = line[12] == '*'
+	result.Note1 = strings.TrimSpace(line[13:14])
+	result.Note2 = strings.TrimSpace(line[14:15])
+	result.ObservatoryCode = line[77:80]
+
+	if result.Number == "" && result.ProvisionalDesignation == "" {
+		return nil, fmt.Errorf("observation has no designation")
+	}
+
+	var err error
+	if result.Epoch, err = parseObservationDate(line[15:32]); err != nil {
+		return nil, err
+	}
+
+	ra, err := parseSexagesimal(line[32:44])
+	if err != nil {
+		return nil, fmt.Errorf("could not parse right ascension %q: %v", line[32:44], err)
+	}
+	result.RightAscension = ra * 15 * toRad
+
+	dec, err := parseSexagesimal(line[44:56])
+	if err != nil {
+		return nil, fmt.Errorf("could not parse declination %q: %v", line[44:56], err)
+	}
+	result.Declination = dec * toRad
+
+	result.Magnitude = math.NaN()
+	if m := strings.TrimSpace(line[65:70]); m != "" {
+		if result.Magnitude, err = strconv.ParseFloat(m, 64); err != nil {
+			return nil, fmt.Errorf("could not parse magnitude %q: %v", m, err)
+		}
+	}
+	result.Band = strings.TrimSpace(line[70:71])
+
+	return &result, nil
+}
+
+/*
+ParseSatelliteLine reads the second line of a satellite observation record and fills in the observer position on obs.
+*/
+func ParseSatelliteLine(obs *Observation, line string) error {
+	if len(line) < lineLength {
+		return fmt.Errorf("satellite line is %d characters long, expected %d", len(line), lineLength)
+	}
+	if line[14] != 's' {
+		return fmt.Errorf("expected a satellite second line got note %q", line[14])
+	}
+
+	// Units flag: 1 for km, 2 for AU.
+	scale := 1.0
+	switch line[32] {
+	case '1':
+	case '2':
+		scale = orbdata.AU
+	default:
+		return fmt.Errorf("unknown satellite position units %q", line[32])
+	}
+
+	pos := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		sign := 34 + i*12
+		field := line[sign+1 : sign+12]
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return fmt.Errorf("could not parse satellite position %q: %v", field, err)
+		}
+		if line[sign] == '-' {
+			v = -v
+		}
+		pos[i] = v * scale
+	}
+
+	obs.ObserverPosition = pos
+	return nil
+}
+
+// parseObservationDate handles dates in the form "YYYY MM DD.dddddd"
+func parseObservationDate(in string) (time.Time, error) {
+	parts := strings.Fields(in)
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("could not parse observation date %q", in)
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse observation year %q: %v", in, err)
+	}
+	month, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse observation month %q: %v", in, err)
+	}
+	day, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse observation day %q: %v", in, err)
+	}
+
+	whole := math.Floor(day)
+	fraction := time.Duration(math.Round((day - whole) * float64(24*time.Hour)))
+	return time.Date(year, time.Month(month), int(whole), 0, 0, 0, 0, time.UTC).Add(fraction), nil
+}
+
+// parseSexagesimal turns "sHH MM SS.sss" into decimal units of the first field. Trailing fields may be missing when
+// the observer reported a lower precision.
+func parseSexagesimal(in string) (float64, error) {
+	in = strings.TrimSpace(in)
+	negative := strings.HasPrefix(in, "-")
+	in = strings.TrimLeft(in, "+-")
+
+	parts := strings.Fields(in)
+	if len(parts) == 0 || len(parts) > 3 {
+		return 0, fmt.Errorf("expected between one and three fields got %d", len(parts))
+	}
+
+	result := 0.0
+	divisor := 1.0
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, err
+		}
+		result += v / divisor
+		divisor *= 60
+	}
+
+	if negative {
+		result = -result
+	}
+	return result, nil
+}
+
+const toRad = math.Pi / 180.0
@@ -0,0 +1,118 @@
+package orbobs
+
+import (
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+const testObservations = `00433         C2019 01 01.50000 12 34 56.78 +12 34 56.7          18.5 V      568
+     K19A00A* C2019 01 02.25    01 30 00.0  -05 30                           G96
+
+     K19A00A  R2019 01 03.00000 23 00 00.00 +00 30 00.0          19.2 R      253
+     K19A00A  S2019 01 03.00000 23 00 00.00 +00 30 00.0          19.2 R      C51
+     K19A00A  s2019 01 03.00000 1 - 5041.5479 + 1539.2347 + 3807.1249   ~0000C51
+`
+
+func TestParseObservationLine(t *testing.T) {
+	obs, err := ParseObservationLine(strings.Split(testObservations, "\n")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if obs.ID() != "00433" {
+		t.Errorf("expected id 00433 got %v", obs.ID())
+	}
+	if obs.Note2 != "C" {
+		t.Errorf("expected note2 C got %v", obs.Note2)
+	}
+	expectedEpoch := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	if !obs.Epoch.Equal(expectedEpoch) {
+		t.Errorf("expected epoch %v got %v", expectedEpoch, obs.Epoch)
+	}
+
+	expectedRA := (12 + 34/60.0 + 56.78/3600.0) * 15 * math.Pi / 180
+	if math.Abs(obs.RightAscension-expectedRA) > 1e-12 {
+		t.Errorf("expected ra %v got %v", expectedRA, obs.RightAscension)
+	}
+	expectedDec := (12 + 34/60.0 + 56.7/3600.0) * math.Pi / 180
+	if math.Abs(obs.Declination-expectedDec) > 1e-12 {
+		t.Errorf("expected dec %v got %v", expectedDec, obs.Declination)
+	}
+	if obs.Magnitude != 18.5 || obs.Band != "V" {
+		t.Errorf("expected magnitude 18.5V got %v%v", obs.Magnitude, obs.Band)
+	}
+	if obs.ObservatoryCode != "568" {
+		t.Errorf("expected observatory 568 got %v", obs.ObservatoryCode)
+	}
+}
+
+func TestParseObservationLineShort(t *testing.T) {
+	if _, err := ParseObservationLine("00433         C2019 01 01.50000"); err == nil {
+		t.Error("expected an error for a short line")
+	}
+}
+
+func TestParseSatelliteLineAU(t *testing.T) {
+	line := "     K19A00A  s2019 01 03.00000 2 -0.034567891+0.012345678+0.023456789  ~0000C51"
+	var obs Observation
+	if err := ParseSatelliteLine(&obs, line); err != nil {
+		t.Fatal(err)
+	}
+	expected := []float64{-0.034567891, 0.012345678, 0.023456789}
+	for i, v := range expected {
+		if math.Abs(obs.ObserverPosition[i]-v*orbdata.AU) > 1e-6 {
+			t.Errorf("expected observer position %v AU got %v km", expected, obs.ObserverPosition)
+		}
+	}
+}
+
+func TestObservationReader(t *testing.T) {
+	reader := NewObservationReaderFromReader(strings.NewReader(testObservations))
+	defer reader.Close()
+
+	var result []*Observation
+	obs, err := reader.ReadEntry()
+	for err == nil {
+		result = append(result, obs)
+		obs, err = reader.ReadEntry()
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+
+	if len(result) != 3 {
+		t.Fatalf("expected 3 observations got %v", len(result))
+	}
+
+	low := result[1]
+	if low.ID() != "K19A00A" || !low.Discovery {
+		t.Errorf("expected discovery observation of K19A00A got %v", low)
+	}
+	if !math.IsNaN(low.Magnitude) {
+		t.Errorf("expected no magnitude got %v", low.Magnitude)
+	}
+	expectedDec := -5.5 * math.Pi / 180
+	if math.Abs(low.Declination-expectedDec) > 1e-12 {
+		t.Errorf("expected dec %v got %v", expectedDec, low.Declination)
+	}
+	expectedEpoch := time.Date(2019, 1, 2, 6, 0, 0, 0, time.UTC)
+	if !low.Epoch.Equal(expectedEpoch) {
+		t.Errorf("expected epoch %v got %v", expectedEpoch, low.Epoch)
+	}
+
+	sat := result[2]
+	expectedPos := []float64{-5041.5479, 1539.2347, 3807.1249}
+	if len(sat.ObserverPosition) != 3 {
+		t.Fatalf("expected satellite position got %v", sat.ObserverPosition)
+	}
+	for i, v := range expectedPos {
+		if math.Abs(sat.ObserverPosition[i]-v) > 1e-9 {
+			t.Errorf("expected observer position %v got %v", expectedPos, sat.ObserverPosition)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package orbobs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+ObservationReader streams observations from an MPC 80 column observation file.
+
+Satellite observations have their second line folded into the ObserverPosition of the returned observation. Radar
+observations and the second line of roving observer records are skipped.
+*/
+type ObservationReader struct {
+	file    io.Closer
+	scanner *bufio.Scanner
+	line    int
+}
+
+/*
+NewObservationReader opens the file at path ready to read observations from it. Gzip and bzip2 compressed files are
+decompressed on the fly.
+*/
+func NewObservationReader(path string) (*ObservationReader, error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	result := NewObservationReaderFromReader(f)
+	result.file = f
+	return result, nil
+}
+
+/*
+NewObservationReaderFromReader creates an ObservationReader that reads from an already open reader.
+*/
+func NewObservationReaderFromReader(in io.Reader) *ObservationReader {
+	return &ObservationReader{
+		scanner: bufio.NewScanner(in),
+	}
+}
+
+/*
+ReadEntry returns the next observation from the file. At the end of the input io.EOF is returned.
+*/
+func (r *ObservationReader) ReadEntry() (*Observation, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Text()
+		if len(line) < lineLength {
+			if len(line) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: observation line is %d characters long, expected %d", r.line, len(line), lineLength)
+		}
+
+		switch line[14] {
+		case 'R', 'r', 'v':
+			continue
+		}
+
+		obs, err := ParseObservationLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", r.line, err)
+		}
+
+		if obs.Note2 == "S" {
+			if !r.scanner.Scan() {
+				return nil, fmt.Errorf("line %d: satellite observation is missing its second line", r.line)
+			}
+			r.line++
+			if err := ParseSatelliteLine(obs, r.scanner.Text()); err != nil {
+				return nil, fmt.Errorf("line %d: %v", r.line, err)
+			}
+		}
+
+		return obs, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+/*
+Close closes the underlying file if this reader opened it.
+*/
+func (r *ObservationReader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
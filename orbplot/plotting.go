@@ -3,6 +3,7 @@ package orbplot
 import (
 	"image/color"
 	"math"
+	"time"
 
 	"github.com/emilyselwood/orbcalc/orbcore"
 	"github.com/emilyselwood/orbcalc/orbdata"
@@ -12,25 +13,30 @@ import (
 	"gonum.org/v1/plot/vg/draw"
 )
 
-// PlotSolarSystemLines plots the major planets of the solar system on the provided plot
-func PlotSolarSystemLines(p *plot.Plot, legend bool) error {
-	if err := PlotFullOrbitLines(p, orbdata.SolarSystem, RainbowList(len(orbdata.SolarSystem)), legend); err != nil {
+// PlotSolarSystemLines plots the orbits of the major planets of the solar system at time t on the provided plot
+func PlotSolarSystemLines(p *plot.Plot, t time.Time, legend bool) error {
+	orbits := orbdata.SolarSystemAt(t)
+	if err := PlotFullOrbitLines(p, orbits, RainbowList(len(orbits)), legend); err != nil {
 		return err
 	}
 	return PlotSun(p)
 }
 
-// PlotInnerSolarSystemLines plots the major planets of the inner solar system on the provided plot
-func PlotInnerSolarSystemLines(p *plot.Plot, legend bool) error {
-	if err := PlotFullOrbitLines(p, orbdata.InnerSolarSystem, RainbowList(len(orbdata.InnerSolarSystem)), legend); err != nil {
+// PlotInnerSolarSystemLines plots the orbits of the major planets of the inner solar system at time t on the provided
+// plot
+func PlotInnerSolarSystemLines(p *plot.Plot, t time.Time, legend bool) error {
+	orbits := orbdata.InnerSolarSystemAt(t)
+	if err := PlotFullOrbitLines(p, orbits, RainbowList(len(orbits)), legend); err != nil {
 		return err
 	}
 	return PlotSun(p)
 }
 
-// PlotOuterSolarSystemLines plots the major planets of the outer solar system on the provided plot
-func PlotOuterSolarSystemLines(p *plot.Plot, legend bool) error {
-	if err := PlotFullOrbitLines(p, orbdata.OuterSolarSystem, RainbowList(len(orbdata.OuterSolarSystem)), legend); err != nil {
+// PlotOuterSolarSystemLines plots the orbits of the major planets of the outer solar system at time t on the provided
+// plot
+func PlotOuterSolarSystemLines(p *plot.Plot, t time.Time, legend bool) error {
+	orbits := orbdata.OuterSolarSystemAt(t)
+	if err := PlotFullOrbitLines(p, orbits, RainbowList(len(orbits)), legend); err != nil {
 		return err
 	}
 	return PlotSun(p)
@@ -0,0 +1,135 @@
+/*
+Package orbspk reads JPL SPK ephemeris kernels, the .bsp files the DE ephemerides (de440s.bsp and friends) are
+distributed as, and evaluates the Chebyshev segments in them to give the position of a body at a time.
+
+Only segment types 2 and 3 are supported, which covers the planetary ephemerides and most satellite ones.
+*/
+package orbspk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// recordLength is the size of a DAF record in bytes
+const recordLength = 1024
+
+// daf is the Double precision Array File container that SPK files are stored in. It is a list of arrays of doubles
+// each with a summary of doubles and integers describing them.
+type daf struct {
+	r       io.ReaderAt
+	order   binary.ByteOrder
+	nd, ni  int
+	entries []dafEntry
+}
+
+type dafEntry struct {
+	name    string
+	doubles []float64
+	ints    []int
+}
+
+func readDAF(r io.ReaderAt) (*daf, error) {
+	record := make([]byte, recordLength)
+	if _, err := r.ReadAt(record, 0); err != nil {
+		return nil, fmt.Errorf("could not read file record: %v", err)
+	}
+
+	id := string(record[0:8])
+	if !strings.HasPrefix(id, "DAF/") && !strings.HasPrefix(id, "NAIF/DAF") {
+		return nil, fmt.Errorf("not a DAF file, id word is %q", id)
+	}
+
+	d := daf{r: r}
+	switch string(record[88:96]) {
+	case "LTL-IEEE":
+		d.order = binary.LittleEndian
+	case "BIG-IEEE":
+		d.order = binary.BigEndian
+	default:
+		// Old files have no format string, the summary sizes are small so only one byte order makes sense.
+		if binary.LittleEndian.Uint32(record[8:12]) < 128 {
+			d.order = binary.LittleEndian
+		} else {
+			d.order = binary.BigEndian
+		}
+	}
+
+	d.nd = int(int32(d.order.Uint32(record[8:12])))
+	d.ni = int(int32(d.order.Uint32(record[12:16])))
+	if d.nd < 0 || d.ni < 2 || d.nd+(d.ni+1)/2 > 125 {
+		return nil, fmt.Errorf("bad summary format ND=%d NI=%d", d.nd, d.ni)
+	}
+
+	next := int(int32(d.order.Uint32(record[76:80])))
+	seen := make(map[int]bool)
+	for next != 0 {
+		if seen[next] {
+			return nil, fmt.Errorf("summary record %d is linked twice", next)
+		}
+		seen[next] = true
+
+		var err error
+		next, err = d.readSummaries(next)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &d, nil
+}
+
+// readSummaries reads the summary record at index n, and the name record after it, returning the index of the next
+// summary record.
+func (d *daf) readSummaries(n int) (int, error) {
+	summaries := make([]byte, recordLength)
+	if _, err := d.r.ReadAt(summaries, int64(n-1)*recordLength); err != nil {
+		return 0, fmt.Errorf("could not read summary record %d: %v", n, err)
+	}
+	names := make([]byte, recordLength)
+	if _, err := d.r.ReadAt(names, int64(n)*recordLength); err != nil {
+		return 0, fmt.Errorf("could not read name record %d: %v", n+1, err)
+	}
+
+	control := d.doubles(summaries[:24])
+	size := (d.nd + (d.ni+1)/2) * 8
+	count := int(control[2])
+	if count < 0 || 24+count*size > recordLength {
+		return 0, fmt.Errorf("summary record %d claims %d summaries", n, count)
+	}
+
+	for i := 0; i < count; i++ {
+		summary := summaries[24+i*size : 24+(i+1)*size]
+		entry := dafEntry{
+			name:    strings.TrimRight(string(names[i*size:(i+1)*size]), " \x00"),
+			doubles: d.doubles(summary[:d.nd*8]),
+		}
+		for j := 0; j < d.ni; j++ {
+			offset := d.nd*8 + j*4
+			entry.ints = append(entry.ints, int(int32(d.order.Uint32(summary[offset:offset+4]))))
+		}
+		d.entries = append(d.entries, entry)
+	}
+
+	return int(control[0]), nil
+}
+
+// read returns count doubles starting at a one based double word address.
+func (d *daf) read(address, count int) ([]float64, error) {
+	buf := make([]byte, count*8)
+	if _, err := d.r.ReadAt(buf, int64(address-1)*8); err != nil {
+		return nil, fmt.Errorf("could not read %d doubles at address %d: %v", count, address, err)
+	}
+	return d.doubles(buf), nil
+}
+
+func (d *daf) doubles(buf []byte) []float64 {
+	result := make([]float64, len(buf)/8)
+	for i := range result {
+		result[i] = math.Float64frombits(d.order.Uint64(buf[i*8 : i*8+8]))
+	}
+	return result
+}
@@ -0,0 +1,331 @@
+package orbspk
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+	"gonum.org/v1/gonum/mat"
+)
+
+// NAIF frame codes that segments may be referenced to
+const (
+	frameJ2000      = 1
+	frameEclipJ2000 = 17
+)
+
+// The solar system barycenter, which every chain of segments ends at
+const barycenter = 0
+
+/*
+Segment is one block of Chebyshev coefficients giving the position of Target relative to Center between Start and
+End.
+*/
+type Segment struct {
+	Name   string
+	Target int
+	Center int
+	Frame  int
+	Type   int
+	Start  time.Time
+	End    time.Time
+
+	start, end float64 // seconds past J2000 TDB
+	first      int     // address of the first double in the segment
+	initial    float64 // start of the first record
+	interval   float64 // length of each record in seconds
+	size       int     // number of doubles in each record
+	records    int
+}
+
+/*
+File is an open SPK kernel. It is safe to use from many goroutines at once.
+*/
+type File struct {
+	Segments []*Segment
+
+	daf  *daf
+	file *os.File
+}
+
+/*
+Open reads the segment list of the SPK file at path. Coefficients are read from the file as they are needed so it
+must stay open until Close is called.
+*/
+func Open(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := NewFile(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	result.file = file
+	return result, nil
+}
+
+/*
+NewFile reads the segment list of an SPK kernel from r.
+*/
+func NewFile(r io.ReaderAt) (*File, error) {
+	d, err := readDAF(r)
+	if err != nil {
+		return nil, err
+	}
+	if d.nd != 2 || d.ni != 6 {
+		return nil, fmt.Errorf("not an SPK file, summaries have %d doubles and %d integers", d.nd, d.ni)
+	}
+
+	result := File{daf: d}
+	for _, entry := range d.entries {
+		s := Segment{
+			Name:   entry.name,
+			Target: entry.ints[0],
+			Center: entry.ints[1],
+			Frame:  entry.ints[2],
+			Type:   entry.ints[3],
+			Start:  orbcore.TimeFromEphemerisTime(entry.doubles[0]),
+			End:    orbcore.TimeFromEphemerisTime(entry.doubles[1]),
+			start:  entry.doubles[0],
+			end:    entry.doubles[1],
+			first:  entry.ints[4],
+		}
+
+		if s.Type == 2 || s.Type == 3 {
+			trailer, err := d.read(entry.ints[5]-3, 4)
+			if err != nil {
+				return nil, fmt.Errorf("segment %q: %v", s.Name, err)
+			}
+			s.initial = trailer[0]
+			s.interval = trailer[1]
+			s.size = int(trailer[2])
+			s.records = int(trailer[3])
+			if s.interval <= 0 || s.records < 1 || s.size < 2 || (s.size-2)%s.components() != 0 {
+				return nil, fmt.Errorf("segment %q: bad directory %v", s.Name, trailer)
+			}
+		}
+
+		result.Segments = append(result.Segments, &s)
+	}
+
+	return &result, nil
+}
+
+/*
+Close closes the underlying file when the kernel was opened with Open.
+*/
+func (f *File) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}
+
+/*
+State returns the position in km and velocity in km/s of target relative to center at time t, in the J2000
+equatorial frame. Targets and centers are NAIF IDs, segments are chained through the solar system barycenter so any
+two bodies in the file can be used.
+*/
+func (f *File) State(target, center int, t time.Time) (*mat.VecDense, *mat.VecDense, error) {
+	et := orbcore.EphemerisTime(t)
+
+	tr, tv, err := f.barycentric(target, et, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	cr, cv, err := f.barycentric(center, et, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tr.SubVec(tr, cr)
+	tv.SubVec(tv, cv)
+	return tr, tv, nil
+}
+
+/*
+Position returns the position and velocity of target relative to center at time t in the J2000 equatorial frame.
+*/
+func (f *File) Position(target, center int, t time.Time) (*orbcore.Position, error) {
+	r, v, err := f.State(target, center, t)
+	if err != nil {
+		return nil, err
+	}
+	return &orbcore.Position{
+		ID:          fmt.Sprint(target),
+		Epoch:       t,
+		X:           r.AtVec(0),
+		Y:           r.AtVec(1),
+		Z:           r.AtVec(2),
+		VX:          v.AtVec(0),
+		VY:          v.AtVec(1),
+		VZ:          v.AtVec(2),
+		HasVelocity: true,
+		Frame:       orbcore.ICRF,
+		Center:      fmt.Sprint(center),
+	}, nil
+}
+
+/*
+HeliocentricPosition makes File an orbdata.Ephemeris. The position is relative to the sun in the J2000 ecliptic
+frame. Planetary ephemerides such as de440s.bsp only have the barycenters of the outer planets, so when a planet is
+not in the file the barycenter of its system is used instead.
+*/
+func (f *File) HeliocentricPosition(body *orbdata.Body, t time.Time) (*orbcore.Position, error) {
+	target := body.NAIF
+	if !f.Covers(target, t) && target > 100 && target < 1000 && target%100 == 99 {
+		target = target / 100
+	}
+
+	r, v, err := f.State(target, orbdata.Sun.NAIF, t)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", body.Name, err)
+	}
+	orbcore.Rotate(r, -orbdata.Obliquity, orbcore.AxisX)
+	orbcore.Rotate(v, -orbdata.Obliquity, orbcore.AxisX)
+
+	return &orbcore.Position{
+		ID:          body.Name,
+		Epoch:       t,
+		X:           r.AtVec(0),
+		Y:           r.AtVec(1),
+		Z:           r.AtVec(2),
+		VX:          v.AtVec(0),
+		VY:          v.AtVec(1),
+		VZ:          v.AtVec(2),
+		HasVelocity: true,
+		Center:      orbdata.Sun.Name,
+	}, nil
+}
+
+/*
+Covers returns true if the file has a segment for target at time t.
+*/
+func (f *File) Covers(target int, t time.Time) bool {
+	return f.segment(target, orbcore.EphemerisTime(t)) != nil
+}
+
+// segment finds the segment for target that covers et. Later segments take priority over earlier ones, the same as
+// in the SPICE toolkit.
+func (f *File) segment(target int, et float64) *Segment {
+	for i := len(f.Segments) - 1; i >= 0; i-- {
+		s := f.Segments[i]
+		if s.Target == target && et >= s.start && et <= s.end {
+			return s
+		}
+	}
+	return nil
+}
+
+// barycentric follows segments from target to the solar system barycenter adding up the states on the way.
+func (f *File) barycentric(target int, et float64, depth int) (*mat.VecDense, *mat.VecDense, error) {
+	if target == barycenter {
+		return mat.NewVecDense(3, nil), mat.NewVecDense(3, nil), nil
+	}
+	if depth > 20 {
+		return nil, nil, fmt.Errorf("segments for %d do not lead back to the barycenter", target)
+	}
+
+	s := f.segment(target, et)
+	if s == nil {
+		return nil, nil, fmt.Errorf("no segment for body %d at %v", target, orbcore.TimeFromEphemerisTime(et))
+	}
+
+	r, v, err := s.state(f.daf, et)
+	if err != nil {
+		return nil, nil, err
+	}
+	pr, pv, err := f.barycentric(s.Center, et, depth+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.AddVec(r, pr)
+	v.AddVec(v, pv)
+	return r, v, nil
+}
+
+// components is the number of coefficient sets in each record, position for type 2 and position and velocity for
+// type 3.
+func (s *Segment) components() int {
+	if s.Type == 3 {
+		return 6
+	}
+	return 3
+}
+
+// state evaluates the segment at et, giving the state relative to the segment center in the J2000 frame.
+func (s *Segment) state(d *daf, et float64) (*mat.VecDense, *mat.VecDense, error) {
+	if s.Type != 2 && s.Type != 3 {
+		return nil, nil, fmt.Errorf("segment %q has unsupported type %d", s.Name, s.Type)
+	}
+	if s.Frame != frameJ2000 && s.Frame != frameEclipJ2000 {
+		return nil, nil, fmt.Errorf("segment %q has unsupported frame %d", s.Name, s.Frame)
+	}
+
+	index := int(math.Floor((et - s.initial) / s.interval))
+	if index == s.records {
+		index-- // the very end of the last record
+	}
+	if index < 0 || index >= s.records {
+		return nil, nil, fmt.Errorf("segment %q does not cover %v", s.Name, orbcore.TimeFromEphemerisTime(et))
+	}
+
+	record, err := d.read(s.first+index*s.size, s.size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("segment %q: %v", s.Name, err)
+	}
+
+	mid, radius := record[0], record[1]
+	x := (et - mid) / radius
+	n := (s.size - 2) / s.components()
+
+	r := mat.NewVecDense(3, nil)
+	v := mat.NewVecDense(3, nil)
+	for i := 0; i < 3; i++ {
+		coeffs := record[2+i*n : 2+(i+1)*n]
+		value, derivative := chebyshev(coeffs, x)
+		r.SetVec(i, value)
+		if s.Type == 2 {
+			v.SetVec(i, derivative/radius)
+		} else {
+			value, _ := chebyshev(record[2+(i+3)*n:2+(i+4)*n], x)
+			v.SetVec(i, value)
+		}
+	}
+
+	if s.Frame == frameEclipJ2000 {
+		orbcore.Rotate(r, orbdata.Obliquity, orbcore.AxisX)
+		orbcore.Rotate(v, orbdata.Obliquity, orbcore.AxisX)
+	}
+	return r, v, nil
+}
+
+// chebyshev evaluates a Chebyshev series and its derivative at x, which must be between -1 and 1.
+func chebyshev(coeffs []float64, x float64) (float64, float64) {
+	t0, t1 := 1.0, x
+	d0, d1 := 0.0, 1.0
+
+	value := coeffs[0] * t0
+	derivative := 0.0
+	if len(coeffs) > 1 {
+		value += coeffs[1] * t1
+		derivative += coeffs[1] * d1
+	}
+	for i := 2; i < len(coeffs); i++ {
+		t2 := 2*x*t1 - t0
+		d2 := 2*t1 + 2*x*d1 - d0
+		value += coeffs[i] * t2
+		derivative += coeffs[i] * d2
+		t0, t1 = t1, t2
+		d0, d1 = d1, d2
+	}
+	return value, derivative
+}
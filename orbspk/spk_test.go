@@ -0,0 +1,214 @@
+package orbspk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+type testSegment struct {
+	name           string
+	target, center int
+	frame, kind    int
+	start, end     float64
+	interval       float64
+	records        [][]float64 // each record is mid, radius then the coefficients
+}
+
+// buildSPK writes a minimal SPK file: the file record, one summary record, its name record and then the segment data.
+func buildSPK(order binary.ByteOrder, segments []testSegment) []byte {
+	var data []float64
+	type addresses struct{ first, last int }
+	var locations []addresses
+
+	next := 3*recordLength/8 + 1
+	for _, s := range segments {
+		first := next + len(data)
+		for _, r := range s.records {
+			data = append(data, r...)
+		}
+		data = append(data, s.start, s.interval, float64(len(s.records[0])), float64(len(s.records)))
+		locations = append(locations, addresses{first, next + len(data) - 1})
+	}
+
+	buf := make([]byte, 3*recordLength+len(data)*8)
+	copy(buf, "DAF/SPK ")
+	order.PutUint32(buf[8:], 2)
+	order.PutUint32(buf[12:], 6)
+	order.PutUint32(buf[76:], 2)
+	order.PutUint32(buf[80:], 2)
+	if order == binary.LittleEndian {
+		copy(buf[88:], "LTL-IEEE")
+	} else {
+		copy(buf[88:], "BIG-IEEE")
+	}
+
+	putDouble := func(offset int, v float64) {
+		order.PutUint64(buf[offset:], math.Float64bits(v))
+	}
+
+	summaries := recordLength
+	putDouble(summaries+16, float64(len(segments)))
+	for i, s := range segments {
+		offset := summaries + 24 + i*40
+		putDouble(offset, s.start)
+		putDouble(offset+8, s.end)
+		for j, v := range []int{s.target, s.center, s.frame, s.kind, locations[i].first, locations[i].last} {
+			order.PutUint32(buf[offset+16+j*4:], uint32(v))
+		}
+		copy(buf[2*recordLength+i*40:], s.name)
+	}
+
+	for i, v := range data {
+		putDouble(3*recordLength+i*8, v)
+	}
+	return buf
+}
+
+const testDay = 86400.0
+
+// testSegments has the sun fixed at the barycenter offset, the earth moon barycenter moving in a straight line, the
+// earth relative to it as a parabola and a type 3 moon with its own velocity coefficients.
+func testSegments() []testSegment {
+	return []testSegment{
+		{
+			name: "SUN", target: 10, center: 0, frame: 1, kind: 2,
+			start: -10 * testDay, end: 10 * testDay, interval: 20 * testDay,
+			records: [][]float64{{0, 10 * testDay, 1000, 0, 2000, 0, 3000, 0}},
+		},
+		{
+			name: "EMB", target: 3, center: 0, frame: 1, kind: 2,
+			start: -10 * testDay, end: 10 * testDay, interval: 10 * testDay,
+			records: [][]float64{
+				{-5 * testDay, 5 * testDay, 1e8, 5e6, 0, 0, 0, 0},
+				{5 * testDay, 5 * testDay, 1e8, 5e6, 1e7, 0, 0, 0},
+			},
+		},
+		{
+			name: "EARTH", target: 399, center: 3, frame: 1, kind: 2,
+			start: -10 * testDay, end: 10 * testDay, interval: 20 * testDay,
+			records: [][]float64{{0, 10 * testDay, 0, 0, 1000, 0, 0, 0, 0, 0, 2000}},
+		},
+		{
+			name: "MOON", target: 301, center: 3, frame: 1, kind: 3,
+			start: -10 * testDay, end: 10 * testDay, interval: 20 * testDay,
+			records: [][]float64{{0, 10 * testDay, 3e5, 0, 4e5, 0, 0, 0, 1, 0, 2, 0, 3, 0}},
+		},
+	}
+}
+
+func openTest(t *testing.T, order binary.ByteOrder) *File {
+	f, err := NewFile(bytes.NewReader(buildSPK(order, testSegments())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestChebyshev(t *testing.T) {
+	coeffs := []float64{1, 2, 3, 4}
+	for _, x := range []float64{-1, -0.3, 0, 0.5, 1} {
+		expected := 1 + 2*x + 3*(2*x*x-1) + 4*(4*x*x*x-3*x)
+		expectedDerivative := 2 + 12*x + 4*(12*x*x-3)
+		value, derivative := chebyshev(coeffs, x)
+		if math.Abs(value-expected) > 1e-12 || math.Abs(derivative-expectedDerivative) > 1e-12 {
+			t.Errorf("at %v expected %v,%v got %v,%v", x, expected, expectedDerivative, value, derivative)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		f := openTest(t, order)
+		if len(f.Segments) != 4 {
+			t.Fatalf("expected 4 segments got %d", len(f.Segments))
+		}
+		s := f.Segments[2]
+		if s.Name != "EARTH" || s.Target != 399 || s.Center != 3 || s.Type != 2 || s.records != 1 || s.size != 11 {
+			t.Errorf("unexpected segment %+v", s)
+		}
+		if s.Start.Before(orbdata.J2000Noon.Add(-241*time.Hour)) || s.Start.After(orbdata.J2000Noon.Add(-239*time.Hour)) {
+			t.Errorf("expected the segment to start ten days before J2000 got %v", s.Start)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	f := openTest(t, binary.LittleEndian)
+
+	// Five days after J2000 is half way through the second EMB record.
+	when := orbcore.TimeFromEphemerisTime(5 * testDay)
+	r, v, err := f.State(399, 0, when)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// earth relative to the EMB: x = 1000 * T2(0.5), z = 2000 * T2(0.5)
+	x := 1e8 + 1000*(2*0.25-1)
+	z := 2000 * (2*0.25 - 1)
+	expected := []float64{x, 1e7, z}
+	for i := range expected {
+		if math.Abs(r.AtVec(i)-expected[i]) > 1e-3 {
+			t.Errorf("position %d: expected %v got %v", i, expected[i], r.AtVec(i))
+		}
+	}
+
+	earthVx := 1000 * 4 * 0.5 / (10 * testDay)
+	vx := 5e6/(5*testDay) + earthVx
+	vz := 2000 * 4 * 0.5 / (10 * testDay)
+	if math.Abs(v.AtVec(0)-vx) > 1e-9 || math.Abs(v.AtVec(2)-vz) > 1e-9 {
+		t.Errorf("expected velocity %v,0,%v got %v", vx, vz, v.RawVector().Data)
+	}
+
+	r, v, err = f.State(301, 399, when)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.AtVec(0)-(3e5-x+1e8)) > 1e-3 || math.Abs(r.AtVec(1)-4e5) > 1e-3 {
+		t.Errorf("unexpected moon position %v", r.RawVector().Data)
+	}
+	if math.Abs(v.AtVec(0)-(1-earthVx)) > 1e-9 || math.Abs(v.AtVec(1)-2) > 1e-9 || math.Abs(v.AtVec(2)-(3-vz)) > 1e-9 {
+		t.Errorf("unexpected moon velocity %v", v.RawVector().Data)
+	}
+}
+
+func TestHeliocentricPosition(t *testing.T) {
+	f := openTest(t, binary.LittleEndian)
+
+	var eph orbdata.Ephemeris = f
+	pos, err := eph.HeliocentricPosition(orbdata.Earth, orbcore.TimeFromEphemerisTime(-5*testDay))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Five days before J2000 is the middle of the first EMB record and a quarter of the way into the earth one.
+	equatorial := []float64{1e8 - 500 - 1000, -2000, -1000 - 3000}
+	y := equatorial[1]*math.Cos(orbdata.Obliquity) + equatorial[2]*math.Sin(orbdata.Obliquity)
+	z := -equatorial[1]*math.Sin(orbdata.Obliquity) + equatorial[2]*math.Cos(orbdata.Obliquity)
+	if math.Abs(pos.X-equatorial[0]) > 1e-3 || math.Abs(pos.Y-y) > 1e-3 || math.Abs(pos.Z-z) > 1e-3 {
+		t.Errorf("expected %v,%v,%v got %v", equatorial[0], y, z, pos)
+	}
+	if pos.ID != "Earth" || pos.Center != "Sun" || !pos.HasVelocity {
+		t.Errorf("expected the Earth relative to the Sun with a velocity got %+v", pos)
+	}
+
+	if _, err := eph.HeliocentricPosition(orbdata.Jupiter, orbdata.J2000Noon); err == nil {
+		t.Error("expected an error for a body that is not in the file")
+	}
+	if _, err := eph.HeliocentricPosition(orbdata.Earth, orbdata.J2000Noon.AddDate(1, 0, 0)); err == nil {
+		t.Error("expected an error for a time that is not in the file")
+	}
+}
+
+func TestNotSPK(t *testing.T) {
+	_, err := NewFile(strings.NewReader(strings.Repeat("x", 2048)))
+	if err == nil {
+		t.Error("expected an error reading something that is not a DAF file")
+	}
+}
@@ -0,0 +1,458 @@
+package orbtle
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+/*
+TEME is the frame SGP4 positions are in, the true equator and mean equinox of the epoch of each position.
+*/
+const TEME = "TEME"
+
+/*
+Gravity holds the earth constants the propagator uses. Element sets are generated with WGS72 so it should be used
+unless there is a reason to match another implementation.
+*/
+type Gravity struct {
+	Mu     float64 // km³/s²
+	Radius float64 // km
+	XKE    float64 // sqrt(mu) in earth radii^1.5 per minute
+	J2     float64
+	J3     float64
+	J4     float64
+}
+
+func newGravity(mu, radius, j2, j3, j4 float64) Gravity {
+	return Gravity{Mu: mu, Radius: radius, XKE: 60 / math.Sqrt(radius*radius*radius/mu), J2: j2, J3: j3, J4: j4}
+}
+
+/*
+The gravity models SGP4 is used with.
+*/
+var (
+	WGS72Old = Gravity{Mu: 398600.79964, Radius: 6378.135, XKE: 0.0743669161, J2: 0.001082616, J3: -0.00000253881, J4: -0.00000165597}
+	WGS72    = newGravity(398600.8, 6378.135, 0.001082616, -0.00000253881, -0.00000165597)
+	WGS84    = newGravity(398600.5, 6378.137, 0.00108262998905, -0.00000253215306, -0.00000161098761)
+)
+
+/*
+Errors the propagator can return. They mean the element set cannot be propagated to the time asked for, normally
+because it is too far from the epoch.
+*/
+var (
+	ErrEccentricity          = errors.New("mean eccentricity is out of range")
+	ErrMeanMotion            = errors.New("mean motion is less than zero")
+	ErrPerturbedEccentricity = errors.New("perturbed eccentricity is out of range")
+	ErrSemiLatusRectum       = errors.New("semi latus rectum is less than zero")
+	ErrDecayed               = errors.New("satellite has decayed")
+)
+
+const (
+	twoPi     = 2 * math.Pi
+	x2o3      = 2.0 / 3.0
+	minPerDay = 1440.0
+	// temp4 stands in for 1 + cos(i) when an orbit is so close to retrograde equatorial that it would divide by zero.
+	temp4 = 1.5e-12
+)
+
+// epoch1950 is the zero point of the epochs the deep space terms use, 1950 January 0.0.
+var epoch1950 = time.Date(1949, 12, 31, 0, 0, 0, 0, time.UTC)
+
+/*
+Satellite is an element set made ready for propagation. Element sets with periods of 225 minutes or more use the deep
+space SDP4 terms for the Sun and Moon and the resonances of 12 and 24 hour orbits, shorter ones the near earth SGP4
+terms. A Satellite is not changed by propagating it so it can be used from several goroutines at once.
+*/
+type Satellite struct {
+	TLE     *TLE
+	Gravity Gravity
+
+	deepSpace bool
+	isimp     bool
+
+	// elements from the element set, in radians and radians per minute
+	bstar, ecco, argpo, inclo, mo, nodeo, noUnkozai float64
+
+	// near earth terms
+	aycof, con41, cc1, cc4, cc5, d2, d3, d4, delmo, eta, argpdot, omgcof, sinmao, t2cof, t3cof, t4cof, t5cof float64
+	x1mth2, x7thm1, mdot, nodedot, xlcof, xmcof, nodecf                                                      float64
+
+	deep deepSpace
+}
+
+/*
+NewSatellite initialises SGP4 for an element set using the WGS72 constants it was fitted with.
+*/
+func NewSatellite(tle *TLE) (*Satellite, error) {
+	return NewSatelliteWithGravity(tle, WGS72)
+}
+
+/*
+NewSatelliteWithGravity initialises SGP4 for an element set with a different set of earth constants.
+*/
+func NewSatelliteWithGravity(tle *TLE, gravity Gravity) (*Satellite, error) {
+	s := Satellite{
+		TLE:     tle,
+		Gravity: gravity,
+		bstar:   tle.BStar,
+		ecco:    tle.Eccentricity,
+		argpo:   tle.ArgumentOfPerigee,
+		inclo:   tle.Inclination,
+		mo:      tle.MeanAnomaly,
+		nodeo:   tle.RightAscension,
+	}
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+/*
+Position gives the position and velocity of the satellite at t in the TEME frame, in km and km/s relative to the
+center of the Earth. The position is returned along with ErrDecayed if the satellite would be inside the Earth.
+*/
+func (s *Satellite) Position(t time.Time) (*orbcore.Position, error) {
+	return s.Propagate(t.Sub(s.TLE.Epoch).Minutes())
+}
+
+/*
+Propagate gives the position and velocity of the satellite a number of minutes from the epoch of the element set, the
+way the SGP4 test cases are written.
+*/
+func (s *Satellite) Propagate(minutes float64) (*orbcore.Position, error) {
+	r, v, err := s.sgp4(minutes)
+	if r == nil {
+		return nil, err
+	}
+	return &orbcore.Position{
+		ID:          s.TLE.CatalogNumber,
+		Epoch:       s.TLE.Epoch.Add(time.Duration(math.Round(minutes * 60e9))),
+		X:           r[0],
+		Y:           r[1],
+		Z:           r[2],
+		VX:          v[0],
+		VY:          v[1],
+		VZ:          v[2],
+		HasVelocity: true,
+		Frame:       TEME,
+		Center:      "Earth",
+	}, err
+}
+
+// init is sgp4init, working out everything that does not change with time.
+func (s *Satellite) init() error {
+	if s.ecco < 0 || s.ecco >= 1 {
+		return ErrEccentricity
+	}
+	g := s.Gravity
+	j3oj2 := g.J3 / g.J2
+	ss := 78/g.Radius + 1
+	qzms2t := math.Pow((120-78)/g.Radius, 4)
+
+	// recover the original mean motion and semimajor axis from the kozai mean motion in the element set
+	noKozai := s.TLE.MeanMotion * twoPi / minPerDay
+	eccsq := s.ecco * s.ecco
+	omeosq := 1 - eccsq
+	rteosq := math.Sqrt(omeosq)
+	cosio := math.Cos(s.inclo)
+	cosio2 := cosio * cosio
+	ak := math.Pow(g.XKE/noKozai, x2o3)
+	d1 := 0.75 * g.J2 * (3*cosio2 - 1) / (rteosq * omeosq)
+	del := d1 / (ak * ak)
+	adel := ak * (1 - del*del - del*(1.0/3.0+134*del*del/81))
+	del = d1 / (adel * adel)
+	s.noUnkozai = noKozai / (1 + del)
+
+	ao := math.Pow(g.XKE/s.noUnkozai, x2o3)
+	sinio := math.Sin(s.inclo)
+	po := ao * omeosq
+	con42 := 1 - 5*cosio2
+	s.con41 = -con42 - cosio2 - cosio2
+	posq := po * po
+	rp := ao * (1 - s.ecco)
+	gsto := greenwichSiderealTime(s.epoch1950())
+
+	if omeosq < 0 && s.noUnkozai < 0 {
+		return ErrEccentricity
+	}
+
+	// perigees below 220 km use a simpler model for drag
+	s.isimp = rp < 220/g.Radius+1
+
+	// below 156 km the atmosphere density parameter s is brought down
+	sfour := ss
+	qzms24 := qzms2t
+	perige := (rp - 1) * g.Radius
+	if perige < 156 {
+		sfour = perige - 78
+		if perige < 98 {
+			sfour = 20
+		}
+		qzms24 = math.Pow((120-sfour)/g.Radius, 4)
+		sfour = sfour/g.Radius + 1
+	}
+	pinvsq := 1 / posq
+
+	tsi := 1 / (ao - sfour)
+	s.eta = ao * s.ecco * tsi
+	etasq := s.eta * s.eta
+	eeta := s.ecco * s.eta
+	psisq := math.Abs(1 - etasq)
+	coef := qzms24 * math.Pow(tsi, 4)
+	coef1 := coef / math.Pow(psisq, 3.5)
+	cc2 := coef1 * s.noUnkozai * (ao*(1+1.5*etasq+eeta*(4+etasq)) +
+		0.375*g.J2*tsi/psisq*s.con41*(8+3*etasq*(8+etasq)))
+	s.cc1 = s.bstar * cc2
+	cc3 := 0.0
+	if s.ecco > 1e-4 {
+		cc3 = -2 * coef * tsi * j3oj2 * s.noUnkozai * sinio / s.ecco
+	}
+	s.x1mth2 = 1 - cosio2
+	s.cc4 = 2 * s.noUnkozai * coef1 * ao * omeosq *
+		(s.eta*(2+0.5*etasq) + s.ecco*(0.5+2*etasq) -
+			g.J2*tsi/(ao*psisq)*(-3*s.con41*(1-2*eeta+etasq*(1.5-0.5*eeta))+
+				0.75*s.x1mth2*(2*etasq-eeta*(1+etasq))*math.Cos(2*s.argpo)))
+	s.cc5 = 2 * coef1 * ao * omeosq * (1 + 2.75*(etasq+eeta) + eeta*etasq)
+
+	cosio4 := cosio2 * cosio2
+	temp1 := 1.5 * g.J2 * pinvsq * s.noUnkozai
+	temp2 := 0.5 * temp1 * g.J2 * pinvsq
+	temp3 := -0.46875 * g.J4 * pinvsq * pinvsq * s.noUnkozai
+	s.mdot = s.noUnkozai + 0.5*temp1*rteosq*s.con41 + 0.0625*temp2*rteosq*(13-78*cosio2+137*cosio4)
+	s.argpdot = -0.5*temp1*con42 + 0.0625*temp2*(7-114*cosio2+395*cosio4) + temp3*(3-36*cosio2+49*cosio4)
+	xhdot1 := -temp1 * cosio
+	s.nodedot = xhdot1 + (0.5*temp2*(4-19*cosio2)+2*temp3*(3-7*cosio2))*cosio
+	xpidot := s.argpdot + s.nodedot
+	s.omgcof = s.bstar * cc3 * math.Cos(s.argpo)
+	if s.ecco > 1e-4 {
+		s.xmcof = -x2o3 * coef * s.bstar / eeta
+	}
+	s.nodecf = 3.5 * omeosq * xhdot1 * s.cc1
+	s.t2cof = 1.5 * s.cc1
+	s.xlcof = lcof(j3oj2, sinio, cosio)
+	s.aycof = -0.5 * j3oj2 * sinio
+	s.delmo = math.Pow(1+s.eta*math.Cos(s.mo), 3)
+	s.sinmao = math.Sin(s.mo)
+	s.x7thm1 = 7*cosio2 - 1
+
+	if twoPi/s.noUnkozai >= 225 {
+		s.deepSpace = true
+		s.isimp = true
+		s.deep.init(s, gsto, eccsq, xpidot)
+	}
+
+	if !s.isimp {
+		cc1sq := s.cc1 * s.cc1
+		s.d2 = 4 * ao * tsi * cc1sq
+		temp := s.d2 * tsi * s.cc1 / 3
+		s.d3 = (17*ao + sfour) * temp
+		s.d4 = 0.5 * temp * ao * tsi * (221*ao + 31*sfour) * s.cc1
+		s.t3cof = s.d2 + 2*cc1sq
+		s.t4cof = 0.25 * (3*s.d3 + s.cc1*(12*s.d2+10*cc1sq))
+		s.t5cof = 0.2 * (3*s.d4 + 12*s.cc1*s.d3 + 6*s.d2*s.d2 + 15*cc1sq*(2*s.d2+cc1sq))
+	}
+
+	_, _, err := s.sgp4(0)
+	return err
+}
+
+// sgp4 propagates the satellite tsince minutes from the epoch, giving the position in km and velocity in km/s.
+func (s *Satellite) sgp4(tsince float64) ([]float64, []float64, error) {
+	g := s.Gravity
+	j3oj2 := g.J3 / g.J2
+	vkmpersec := g.Radius * g.XKE / 60
+	t := tsince
+
+	// secular gravity and atmospheric drag
+	xmdf := s.mo + s.mdot*t
+	argpdf := s.argpo + s.argpdot*t
+	nodedf := s.nodeo + s.nodedot*t
+	argpm := argpdf
+	mm := xmdf
+	t2 := t * t
+	nodem := nodedf + s.nodecf*t2
+	tempa := 1 - s.cc1*t
+	tempe := s.bstar * s.cc4 * t
+	templ := s.t2cof * t2
+
+	if !s.isimp {
+		delomg := s.omgcof * t
+		delm := s.xmcof * (math.Pow(1+s.eta*math.Cos(xmdf), 3) - s.delmo)
+		temp := delomg + delm
+		mm = xmdf + temp
+		argpm = argpdf - temp
+		t3 := t2 * t
+		t4 := t3 * t
+		tempa = tempa - s.d2*t2 - s.d3*t3 - s.d4*t4
+		tempe = tempe + s.bstar*s.cc5*(math.Sin(mm)-s.sinmao)
+		templ = templ + s.t3cof*t3 + t4*(s.t4cof+t*s.t5cof)
+	}
+
+	nm := s.noUnkozai
+	em := s.ecco
+	inclm := s.inclo
+	if s.deepSpace {
+		em, argpm, inclm, mm, nodem, nm = s.deep.space(s, t, em, argpm, inclm, mm, nodem)
+	}
+
+	if nm <= 0 {
+		return nil, nil, ErrMeanMotion
+	}
+	am := math.Pow(g.XKE/nm, x2o3) * tempa * tempa
+	nm = g.XKE / math.Pow(am, 1.5)
+	em = em - tempe
+
+	if em >= 1 || em < -0.001 {
+		return nil, nil, ErrEccentricity
+	}
+	if em < 1e-6 {
+		em = 1e-6
+	}
+	mm = mm + s.noUnkozai*templ
+	xlm := mm + argpm + nodem
+
+	nodem = math.Mod(nodem, twoPi)
+	argpm = math.Mod(argpm, twoPi)
+	xlm = math.Mod(xlm, twoPi)
+	mm = math.Mod(xlm-argpm-nodem, twoPi)
+
+	// lunar and solar periodics
+	ep := em
+	xincp := inclm
+	argpp := argpm
+	nodep := nodem
+	mp := mm
+	sinip := math.Sin(inclm)
+	cosip := math.Cos(inclm)
+	aycof, xlcof := s.aycof, s.xlcof
+	con41, x1mth2, x7thm1 := s.con41, s.x1mth2, s.x7thm1
+	if s.deepSpace {
+		ep, xincp, nodep, argpp, mp = s.deep.periodics(t, ep, xincp, nodep, argpp, mp)
+		if xincp < 0 {
+			xincp = -xincp
+			nodep = nodep + math.Pi
+			argpp = argpp - math.Pi
+		}
+		if ep < 0 || ep > 1 {
+			return nil, nil, ErrPerturbedEccentricity
+		}
+
+		sinip = math.Sin(xincp)
+		cosip = math.Cos(xincp)
+		aycof = -0.5 * j3oj2 * sinip
+		xlcof = lcof(j3oj2, sinip, cosip)
+		cosisq := cosip * cosip
+		con41 = 3*cosisq - 1
+		x1mth2 = 1 - cosisq
+		x7thm1 = 7*cosisq - 1
+	}
+
+	// long period periodics
+	axnl := ep * math.Cos(argpp)
+	temp := 1 / (am * (1 - ep*ep))
+	aynl := ep*math.Sin(argpp) + temp*aycof
+	xl := mp + argpp + nodep + temp*xlcof*axnl
+
+	// solve kepler's equation
+	u := math.Mod(xl-nodep, twoPi)
+	eo1 := u
+	tem5 := 9999.9
+	var sineo1, coseo1 float64
+	for ktr := 1; math.Abs(tem5) >= 1e-12 && ktr <= 10; ktr++ {
+		sineo1 = math.Sin(eo1)
+		coseo1 = math.Cos(eo1)
+		tem5 = 1 - coseo1*axnl - sineo1*aynl
+		tem5 = (u - aynl*coseo1 + axnl*sineo1 - eo1) / tem5
+		if math.Abs(tem5) >= 0.95 {
+			tem5 = math.Copysign(0.95, tem5)
+		}
+		eo1 = eo1 + tem5
+	}
+
+	// short period preliminary quantities
+	ecose := axnl*coseo1 + aynl*sineo1
+	esine := axnl*sineo1 - aynl*coseo1
+	el2 := axnl*axnl + aynl*aynl
+	pl := am * (1 - el2)
+	if pl < 0 {
+		return nil, nil, ErrSemiLatusRectum
+	}
+	rl := am * (1 - ecose)
+	rdotl := math.Sqrt(am) * esine / rl
+	rvdotl := math.Sqrt(pl) / rl
+	betal := math.Sqrt(1 - el2)
+	temp = esine / (1 + betal)
+	sinu := am / rl * (sineo1 - aynl - axnl*temp)
+	cosu := am / rl * (coseo1 - axnl + aynl*temp)
+	su := math.Atan2(sinu, cosu)
+	sin2u := (cosu + cosu) * sinu
+	cos2u := 1 - 2*sinu*sinu
+	temp = 1 / pl
+	temp1 := 0.5 * g.J2 * temp
+	temp2 := temp1 * temp
+
+	// update for short period periodics
+	mrt := rl*(1-1.5*temp2*betal*con41) + 0.5*temp1*x1mth2*cos2u
+	su = su - 0.25*temp2*x7thm1*sin2u
+	xnode := nodep + 1.5*temp2*cosip*sin2u
+	xinc := xincp + 1.5*temp2*cosip*sinip*cos2u
+	mvt := rdotl - nm*temp1*x1mth2*sin2u/g.XKE
+	rvdot := rvdotl + nm*temp1*(x1mth2*cos2u+1.5*con41)/g.XKE
+
+	// orientation vectors
+	sinsu := math.Sin(su)
+	cossu := math.Cos(su)
+	snod := math.Sin(xnode)
+	cnod := math.Cos(xnode)
+	sini := math.Sin(xinc)
+	cosi := math.Cos(xinc)
+	xmx := -snod * cosi
+	xmy := cnod * cosi
+	ux := xmx*sinsu + cnod*cossu
+	uy := xmy*sinsu + snod*cossu
+	uz := sini * sinsu
+	vx := xmx*cossu - cnod*sinsu
+	vy := xmy*cossu - snod*sinsu
+	vz := sini * cossu
+
+	r := []float64{mrt * ux * g.Radius, mrt * uy * g.Radius, mrt * uz * g.Radius}
+	v := []float64{
+		(mvt*ux + rvdot*vx) * vkmpersec,
+		(mvt*uy + rvdot*vy) * vkmpersec,
+		(mvt*uz + rvdot*vz) * vkmpersec,
+	}
+	if mrt < 1 {
+		return r, v, ErrDecayed
+	}
+	return r, v, nil
+}
+
+// epoch1950 is the epoch of the element set in days from 1950 January 0.0.
+func (s *Satellite) epoch1950() float64 {
+	return float64(s.TLE.Epoch.Sub(epoch1950)) / float64(24*time.Hour)
+}
+
+// lcof is the coefficient of the long period periodics in the mean longitude, avoiding a divide by zero for
+// retrograde equatorial orbits.
+func lcof(j3oj2, sini, cosi float64) float64 {
+	if math.Abs(cosi+1) > temp4 {
+		return -0.25 * j3oj2 * sini * (3 + 5*cosi) / (1 + cosi)
+	}
+	return -0.25 * j3oj2 * sini * (3 + 5*cosi) / temp4
+}
+
+// greenwichSiderealTime gives the Greenwich mean sidereal time in radians for a time in days from 1950 January 0.0
+// using the IAU 1982 model.
+func greenwichSiderealTime(epoch float64) float64 {
+	tut1 := (epoch + 2433281.5 - 2451545) / 36525
+	temp := -6.2e-6*tut1*tut1*tut1 + 0.093104*tut1*tut1 + (876600*3600+8640184.812866)*tut1 + 67310.54841
+	temp = math.Mod(temp*math.Pi/180/240, twoPi)
+	if temp < 0 {
+		temp += twoPi
+	}
+	return temp
+}
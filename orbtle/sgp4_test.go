@@ -0,0 +1,328 @@
+package orbtle
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+// verification is one expected result, either a state or the error the reference implementation stopped with
+type verification struct {
+	minutes float64
+	state   [6]float64 // position in km then velocity in km/s
+	err     error
+}
+
+// verificationErrors maps the error codes printed by the reference implementation to ours
+var verificationErrors = map[int]error{
+	1: ErrEccentricity,
+	2: ErrMeanMotion,
+	3: ErrPerturbedEccentricity,
+	4: ErrSemiLatusRectum,
+	6: ErrDecayed,
+}
+
+// verificationSets are checked by TestPropagateVerification. Tolerances are in km and km/s. Optional sets are skipped
+// when their files are missing.
+var verificationSets = []struct {
+	tles, states       string
+	gravity            Gravity
+	position, velocity float64
+	optional           bool
+}{
+	{"testdata/vallado-excerpt.tle", "testdata/vallado-excerpt.out", WGS72, 1e-6, 1e-8, false},
+	{"testdata/str3.tle", "testdata/str3.out", WGS72Old, 0.05, 5e-5, false},
+	// the full verification set of Vallado et al. is not bundled, copy it in to check every case
+	{"testdata/SGP4-VER.TLE", "testdata/tcppver.out", WGS72, 1e-6, 1e-8, true},
+}
+
+// catalogKey drops the leading zeros, the reference output prints catalog numbers without them
+func catalogKey(catalog string) string {
+	return strings.TrimLeft(catalog, "0")
+}
+
+// readVerification reads the expected results for each catalog number from a file laid out like the tcppver.out
+// produced by the reference implementation. Lines starting with # are comments unless they report an error.
+func readVerification(t *testing.T, path string) map[string][]verification {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	result := make(map[string][]verification)
+	catalog := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if strings.HasPrefix(line, "#") {
+			// "# *** error: t:= 494.202867 *** code =   1"
+			var v verification
+			var code int
+			if _, err := fmt.Sscanf(line, "# *** error: t:= %f *** code = %d", &v.minutes, &code); err == nil {
+				if v.err = verificationErrors[code]; v.err == nil {
+					t.Fatalf("unknown error code in %v: %q", path, line)
+				}
+				result[catalog] = append(result[catalog], v)
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 2 && fields[1] == "xx" {
+			catalog = catalogKey(fields[0])
+			result[catalog] = nil
+			continue
+		}
+		// the full output has the date and osculating elements after the state, only the state is checked
+		if len(fields) < 7 || catalog == "" {
+			t.Fatalf("unexpected line in %v: %q", path, line)
+		}
+		var v verification
+		if v.minutes, err = strconv.ParseFloat(fields[0], 64); err != nil {
+			t.Fatal(err)
+		}
+		for i := range v.state {
+			if v.state[i], err = strconv.ParseFloat(fields[i+1], 64); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result[catalog] = append(result[catalog], v)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// readVerificationTLEs reads the element sets of a verification file. SGP4-VER.TLE puts the start, stop and step
+// times after the second line of each set so anything beyond the 69th column is dropped.
+func readVerificationTLEs(t *testing.T, path string) []*TLE {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result []*TLE
+	var line1 string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \r")
+		switch {
+		case strings.HasPrefix(line, "1 "):
+			line1 = line
+		case strings.HasPrefix(line, "2 "):
+			if len(line) > 69 {
+				line = line[:69]
+			}
+			tle, err := ParseTLE(line1, line)
+			if err != nil {
+				t.Fatalf("%v: %v", path, err)
+			}
+			result = append(result, tle)
+		}
+	}
+	return result
+}
+
+func TestPropagateVerification(t *testing.T) {
+	for _, set := range verificationSets {
+		if _, err := os.Stat(set.states); set.optional && os.IsNotExist(err) {
+			t.Logf("skipping %v, not found", set.states)
+			continue
+		}
+		expected := readVerification(t, set.states)
+		tles := readVerificationTLEs(t, set.tles)
+
+		for _, tle := range tles {
+			key := catalogKey(tle.CatalogNumber)
+			results, ok := expected[key]
+			if !ok {
+				t.Errorf("%v: no expected results for %v", set.states, tle.CatalogNumber)
+				continue
+			}
+			delete(expected, key)
+
+			sat, err := NewSatelliteWithGravity(tle, set.gravity)
+			if err != nil {
+				if len(results) == 0 || results[0].err != err {
+					t.Errorf("%v: unexpected error %v", tle.CatalogNumber, err)
+				}
+				continue
+			}
+			for _, v := range results {
+				p, err := sat.Propagate(v.minutes)
+				if v.err != nil {
+					if err != v.err {
+						t.Errorf("%v at %v minutes: expected error %v got %v", tle.CatalogNumber, v.minutes, v.err, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%v at %v minutes: %v", tle.CatalogNumber, v.minutes, err)
+					continue
+				}
+				got := [6]float64{p.X, p.Y, p.Z, p.VX, p.VY, p.VZ}
+				for i := range got {
+					tolerance := set.position
+					if i > 2 {
+						tolerance = set.velocity
+					}
+					if math.Abs(got[i]-v.state[i]) > tolerance {
+						t.Errorf("%v at %v minutes: expected %v got %v", tle.CatalogNumber, v.minutes, v.state, got)
+						break
+					}
+				}
+			}
+		}
+		for catalog := range expected {
+			t.Errorf("%v: no element set for %v", set.tles, catalog)
+		}
+	}
+}
+
+func TestSatellitePosition(t *testing.T) {
+	tle, err := ParseTLE(vanguard1, vanguard2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sat, err := NewSatellite(tle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := sat.Position(tle.Epoch.Add(6 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(p.X+7154.03120202) > 1e-3 || math.Abs(p.Y+3783.17682504) > 1e-3 || math.Abs(p.Z+3536.19412294) > 1e-3 {
+		t.Errorf("wrong position after six hours %v", p)
+	}
+	if p.ID != "00005" || p.Frame != TEME || p.Center != "Earth" || !p.HasVelocity {
+		t.Errorf("wrong position metadata %v", p)
+	}
+	if !p.Epoch.Equal(tle.Epoch.Add(6 * time.Hour)) {
+		t.Errorf("expected epoch %v got %v", tle.Epoch.Add(6*time.Hour), p.Epoch)
+	}
+}
+
+func TestSatelliteErrors(t *testing.T) {
+	tle, err := ParseTLE(vanguard1, vanguard2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hyperbolic := *tle
+	hyperbolic.Eccentricity = 1.2
+	if _, err := NewSatellite(&hyperbolic); err != ErrEccentricity {
+		t.Errorf("expected an eccentricity error for an eccentricity above one got %v", err)
+	}
+
+	// a slow and eccentric deep space orbit has the lunar and solar terms push the eccentricity out of range at epoch
+	slow := *tle
+	slow.MeanMotion, slow.Eccentricity, slow.Inclination = 0.00001, 0.5602877, 68.4714*math.Pi/180
+	if _, err := NewSatellite(&slow); err != ErrPerturbedEccentricity {
+		t.Errorf("expected a perturbed eccentricity error got %v", err)
+	}
+
+	start, err := ParseTLE(
+		"1 88888U          80275.98708465  .00073094  13844-3  66816-4 0    87",
+		"2 88888  72.8435 115.9689 0086731  52.6988 110.5714 16.05824518  1058")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name                                  string
+		meanMotion, eccentricity, inclination float64
+		bstar                                 float64
+		expected                              error
+	}{
+		// heavy drag on an orbit with a perigee under 220 km takes the mean eccentricity below zero first
+		{"drag", 16.05824518, 0.0086731, 72.8435, 0.01, ErrEccentricity},
+		// the J3 long period terms take a near parabolic orbit past an eccentricity of one
+		{"near parabolic", 7, 0.999, 90, 0, ErrSemiLatusRectum},
+		// a circular orbit keeps its eccentricity while drag brings it down inside the earth
+		{"decay", 15.5, 0.0001, 50, 0.05, ErrDecayed},
+	}
+	for _, c := range cases {
+		elements := *start
+		elements.MeanMotion = c.meanMotion
+		elements.Eccentricity = c.eccentricity
+		elements.Inclination = c.inclination * math.Pi / 180
+		elements.BStar = c.bstar
+		// NewSatellite propagates to the epoch so it can return the error too
+		var p *orbcore.Position
+		sat, err := NewSatellite(&elements)
+		for minutes := 0.0; minutes < 14400 && err == nil; minutes += 10 {
+			p, err = sat.Propagate(minutes)
+		}
+		if err != c.expected {
+			t.Errorf("%v: expected %v got %v", c.name, c.expected, err)
+		}
+		// a decayed satellite still has a position, the other errors do not
+		if (p != nil) != (c.expected == ErrDecayed) {
+			t.Errorf("%v: unexpected position %v with %v", c.name, p, err)
+		}
+	}
+}
+
+func TestDeepSpace(t *testing.T) {
+	tle, err := ParseTLE(vanguard1, vanguard2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                                  string
+		meanMotion, eccentricity, inclination float64
+		resonance                             int
+	}{
+		{"non resonant", 4, 0.3, 0.5, 0},
+		{"geostationary", 1.0027, 0.0003, 0.001, 1},
+		{"molniya", 2.006, 0.7, 1.1, 2},
+	}
+	for _, c := range cases {
+		elements := *tle
+		elements.MeanMotion = c.meanMotion
+		elements.Eccentricity = c.eccentricity
+		elements.Inclination = c.inclination
+		sat, err := NewSatellite(&elements)
+		if err != nil {
+			t.Fatalf("%v: %v", c.name, err)
+		}
+		if !sat.deepSpace || sat.deep.irez != c.resonance {
+			t.Errorf("%v: expected deep space with resonance %v got %v %v", c.name, c.resonance, sat.deepSpace, sat.deep.irez)
+		}
+
+		// three days should not move the orbit far from the one it started on
+		n := c.meanMotion * 2 * math.Pi / 86400
+		a := math.Cbrt(sat.Gravity.Mu / (n * n))
+		for minutes := 0.0; minutes <= 4320; minutes += 60 {
+			p, err := sat.Propagate(minutes)
+			if err != nil {
+				t.Fatalf("%v at %v minutes: %v", c.name, minutes, err)
+			}
+			r := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+			if r < 0.98*a*(1-c.eccentricity) || r > 1.02*a*(1+c.eccentricity) {
+				t.Errorf("%v at %v minutes: radius %v outside the orbit of semimajor axis %v", c.name, minutes, r, a)
+			}
+		}
+
+		// the resonance integration does not depend on the times propagated to before
+		later, _ := sat.Propagate(100)
+		fresh, _ := NewSatellite(&elements)
+		first, _ := fresh.Propagate(100)
+		if math.Abs(later.X-first.X) > 1e-9 || math.Abs(later.Y-first.Y) > 1e-9 || math.Abs(later.Z-first.Z) > 1e-9 {
+			t.Errorf("%v: propagating out of order gave %v not %v", c.name, later, first)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+/*
+Package orbtle reads NORAD two line element sets and propagates them with SGP4 and SDP4, the models they are
+generated for, to give the positions of Earth satellites.
+
+The propagator follows the revised SGP4 of Vallado, Crawford, Hujsak and Kelso, "Revisiting Spacetrack Report #3"
+(AIAA 2006-6753), and gives positions in the True Equator Mean Equinox (TEME) frame centered on the Earth.
+*/
+package orbtle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+// tleLineLength is the length of both lines of a two line element set, including the checksum
+const tleLineLength = 69
+
+/*
+TLE holds a two line element set. Angles are in radians and the mean motion in revolutions per day, as they are
+written. MeanMotionDot is the first derivative of the mean motion divided by two in rev/day², MeanMotionDDot the
+second divided by six in rev/day³, and BStar the drag term in inverse earth radii.
+*/
+type TLE struct {
+	Name                    string
+	CatalogNumber           string
+	Classification          byte
+	InternationalDesignator string
+	Epoch                   time.Time
+	MeanMotionDot           float64
+	MeanMotionDDot          float64
+	BStar                   float64
+	EphemerisType           int
+	ElementSetNumber        int
+	Inclination             float64
+	RightAscension          float64
+	Eccentricity            float64
+	ArgumentOfPerigee       float64
+	MeanAnomaly             float64
+	MeanMotion              float64
+	RevolutionNumber        int
+}
+
+func (t *TLE) String() string {
+	return fmt.Sprintf("%v %v epoch: %v i: %v raan: %v e: %v w: %v M: %v n: %v bstar: %v",
+		t.CatalogNumber, t.Name, t.Epoch.Format(time.RFC3339Nano), t.Inclination, t.RightAscension, t.Eccentricity,
+		t.ArgumentOfPerigee, t.MeanAnomaly, t.MeanMotion, t.BStar)
+}
+
+/*
+ParseTLE reads an element set from its two lines. The checksum on each line is checked and the catalog numbers on the
+two lines must match. Trailing white space is ignored.
+*/
+func ParseTLE(line1, line2 string) (*TLE, error) {
+	line1 = strings.TrimRight(line1, " \t\r")
+	line2 = strings.TrimRight(line2, " \t\r")
+	if err := checkLine(line1, '1'); err != nil {
+		return nil, fmt.Errorf("line 1: %v", err)
+	}
+	if err := checkLine(line2, '2'); err != nil {
+		return nil, fmt.Errorf("line 2: %v", err)
+	}
+
+	p := fieldParser{line: line1}
+	result := TLE{
+		CatalogNumber:           strings.TrimSpace(line1[2:7]),
+		Classification:          line1[7],
+		InternationalDesignator: strings.TrimSpace(line1[9:17]),
+	}
+	result.Epoch = p.epoch(18, 32)
+	result.MeanMotionDot = p.float(33, 43)
+	result.MeanMotionDDot = p.exponent(44, 52)
+	result.BStar = p.exponent(53, 61)
+	result.EphemerisType = p.int(62, 63)
+	result.ElementSetNumber = p.int(64, 68)
+	if p.err != nil {
+		return nil, fmt.Errorf("line 1: %v", p.err)
+	}
+
+	if catalog := strings.TrimSpace(line2[2:7]); catalog != result.CatalogNumber {
+		return nil, fmt.Errorf("line 2: catalog number %q does not match %q on line 1", catalog, result.CatalogNumber)
+	}
+	p = fieldParser{line: line2}
+	result.Inclination = p.angle(8, 16)
+	result.RightAscension = p.angle(17, 25)
+	result.Eccentricity = p.decimal(26, 33)
+	result.ArgumentOfPerigee = p.angle(34, 42)
+	result.MeanAnomaly = p.angle(43, 51)
+	result.MeanMotion = p.float(52, 63)
+	result.RevolutionNumber = p.int(63, 68)
+	if p.err != nil {
+		return nil, fmt.Errorf("line 2: %v", p.err)
+	}
+	return &result, nil
+}
+
+/*
+Checksum works out the checksum of the first 68 characters of a line, the sum of its digits plus one for each minus
+sign, modulo ten.
+*/
+func Checksum(line string) int {
+	sum := 0
+	for i := 0; i < len(line) && i < tleLineLength-1; i++ {
+		switch c := line[i]; {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c == '-':
+			sum++
+		}
+	}
+	return sum % 10
+}
+
+func checkLine(line string, number byte) error {
+	if len(line) != tleLineLength {
+		return fmt.Errorf("expected %d characters got %d", tleLineLength, len(line))
+	}
+	if line[0] != number || line[1] != ' ' {
+		return fmt.Errorf("expected the line to start with %q got %q", string(number)+" ", line[:2])
+	}
+	last := line[tleLineLength-1]
+	if last < '0' || last > '9' {
+		return fmt.Errorf("checksum %q is not a digit", last)
+	}
+	if sum := Checksum(line); sum != int(last-'0') {
+		return fmt.Errorf("checksum is %c but the line sums to %d", last, sum)
+	}
+	return nil
+}
+
+// fieldParser reads fixed width fields from a line, keeping the first error so the fields can be read one after
+// another and checked once.
+type fieldParser struct {
+	line string
+	err  error
+}
+
+func (p *fieldParser) field(start, end int) string {
+	return strings.TrimSpace(p.line[start:end])
+}
+
+func (p *fieldParser) fail(start, end int, what string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("columns %d-%d: could not parse %q as %v", start+1, end, p.line[start:end], what)
+	}
+}
+
+func (p *fieldParser) float(start, end int) float64 {
+	f := p.field(start, end)
+	if f == "" {
+		return 0
+	}
+	// Some element sets leave out the zero before the decimal point after a minus sign.
+	f = strings.Replace(f, "-.", "-0.", 1)
+	v, err := strconv.ParseFloat(f, 64)
+	if err != nil {
+		p.fail(start, end, "a number")
+	}
+	return v
+}
+
+func (p *fieldParser) int(start, end int) int {
+	f := p.field(start, end)
+	if f == "" {
+		return 0
+	}
+	v, err := strconv.Atoi(f)
+	if err != nil {
+		p.fail(start, end, "an integer")
+	}
+	return v
+}
+
+func (p *fieldParser) angle(start, end int) float64 {
+	return p.float(start, end) * math.Pi / 180
+}
+
+// decimal reads a field with an assumed leading decimal point, like the eccentricity.
+func (p *fieldParser) decimal(start, end int) float64 {
+	f := p.field(start, end)
+	v, err := strconv.ParseFloat("0."+f, 64)
+	if err != nil || strings.ContainsAny(f, "+-. ") {
+		p.fail(start, end, "a decimal")
+	}
+	return v
+}
+
+// exponent reads a field with an assumed decimal point and a power of ten, " 12345-3" is 0.12345e-3.
+func (p *fieldParser) exponent(start, end int) float64 {
+	f := p.field(start, end)
+	if f == "" {
+		return 0
+	}
+	if len(f) < 3 || (f[len(f)-2] != '-' && f[len(f)-2] != '+') {
+		p.fail(start, end, "a number with an exponent")
+		return 0
+	}
+	mantissa := f[:len(f)-2]
+	sign := ""
+	if mantissa[0] == '-' || mantissa[0] == '+' {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	v, err := strconv.ParseFloat(sign+"0."+mantissa+"e"+f[len(f)-2:], 64)
+	if err != nil || strings.ContainsAny(mantissa, "+-. ") {
+		p.fail(start, end, "a number with an exponent")
+	}
+	return v
+}
+
+// epoch reads the two digit year and fractional day of the year. Years from 57 are in the 1900s.
+func (p *fieldParser) epoch(start, end int) time.Time {
+	year := p.int(start, start+2)
+	day := p.float(start+2, end)
+	if p.err != nil {
+		return time.Time{}
+	}
+	if day < 1 || day >= 367 {
+		p.fail(start, end, "an epoch")
+		return time.Time{}
+	}
+	if year < 57 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	whole := math.Floor(day)
+	nanos := math.Round((day - whole) * 86400e9)
+	return time.Date(year, 1, int(whole), 0, 0, 0, 0, time.UTC).Add(time.Duration(nanos))
+}
+
+/*
+TLEReader reads element sets from a file one at a time. Both the two line form and the three line form, with a name
+line before each set, are understood, as are the "0 " prefixed name lines some sources use. Blank lines and comment
+lines starting with # are skipped.
+*/
+type TLEReader struct {
+	file    io.Closer
+	scanner *bufio.Scanner
+	line    int
+}
+
+/*
+NewTLEReader opens a file of element sets. Gzip and bzip2 compressed files are decompressed on the fly.
+*/
+func NewTLEReader(path string) (*TLEReader, error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	result := NewTLEReaderFromReader(f)
+	result.file = f
+	return result, nil
+}
+
+/*
+NewTLEReaderFromReader creates a TLEReader that reads from an already open reader.
+*/
+func NewTLEReaderFromReader(in io.Reader) *TLEReader {
+	return &TLEReader{scanner: bufio.NewScanner(in)}
+}
+
+/*
+ReadEntry returns the next element set. At the end of the input io.EOF is returned.
+*/
+func (r *TLEReader) ReadEntry() (*TLE, error) {
+	first, err := r.next()
+	if err != nil {
+		return nil, err
+	}
+
+	name := ""
+	if !strings.HasPrefix(first, "1 ") {
+		name = strings.TrimSpace(strings.TrimPrefix(first, "0 "))
+		if first, err = r.next(); err != nil {
+			return nil, r.unexpected(err)
+		}
+	}
+	second, err := r.next()
+	if err != nil {
+		return nil, r.unexpected(err)
+	}
+
+	tle, err := ParseTLE(first, second)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", r.line-1, err)
+	}
+	tle.Name = name
+	return tle, nil
+}
+
+/*
+Close closes the underlying file if this reader opened it.
+*/
+func (r *TLEReader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+func (r *TLEReader) next() (string, error) {
+	for r.scanner.Scan() {
+		r.line++
+		if line := r.scanner.Text(); strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+func (r *TLEReader) unexpected(err error) error {
+	if err == io.EOF {
+		return fmt.Errorf("line %d: element set is not complete", r.line)
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package orbtle
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	vanguard1 = "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753"
+	vanguard2 = "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667"
+)
+
+func TestParseTLE(t *testing.T) {
+	tle, err := ParseTLE(vanguard1, vanguard2+"  \r")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tle.CatalogNumber != "00005" || tle.Classification != 'U' || tle.InternationalDesignator != "58002B" {
+		t.Errorf("wrong identifiers %v %c %v", tle.CatalogNumber, tle.Classification, tle.InternationalDesignator)
+	}
+	epoch := time.Date(2000, 6, 27, 18, 50, 19, 733568000, time.UTC)
+	if d := tle.Epoch.Sub(epoch); d < -time.Microsecond || d > time.Microsecond {
+		t.Errorf("expected epoch %v got %v", epoch, tle.Epoch)
+	}
+
+	floats := []struct {
+		name            string
+		value, expected float64
+	}{
+		{"MeanMotionDot", tle.MeanMotionDot, 0.00000023},
+		{"MeanMotionDDot", tle.MeanMotionDDot, 0},
+		{"BStar", tle.BStar, 0.28098e-4},
+		{"Inclination", tle.Inclination, 34.2682 * math.Pi / 180},
+		{"RightAscension", tle.RightAscension, 348.7242 * math.Pi / 180},
+		{"Eccentricity", tle.Eccentricity, 0.1859667},
+		{"ArgumentOfPerigee", tle.ArgumentOfPerigee, 331.7664 * math.Pi / 180},
+		{"MeanAnomaly", tle.MeanAnomaly, 19.3264 * math.Pi / 180},
+		{"MeanMotion", tle.MeanMotion, 10.82419157},
+	}
+	for _, f := range floats {
+		if math.Abs(f.value-f.expected) > 1e-12 {
+			t.Errorf("%v: expected %v got %v", f.name, f.expected, f.value)
+		}
+	}
+	if tle.ElementSetNumber != 475 || tle.RevolutionNumber != 41366 {
+		t.Errorf("expected element set 475 and revolution 41366 got %v and %v", tle.ElementSetNumber, tle.RevolutionNumber)
+	}
+}
+
+func TestParseTLEFields(t *testing.T) {
+	p := fieldParser{line: " 12345-3 -11606-4 +1-1 -.00002182"}
+	if v := p.exponent(0, 8); math.Abs(v-0.12345e-3) > 1e-15 {
+		t.Errorf("expected 0.12345e-3 got %v", v)
+	}
+	if v := p.exponent(8, 17); math.Abs(v+0.11606e-4) > 1e-15 {
+		t.Errorf("expected -0.11606e-4 got %v", v)
+	}
+	if v := p.exponent(17, 22); math.Abs(v-0.01) > 1e-15 {
+		t.Errorf("expected 0.01 got %v", v)
+	}
+	if v := p.float(22, 33); v != -0.00002182 {
+		t.Errorf("expected -0.00002182 got %v", v)
+	}
+	if p.err != nil {
+		t.Error(p.err)
+	}
+
+	p = fieldParser{line: "80275.98708465 57001.5"}
+	if e := p.epoch(0, 14); e.Year() != 1980 || e.YearDay() != 275 {
+		t.Errorf("expected day 275 of 1980 got %v", e)
+	}
+	if e := p.epoch(15, 22); e.Year() != 1957 || e.YearDay() != 1 || e.Hour() != 12 {
+		t.Errorf("expected noon on the first of 1957 got %v", e)
+	}
+}
+
+func TestParseTLEErrors(t *testing.T) {
+	cases := map[string][2]string{
+		"line 1: checksum": {vanguard1[:68] + "4", vanguard2},
+		"line 2: checksum": {vanguard1, vanguard2[:68] + "0"},
+		"line 1: expected": {vanguard1[:60], vanguard2},
+		"line 2: expected": {vanguard1, vanguard1},
+		"line 2: catalog":  {vanguard1, withChecksum("2 00006" + vanguard2[7:68])},
+		"line 1: columns":  {withChecksum(vanguard1[:53] + " 2a098-4" + vanguard1[61:68]), vanguard2},
+	}
+	for prefix, lines := range cases {
+		_, err := ParseTLE(lines[0], lines[1])
+		if err == nil {
+			t.Errorf("%v: expected an error", prefix)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), prefix) {
+			t.Errorf("expected an error starting %q got %v", prefix, err)
+		}
+	}
+}
+
+func withChecksum(line string) string {
+	return line + strconv.Itoa(Checksum(line))
+}
+
+func TestChecksum(t *testing.T) {
+	for _, line := range []string{vanguard1, vanguard2} {
+		if sum := Checksum(line); sum != int(line[68]-'0') {
+			t.Errorf("%v: got checksum %v", line, sum)
+		}
+	}
+}
+
+func TestTLEReader(t *testing.T) {
+	input := strings.Join([]string{
+		"# mixed forms",
+		vanguard1,
+		vanguard2,
+		"",
+		"VANGUARD 1",
+		vanguard1,
+		vanguard2,
+		"0 VANGUARD 1",
+		vanguard1,
+		vanguard2,
+	}, "\n")
+
+	r := NewTLEReaderFromReader(strings.NewReader(input))
+	for _, name := range []string{"", "VANGUARD 1", "VANGUARD 1"} {
+		tle, err := r.ReadEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tle.Name != name || tle.CatalogNumber != "00005" {
+			t.Errorf("expected %q 00005 got %q %v", name, tle.Name, tle.CatalogNumber)
+		}
+	}
+	if _, err := r.ReadEntry(); err != io.EOF {
+		t.Errorf("expected EOF got %v", err)
+	}
+
+	r = NewTLEReaderFromReader(strings.NewReader("VANGUARD 1\n" + vanguard1 + "\n"))
+	if _, err := r.ReadEntry(); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("expected an incomplete error on line 2 got %v", err)
+	}
+
+	r = NewTLEReaderFromReader(strings.NewReader("\n" + vanguard1 + "\n" + vanguard2[:68] + "0\n"))
+	if _, err := r.ReadEntry(); err == nil || !strings.HasPrefix(err.Error(), "line 2: line 2: checksum") {
+		t.Errorf("expected a checksum error on line 2 got %v", err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"gonum.org/v1/plot/vg/draw"
-	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -13,7 +12,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/emilyselwood/gompcreader"
+	"github.com/emilyselwood/orbcalc/orbcatalog"
 	"github.com/emilyselwood/orbcalc/orbconvert"
 	"github.com/emilyselwood/orbcalc/orbcore"
 	"github.com/emilyselwood/orbcalc/orbdata"
@@ -29,12 +28,14 @@ import (
 const monitoringInterval = 1 * time.Second
 const processors = 3
 const channelSize = 100000
+const maxObjects = 1000000
 
 var inputfile = flag.String("in", "", "the minor planet center file to read")
 var outputPath = flag.String("out", "", "path to output files")
 var count = flag.Int("count", 6000, "number of frames to run")
 var maxSemiMajorAxis = flag.Float64("max", 7, "maximum semimajor axis to accept, in AU")
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
+var filter = flag.String("filter", "", "only plot orbits matching this expression as well as the -max limit, for example 'class == \"Hilda\"'")
 
 /*
 An example program that uses the gompcreader and calculates the position in space for each object.
@@ -65,6 +66,25 @@ func main() {
 
 	os.MkdirAll(*outputPath, os.ModePerm)
 
+	query, err := orbcatalog.ParseQuery(*filter)
+	if err != nil {
+		log.Fatal("could not parse filter ", err)
+	}
+
+	// Load the catalog once and keep the objects that stay inside the plot, every frame starts from these.
+	log.Println("loading data")
+	c, err := orbcatalog.Load(*inputfile)
+	if err != nil {
+		log.Fatal("could not load catalog ", err)
+	}
+	orbits := c.Filter(func(orb *orbcore.Orbit) bool {
+		aphelion := orbconvert.KmToAu(orb.SemimajorAxis) * (1 + orb.OrbitalEccentricity)
+		return aphelion < *maxSemiMajorAxis && query.Match(orb)
+	})
+	if len(orbits) > maxObjects {
+		orbits = orbits[:maxObjects]
+	}
+
 	saveChan := make(chan *savePack, 100)
 	var saveWait sync.WaitGroup
 	for i := 0; i < 4; i++ {
@@ -80,14 +100,14 @@ func main() {
 	}
 
 	for i := int64(0); i < int64(*count); i++ {
-		processFrame(i, saveChan)
+		processFrame(i, orbits, saveChan)
 	}
 
 	close(saveChan)
 	saveWait.Wait()
 }
 
-func processFrame(days int64, saveChan chan *savePack) {
+func processFrame(days int64, orbits []*orbcore.Orbit, saveChan chan *savePack) {
 	// rate counters for each processing stage
 	counter1 := ratecounter.NewRateCounter(monitoringInterval)
 	counter2 := ratecounter.NewRateCounter(monitoringInterval)
@@ -115,9 +135,9 @@ func processFrame(days int64, saveChan chan *savePack) {
 	var positionGroup sync.WaitGroup
 	var complete sync.WaitGroup
 
-	// Setup stage one which reads in the input file, parses the records from it and passes them on to the next stage.
+	// Setup stage one which passes the loaded orbits on to the next stage.
 	readGroup.Add(1)
-	go stageRead(*inputfile, 1000000, 0, stage1, &readGroup, counter1)
+	go stageRead(orbits, stage1, &readGroup, counter1)
 
 	// Stage two progates an object forward one day and then passes it on.
 	for i := 0; i < processors; i++ {
@@ -144,41 +164,15 @@ func processFrame(days int64, saveChan chan *savePack) {
 	log.Println("done", days)
 }
 
-// stageRead opens a file using the gompcreader project and reads out orbital information.
-func stageRead(inputfile string, target int, skip int, output chan *orbcore.Orbit, wg *sync.WaitGroup, counter *ratecounter.RateCounter) {
-	mpcReader, err := gompcreader.NewMpcReader(inputfile)
-	if err != nil {
-		log.Fatal("error creating mpcReader ", err)
-	}
-	defer mpcReader.Close()
+// stageRead feeds the loaded orbits into the processing pipeline.
+func stageRead(orbits []*orbcore.Orbit, output chan *orbcore.Orbit, wg *sync.WaitGroup, counter *ratecounter.RateCounter) {
 	defer close(output)
 	defer wg.Done()
 
-	var count int
-	result, err := mpcReader.ReadEntry()
-	for err == nil {
-		if skip == 0 {
-			a := result.SemimajorAxis * (1 + result.OrbitalEccentricity)
-
-			if a < *maxSemiMajorAxis {
-				orb := orbconvert.ConvertFromMinorPlanet(result)
-				//fmt.Println(orb)
-				output <- orb
-			}
-		} else {
-			skip--
-		}
+	for _, orb := range orbits {
+		output <- orb
 		counter.Incr(1)
-		count++
-		if count >= target {
-			return
-		}
-		result, err = mpcReader.ReadEntry()
-	}
-	if err != nil && err != io.EOF {
-		log.Fatal("error reading", err)
 	}
-
 }
 
 func stageMeanMotion(days int64, in chan *orbcore.Orbit, output chan *orbcore.Orbit, wg *sync.WaitGroup, counter *ratecounter.RateCounter) {
@@ -0,0 +1,166 @@
+// Compares two orbit catalogs, usually consecutive MPCORB releases, and reports what changed between them.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcatalog"
+	"github.com/emilyselwood/orbcalc/orbcore"
+)
+
+var oldPath = flag.String("old", "", "the older catalog, MPCORB.DAT or mpcorb_extended.json, optionally compressed")
+var newPath = flag.String("new", "", "the newer catalog")
+var outPath = flag.String("out", "", "where to write the changes, standard out if not given")
+var format = flag.String("format", "json", "output format, json (one change per line) or csv")
+var tolA = flag.Float64("tol-a", orbcatalog.DefaultTolerances.SemimajorAxis, "semimajor axis tolerance in AU")
+var tolE = flag.Float64("tol-e", orbcatalog.DefaultTolerances.Eccentricity, "eccentricity tolerance")
+var tolAngle = flag.Float64("tol-angle", orbcatalog.DefaultTolerances.Angle, "angle tolerance in degrees")
+var tolH = flag.Float64("tol-h", orbcatalog.DefaultTolerances.AbsoluteMagnitude, "absolute magnitude tolerance")
+var propagate = flag.String("propagate", "", "also work out positions for the added, changed and numbered objects on this date, YYYY-MM-DD")
+var positionsPath = flag.String("positions", "", "where to write the positions from -propagate, gzip compressed if it ends in .gz")
+
+func main() {
+	flag.Parse()
+
+	if *oldPath == "" || *newPath == "" {
+		flag.Usage()
+		log.Fatal("need both an -old and a -new catalog")
+	}
+	if *format != "json" && *format != "csv" {
+		flag.Usage()
+		log.Fatal("unknown format ", *format)
+	}
+
+	var target time.Time
+	if *propagate != "" {
+		if *positionsPath == "" {
+			flag.Usage()
+			log.Fatal("-propagate needs a -positions file to write to")
+		}
+		t, err := time.Parse("2006-01-02", *propagate)
+		if err != nil {
+			flag.Usage()
+			log.Fatal("could not parse propagation date ", err)
+		}
+		target = t
+	}
+
+	log.Println("loading", *oldPath)
+	oldCatalog, err := orbcatalog.Load(*oldPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("loading", *newPath)
+	newCatalog, err := orbcatalog.Load(*newPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes := orbcatalog.Diff(oldCatalog, newCatalog, orbcatalog.Tolerances{
+		SemimajorAxis:     *tolA,
+		Eccentricity:      *tolE,
+		Angle:             *tolAngle,
+		AbsoluteMagnitude: *tolH,
+	})
+	log.Println(len(changes), "changes")
+
+	out := os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if *format == "csv" {
+		err = writeCSV(out, changes)
+	} else {
+		err = writeJSON(out, changes)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *propagate != "" {
+		if err := writePositions(*positionsPath, changes, target); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func writeJSON(out io.Writer, changes []orbcatalog.Change) error {
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	for i := range changes {
+		if err := enc.Encode(&changes[i]); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// writeCSV writes one row per changed element. Added and removed objects get a single row with no element.
+func writeCSV(out io.Writer, changes []orbcatalog.Change) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"kind", "id", "old_id", "name", "element", "old", "new"}); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		row := []string{string(c.Kind), c.ID, c.OldID, c.Name, "", "", ""}
+		if len(c.Elements) == 0 {
+			if err := w.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, e := range c.Elements {
+			row[4] = e.Element
+			row[5] = strconv.FormatFloat(e.Old, 'g', -1, 64)
+			row[6] = strconv.FormatFloat(e.New, 'g', -1, 64)
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writePositions re-propagates only the orbits that are new or different in the newer catalog.
+func writePositions(path string, changes []orbcatalog.Change, target time.Time) (err error) {
+	f, err := orbcore.CreateCompressed(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	w := bufio.NewWriterSize(f, 64*1024)
+	count := 0
+	for _, c := range changes {
+		if c.New == nil {
+			continue
+		}
+		pos := orbcore.OrbitToPosition(orbcore.MeanMotionToDate(c.New, target))
+		if _, err := w.WriteString(pos.String() + "\n"); err != nil {
+			return err
+		}
+		count++
+	}
+	log.Println("propagated", count, "orbits")
+	return w.Flush()
+}
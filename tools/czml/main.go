@@ -0,0 +1,113 @@
+// Writes the planets and a filtered set of asteroids from an orbit catalog to a CZML document for viewing in Cesium.
+
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcatalog"
+	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+var inPath = flag.String("in", "", "optional catalog of asteroids to add, MPCORB.DAT or mpcorb_extended.json, optionally compressed")
+var outPath = flag.String("out", "", "the czml file to write, gzip compressed if it ends in .gz")
+var startDate = flag.String("start", "", "first date to show, YYYY-MM-DD")
+var endDate = flag.String("end", "", "last date to show, YYYY-MM-DD")
+var step = flag.Duration("step", 24*time.Hour, "time between samples")
+var filter = flag.String("filter", "", "only add asteroids matching this expression, for example 'neo && H < 18'")
+var limit = flag.Int("limit", 1000, "most asteroids to add")
+var labels = flag.Bool("labels", false, "label the asteroids as well as the planets")
+var planets = flag.Bool("planets", true, "add the eight planets")
+
+var planetColors = map[*orbdata.Body][4]int{
+	orbdata.Mercury: {183, 183, 183, 255},
+	orbdata.Venus:   {230, 200, 140, 255},
+	orbdata.Earth:   {80, 140, 255, 255},
+	orbdata.Mars:    {230, 90, 50, 255},
+	orbdata.Jupiter: {220, 170, 120, 255},
+	orbdata.Saturn:  {240, 220, 150, 255},
+	orbdata.Uranus:  {150, 220, 230, 255},
+	orbdata.Neptune: {80, 110, 230, 255},
+}
+
+func main() {
+	flag.Parse()
+
+	if *outPath == "" {
+		flag.Usage()
+		log.Fatal("need an -out file")
+	}
+	start, err := time.Parse("2006-01-02", *startDate)
+	if err != nil {
+		flag.Usage()
+		log.Fatal("could not parse start date ", err)
+	}
+	end, err := time.Parse("2006-01-02", *endDate)
+	if err != nil {
+		flag.Usage()
+		log.Fatal("could not parse end date ", err)
+	}
+	query, err := orbcatalog.ParseQuery(*filter)
+	if err != nil {
+		log.Fatal("could not parse filter ", err)
+	}
+
+	var asteroids []*orbcore.Orbit
+	if *inPath != "" {
+		log.Println("loading", *inPath)
+		catalog, err := orbcatalog.Load(*inPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		asteroids = catalog.Query(query)
+		if len(asteroids) > *limit {
+			log.Println(len(asteroids), "asteroids match, only writing the first", *limit)
+			asteroids = asteroids[:*limit]
+		}
+	}
+
+	f, err := orbcore.CreateCompressed(*outPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w, err := orbconvert.NewCZMLWriter(f, orbconvert.CZMLDocument{Name: "orbcalc", Start: start, End: end, Step: *step})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *planets {
+		for _, body := range []*orbdata.Body{
+			orbdata.Mercury, orbdata.Venus, orbdata.Earth, orbdata.Mars,
+			orbdata.Jupiter, orbdata.Saturn, orbdata.Uranus, orbdata.Neptune,
+		} {
+			style := orbconvert.DefaultCZMLStyle
+			style.Color = planetColors[body]
+			style.PointSize = 8
+			if err := w.WriteBody(body, &style); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	style := orbconvert.CZMLStyle{
+		Color:     [4]int{200, 200, 200, 180},
+		PointSize: 3,
+		PathWidth: 1,
+		Label:     *labels,
+	}
+	for _, orb := range asteroids {
+		if err := w.WriteOrbit(orb, &style); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := orbcore.FinishCompressed(f, w.Close); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("wrote", len(asteroids), "asteroids to", *outPath)
+}
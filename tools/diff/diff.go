@@ -1,12 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"math"
 
+	"github.com/emilyselwood/orbcalc/orbconvert"
 	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbhorizons"
 )
 
 func main() {
@@ -19,12 +23,12 @@ func main() {
 		log.Fatal("Need to have two input files A and B")
 	}
 
-	aRows, err := orbcore.ReadPositionFile(*inA)
+	aRows, err := readPositions(*inA)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	bRows, err := orbcore.ReadPositionFile(*inB)
+	bRows, err := readPositions(*inB)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -48,3 +52,37 @@ func main() {
 func compareRows(a *orbcore.Position, b *orbcore.Position) float64 {
 	return math.Abs(a.X-b.X) + math.Abs(a.Y-b.Y) + math.Abs(a.Z-b.Z)
 }
+
+// readPositions loads a csv file of positions, a saved Horizons vector table or a CCSDS OEM.
+func readPositions(path string) ([]*orbcore.Position, error) {
+	f, err := orbcore.OpenDecompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Contains(data, []byte("$$SOE")) {
+		table, err := orbhorizons.Read(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		return table.Positions(), nil
+	}
+	if bytes.Contains(data, []byte("CCSDS_OEM_VERS")) {
+		oem, err := orbconvert.ReadOEM(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		var result []*orbcore.Position
+		for _, s := range oem.Segments {
+			result = append(result, s.Positions()...)
+		}
+		return result, nil
+	}
+	return orbcore.ReadPositions(bytes.NewReader(data))
+}
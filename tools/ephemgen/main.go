@@ -0,0 +1,93 @@
+// Fits Chebyshev polynomials to the paths of every object in an orbit catalog over a date range and writes them to an
+// ephemeris file that can be read with orbephem.
+
+package main
+
+import (
+	"flag"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcatalog"
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbephem"
+)
+
+var inPath = flag.String("in", "", "the catalog to read, MPCORB.DAT or mpcorb_extended.json, optionally compressed")
+var outPath = flag.String("out", "", "the ephemeris file to write, gzip compressed if it ends in .gz")
+var startDate = flag.String("start", "", "first date to cover, YYYY-MM-DD")
+var endDate = flag.String("end", "", "last date to cover, YYYY-MM-DD")
+var tolerance = flag.Float64("tol", 10, "largest allowed position error in km")
+var filter = flag.String("filter", "", "only fit orbits matching this expression, for example 'neo'")
+
+func main() {
+	flag.Parse()
+
+	if *inPath == "" || *outPath == "" {
+		flag.Usage()
+		log.Fatal("need an -in catalog and an -out file")
+	}
+	start, err := time.Parse("2006-01-02", *startDate)
+	if err != nil {
+		flag.Usage()
+		log.Fatal("could not parse start date ", err)
+	}
+	end, err := time.Parse("2006-01-02", *endDate)
+	if err != nil {
+		flag.Usage()
+		log.Fatal("could not parse end date ", err)
+	}
+	query, err := orbcatalog.ParseQuery(*filter)
+	if err != nil {
+		log.Fatal("could not parse filter ", err)
+	}
+
+	log.Println("loading", *inPath)
+	catalog, err := orbcatalog.Load(*inPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	orbits := catalog.Query(query)
+	log.Println("fitting", len(orbits), "orbits")
+
+	w, err := orbephem.Create(*outPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fitted := make(chan *orbephem.Series, 1000)
+	var wg sync.WaitGroup
+	for _, chunk := range orbcatalog.Chunks(orbits, runtime.NumCPU()) {
+		wg.Add(1)
+		go func(chunk []*orbcore.Orbit) {
+			defer wg.Done()
+			for _, orb := range chunk {
+				s, err := orbephem.Fit(orb, start, end, *tolerance)
+				if err != nil {
+					log.Println("skipping", err)
+					continue
+				}
+				fitted <- s
+			}
+		}(chunk)
+	}
+	go func() {
+		wg.Wait()
+		close(fitted)
+	}()
+
+	written, pieces := 0, 0
+	for s := range fitted {
+		if err := w.Write(s); err != nil {
+			log.Fatal(err)
+		}
+		written++
+		pieces += s.Pieces()
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("wrote", written, "objects in", pieces, "pieces")
+}
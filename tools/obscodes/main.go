@@ -0,0 +1,84 @@
+/*
+This tool converts a copy of the MPC observatory code list (ObsCodes.html) into the go source file that orbdata
+bundles, so the list is available without any extra data files.
+*/
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+const header = `// Code generated by tools/obscodes from the MPC ObsCodes list. DO NOT EDIT.
+
+package orbdata
+
+// observatoryCodes is the MPC list of observatory codes, parsed by ParseObservatoryCodes.
+const observatoryCodes = ` + "`"
+
+func main() {
+	in := flag.String("in", "", "path to the MPC ObsCodes file")
+	out := flag.String("out", "observatory_codes.go", "path of the go file to write")
+
+	flag.Parse()
+
+	if *in == "" {
+		log.Fatal("No input file provided. Use the -in /path/to/ObsCodes.html")
+	}
+
+	if err := convert(*in, *out); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func convert(inPath, outPath string) error {
+	in, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+
+	if err := write(in, out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// write copies the observatory lines from in to out wrapped in the go source.
+func write(in io.Reader, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	if _, err := w.WriteString(header); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		// Skip the html wrapper and anything we could not put inside a raw string.
+		if len(line) < 4 || line[3] != ' ' || strings.HasPrefix(line, "<") || strings.Contains(line, "`") {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if _, err := w.WriteString("`\n"); err != nil {
+		return err
+	}
+	return w.Flush()
+}
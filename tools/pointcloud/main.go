@@ -0,0 +1,134 @@
+// Writes the positions of every object in an orbit catalog on a date to a PLY point cloud, and optionally the orbits of
+// the planets to an OBJ file, for rendering in Blender.
+
+package main
+
+import (
+	"flag"
+	"image/color"
+	"log"
+	"math"
+	"time"
+
+	"github.com/emilyselwood/orbcalc/orbcatalog"
+	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbcore"
+	"github.com/emilyselwood/orbcalc/orbdata"
+)
+
+var inPath = flag.String("in", "", "catalog of asteroids, MPCORB.DAT or mpcorb_extended.json, optionally compressed")
+var outPath = flag.String("out", "", "the ply file to write")
+var orbitsPath = flag.String("orbits", "", "optional obj file to write the orbits of the planets to")
+var date = flag.String("date", "", "date to place the objects at, YYYY-MM-DD")
+var filter = flag.String("filter", "", "only write objects matching this expression, for example 'a < 6'")
+var colorBy = flag.String("color", "class", "colour points by orbit class or by a numeric filter field such as a, e, i or H")
+var low = flag.Float64("min", math.NaN(), "value of the colour field at the bottom of the colour ramp, the smallest value if not set")
+var high = flag.Float64("max", math.NaN(), "value of the colour field at the top of the colour ramp, the largest value if not set")
+var unit = flag.Float64("unit", orbdata.AU, "length in km of one unit in the output files")
+var segments = flag.Int("segments", 360, "number of line segments in each planet orbit")
+var ascii = flag.Bool("ascii", false, "write a text ply file rather than binary")
+
+func main() {
+	flag.Parse()
+
+	if *inPath == "" || *outPath == "" {
+		flag.Usage()
+		log.Fatal("need an -in catalog and an -out file")
+	}
+	t, err := time.Parse("2006-01-02", *date)
+	if err != nil {
+		flag.Usage()
+		log.Fatal("could not parse date ", err)
+	}
+	query, err := orbcatalog.ParseQuery(*filter)
+	if err != nil {
+		log.Fatal("could not parse filter ", err)
+	}
+
+	var value func(*orbcore.Orbit) float64
+	if *colorBy != "class" {
+		if value, err = orbcatalog.NumberField(*colorBy); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	log.Println("loading", *inPath)
+	catalog, err := orbcatalog.Load(*inPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	orbits := catalog.Query(query)
+
+	colour := orbconvert.ClassColor
+	if value != nil {
+		colour = scalarColours(value, orbits)
+	}
+
+	f, err := orbcore.CreateCompressed(*outPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := orbconvert.NewPLYWriter(f)
+	w.Unit = *unit
+	w.ASCII = *ascii
+	for _, orb := range orbits {
+		p := orbcore.OrbitToPosition(orbcore.MeanMotionToDate(orb, t))
+		if err := w.WritePoint(p, colour(orb)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := orbcore.FinishCompressed(f, w.Close); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("wrote", len(orbits), "points to", *outPath)
+
+	if *orbitsPath != "" {
+		writePlanets(*orbitsPath, t)
+	}
+}
+
+// scalarColours colours orbits by value, spreading the colour ramp over the range of values unless -min or -max are set.
+func scalarColours(value func(*orbcore.Orbit) float64, orbits []*orbcore.Orbit) func(*orbcore.Orbit) color.RGBA {
+	min, max := *low, *high
+	if math.IsNaN(min) || math.IsNaN(max) {
+		lowest, highest := math.Inf(1), math.Inf(-1)
+		for _, orb := range orbits {
+			v := value(orb)
+			lowest, highest = math.Min(lowest, v), math.Max(highest, v)
+		}
+		if math.IsNaN(min) {
+			min = lowest
+		}
+		if math.IsNaN(max) {
+			max = highest
+		}
+	}
+	log.Printf("colouring %v from %v to %v", *colorBy, min, max)
+
+	return func(orb *orbcore.Orbit) color.RGBA {
+		return orbconvert.ScalarColor(value(orb), min, max)
+	}
+}
+
+func writePlanets(path string, t time.Time) {
+	f, err := orbcore.CreateCompressed(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := orbconvert.NewOBJWriter(f)
+	w.Unit = *unit
+	for _, body := range []*orbdata.Body{
+		orbdata.Mercury, orbdata.Venus, orbdata.Earth, orbdata.Mars,
+		orbdata.Jupiter, orbdata.Saturn, orbdata.Uranus, orbdata.Neptune,
+	} {
+		if err := w.WriteOrbit(body.OrbitAt(t), *segments); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := orbcore.FinishCompressed(f, w.Flush); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("wrote the planet orbits to", path)
+}
@@ -8,7 +8,6 @@ import (
 	"flag"
 	"fmt"
 	"github.com/paulbellamy/ratecounter"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -17,8 +16,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/emilyselwood/gompcreader"
-	"github.com/emilyselwood/orbcalc/orbconvert"
+	"github.com/emilyselwood/orbcalc/orbcatalog"
 	"github.com/emilyselwood/orbcalc/orbcore"
 	"github.com/emilyselwood/orbcalc/orbdata"
 )
@@ -33,8 +31,9 @@ const channelSize = 100000
 var generate = flag.Bool("gen", false, "Should the data file be generated")
 var dataPath = flag.String("data", "", "path to the minor planet center data file")
 var genDate = flag.String("date", "2019-01-01", "Date to generate data for. YYYY-MM-DD format")
+var filter = flag.String("filter", "", "only generate data for orbits matching this expression, for example 'H < 15'")
 
-var asteroidData map[string]objectData
+var catalog *orbcatalog.Catalog
 
 func main() {
 	flag.Parse()
@@ -49,6 +48,11 @@ func main() {
 		log.Fatal("generation date parameter is required")
 	}
 
+	log.Println("loading data")
+	if err := prepObjectData(*dataPath); err != nil {
+		log.Fatal("Could not load data", err)
+	}
+
 	if *generate {
 		targetTime, err := time.Parse("2006-01-02", *genDate)
 		if err != nil {
@@ -56,13 +60,14 @@ func main() {
 			log.Fatal("generation date parameter is required", err)
 		}
 
-		generateData(targetTime)
-		generateMajorPlanetData()
-	}
+		query, err := orbcatalog.ParseQuery(*filter)
+		if err != nil {
+			flag.Usage()
+			log.Fatal("could not parse filter ", err)
+		}
 
-	log.Println("loading data")
-	if err := prepObjectData(*dataPath); err != nil {
-		log.Fatal("Could not load data", err)
+		generateData(catalog.Query(query), targetTime)
+		generateMajorPlanetData()
 	}
 
 	log.Println("starting server")
@@ -85,19 +90,19 @@ func logWrapper(child func(rw http.ResponseWriter, req *http.Request)) func(rw h
 func lookupAsteroid(rw http.ResponseWriter, req *http.Request) {
 	id := path.Base(req.URL.Path)
 	id = strings.Replace(id, "+", " ", -1)
-	v, ok := asteroidData[id]
+	orb, ok := catalog.Lookup(id)
 	if !ok {
 		rw.WriteHeader(404)
 		return
 	}
-	if v.Orbit == nil {
-		v.Orbit = make([]point, 366)
-		for i, p := range orbcore.MeanMotionFullOrbit(v.toOrbit(), 365) {
-			pos := orbcore.OrbitToPosition(p)
-			v.Orbit[i].X = pos.X
-			v.Orbit[i].Y = pos.Y
-			v.Orbit[i].Z = pos.Z
-		}
+
+	v := newObjectData(orb)
+	v.Orbit = make([]point, 366)
+	for i, p := range orbcore.MeanMotionFullOrbit(orb, 365) {
+		pos := orbcore.OrbitToPosition(p)
+		v.Orbit[i].X = pos.X
+		v.Orbit[i].Y = pos.Y
+		v.Orbit[i].Z = pos.Z
 	}
 
 	if err := json.NewEncoder(rw).Encode(v); err != nil {
@@ -106,7 +111,7 @@ func lookupAsteroid(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func generateData(d time.Time) {
+func generateData(orbits []*orbcore.Orbit, d time.Time) {
 
 	log.Println("generating data...")
 
@@ -134,9 +139,9 @@ func generateData(d time.Time) {
 	var fanGroup sync.WaitGroup
 	var complete sync.WaitGroup
 
-	// Setup stage one which reads in the input file, parses the records from it and passes them on to the next stage.
+	// Setup stage one which passes the loaded orbits on to the next stage.
 	readGroup.Add(1)
-	go stageRead(*dataPath, stage1, &readGroup, counter1)
+	go stageRead(orbits, stage1, &readGroup, counter1)
 
 	// Stage two progates an object forward one day and then passes it on.
 	for i := 0; i < processors; i++ {
@@ -160,29 +165,14 @@ func generateData(d time.Time) {
 
 }
 
-// stageRead opens a file using the gompcreader project and reads out orbital information.
-func stageRead(inputFile string, output chan *orbcore.Orbit, wg *sync.WaitGroup, counter *ratecounter.RateCounter) {
-	mpcReader, err := gompcreader.NewMpcReader(inputFile)
-	if err != nil {
-		log.Fatal("error creating mpcReader ", err)
-	}
-	defer mpcReader.Close()
+// stageRead feeds the selected orbits into the processing pipeline.
+func stageRead(orbits []*orbcore.Orbit, output chan *orbcore.Orbit, wg *sync.WaitGroup, counter *ratecounter.RateCounter) {
 	defer close(output)
 	defer wg.Done()
 
-	var count int
-	result, err := mpcReader.ReadEntry()
-	for err == nil {
-		orb := orbconvert.ConvertFromMinorPlanet(result)
-
+	for _, orb := range orbits {
 		output <- orb
-
 		counter.Incr(1)
-		count++
-		result, err = mpcReader.ReadEntry()
-	}
-	if err != io.EOF {
-		log.Fatal("error reading line ", count, " -> ", err)
 	}
 }
 
@@ -230,7 +220,7 @@ func filename(i int) string {
 }
 
 func generateMajorPlanetData() {
-	for _, p := range orbdata.SolarSystem {
+	for _, p := range orbdata.SolarSystemAt(time.Now()) {
 		if err := fileForPlanet(p); err != nil {
 			log.Fatal(err)
 		}
@@ -262,35 +252,12 @@ func fileForPlanet(orb orbcore.Orbit) error {
 }
 
 func prepObjectData(inputPath string) error {
-	mpcReader, err := gompcreader.NewMpcReader(inputPath)
+	c, err := orbcatalog.Load(inputPath)
 	if err != nil {
-		log.Fatal("error creating mpcReader ", err)
-	}
-	defer mpcReader.Close()
-
-	asteroidData = make(map[string]objectData)
-
-	result, err := mpcReader.ReadEntry()
-	for err == nil {
-		ob := objectData{
-			ID:                          result.ID,
-			Epoch:                       result.Epoch,
-			MeanAnomalyEpoch:            orbconvert.DegToRad(result.MeanAnomalyEpoch),
-			ArgumentOfPerihelion:        orbconvert.DegToRad(result.ArgumentOfPerihelion),
-			LongitudeOfTheAscendingNode: orbconvert.DegToRad(result.LongitudeOfTheAscendingNode),
-			InclinationToTheEcliptic:    orbconvert.DegToRad(result.InclinationToTheEcliptic),
-			OrbitalEccentricity:         result.OrbitalEccentricity,
-			MeanDailyMotion:             result.MeanDailyMotion,
-			SemimajorAxis:               orbconvert.AuToKm(result.SemimajorAxis),
-			Orbit:                       nil,
-		}
-
-		asteroidData[ob.ID] = ob
-		result, err = mpcReader.ReadEntry()
-	}
-	if err != io.EOF {
 		return err
 	}
+	catalog = c
+	log.Println("loaded", catalog.Len(), "objects")
 	return nil
 }
 
@@ -307,18 +274,17 @@ type objectData struct {
 	Orbit                       []point
 }
 
-func (o *objectData) toOrbit() *orbcore.Orbit {
-	return &orbcore.Orbit{
-		ID:                          o.ID,
-		ParentGrav:                  orbdata.SunGrav,
-		Epoch:                       o.Epoch,
-		MeanAnomalyEpoch:            o.MeanAnomalyEpoch,
-		ArgumentOfPerihelion:        o.ArgumentOfPerihelion,
-		LongitudeOfTheAscendingNode: o.LongitudeOfTheAscendingNode,
-		InclinationToTheEcliptic:    o.InclinationToTheEcliptic,
-		OrbitalEccentricity:         o.OrbitalEccentricity,
-		MeanDailyMotion:             o.MeanDailyMotion,
-		SemimajorAxis:               o.SemimajorAxis,
+func newObjectData(orb *orbcore.Orbit) objectData {
+	return objectData{
+		ID:                          orb.ID,
+		Epoch:                       orb.Epoch,
+		MeanAnomalyEpoch:            orb.MeanAnomalyEpoch,
+		ArgumentOfPerihelion:        orb.ArgumentOfPerihelion,
+		LongitudeOfTheAscendingNode: orb.LongitudeOfTheAscendingNode,
+		InclinationToTheEcliptic:    orb.InclinationToTheEcliptic,
+		OrbitalEccentricity:         orb.OrbitalEccentricity,
+		MeanDailyMotion:             orb.MeanDailyMotion,
+		SemimajorAxis:               orb.SemimajorAxis,
 	}
 }
 
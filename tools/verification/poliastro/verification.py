@@ -38,6 +38,26 @@ testObjects = {
         argp = 1.2761023695175595 * u.rad,
         nu = 6.147582300011738 * u.rad,
         epoch = Time('2018-01-01T00:00:00Z', scale='utc', format='isot')
+    ),
+    "Vesta": Orbit.from_classical(
+        Sun,
+        a = 3.5327717298e+08 * u.km,
+        ecc = 0.08874 * u.one,
+        inc = 0.12465490583593901 * u.rad,
+        raan = 1.8100809672433191 * u.rad,
+        argp = 2.6469663435746003 * u.rad,
+        nu = 2.986010880623069 * u.rad,
+        epoch = Time('2020-05-31T00:00:00Z', scale='utc', format='isot')
+    ),
+    "Pluto": Orbit.from_classical(
+        Sun,
+        a = 5.9064457015665e+09 * u.km,
+        ecc = 0.2488273 * u.one,
+        inc = 0.29914964427853585 * u.rad,
+        raan = 1.9251668757698697 * u.rad,
+        argp = 1.9855734648661878 * u.rad,
+        nu = 0.4395042529961709 * u.rad,
+        epoch = Time('2000-01-01T11:58:55.816Z', scale='utc', format='isot')
     )
 }
 
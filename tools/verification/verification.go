@@ -42,7 +42,8 @@ var testObjects = []*orbcore.Orbit{
 		OrbitalEccentricity:         0.0755347,
 		SemimajorAxis:               4.1394459238740003e+08,
 	},
-	// TODO: More objects at least vesta and pluto as test cases
+	&orbdata.VestaOrbit,
+	&orbdata.PlutoOrbit,
 }
 
 func main() {